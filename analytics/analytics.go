@@ -0,0 +1,85 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package analytics derives driving information from decoded AVL records, e.g. trips of a vehicle.
+// Records of one device are expected, use one value of each type per device.
+package analytics
+
+import (
+	"math"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// IO elements used by the analytics
+const (
+	ioIgnition      = 239 // Ignition, 0 off, 1 on
+	ioMovement      = 240 // Movement, 0 standing, 1 moving
+	ioTotalOdometer = 16  // Total Odometer in meters
+	ioTripOdometer  = 199 // Trip Odometer in meters since the start of the trip
+)
+
+// earthRadius is the mean Earth radius in meters
+const earthRadius = 6371000
+
+// Point is a position in degrees
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Position returns the position of the record in degrees
+func Position(data *teltonikaparser.AvlData) Point {
+	return Point{Lat: float64(data.Lat) / 1e7, Lng: float64(data.Lng) / 1e7}
+}
+
+// valid returns false for the zero position which devices send without a GPS fix
+func (p Point) valid() bool {
+	return p.Lat != 0 || p.Lng != 0
+}
+
+// recordTime returns the timestamp of the record
+func recordTime(data *teltonikaparser.AvlData) time.Time {
+	return time.Unix(0, int64(data.UtimeMs)*int64(time.Millisecond)).UTC()
+}
+
+// ioValue returns the value of the IO element of the record as an unsigned number
+func ioValue(data *teltonikaparser.AvlData, id uint16) (uint64, bool) {
+	for _, el := range data.Elements {
+		if el.IOID != id || len(el.Value) == 0 || len(el.Value) > 8 {
+			continue
+		}
+		var value uint64
+		for _, b := range el.Value {
+			value = value<<8 | uint64(b)
+		}
+		return value, true
+	}
+	return 0, false
+}
+
+// Distance returns the great-circle distance of two points in meters by the haversine formula
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// RecordDistance returns the great-circle distance of positions of two records in meters,
+// it is zero if one of them has no position
+func RecordDistance(a, b *teltonikaparser.AvlData) float64 {
+	from, to := Position(a), Position(b)
+	if !from.valid() || !to.valid() {
+		return 0
+	}
+	return Distance(from, to)
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
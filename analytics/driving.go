@@ -0,0 +1,273 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// IO elements of the green driving, over speeding and idling scenarios
+const (
+	ioEcoScore          = 15  // Eco Score multiplied by 100, average amount of events on some distance
+	ioIdling            = 251 // Idling, 0 moving, 1 idling
+	ioGreenDrivingType  = 253 // Green driving type, 1 harsh acceleration, 2 harsh braking, 3 harsh cornering
+	ioGreenDrivingValue = 254 // Green driving value, g or rad multiplied by 100
+	ioOverSpeeding      = 255 // Over Speeding in km/h
+)
+
+// DrivingEventType is a kind of a DrivingEvent
+type DrivingEventType int
+
+// Types of driving events
+const (
+	HarshAcceleration DrivingEventType = iota + 1 // HarshAcceleration has Value in g
+	HarshBraking                                  // HarshBraking has Value in g
+	HarshCornering                                // HarshCornering has Value in rad
+	OverspeedStart                                // OverspeedStart has Speed of the vehicle
+	OverspeedEnd                                  // OverspeedEnd has Speed of the vehicle and Duration of the over speeding
+	IdlingStart                                   // IdlingStart is the start of idling
+	IdlingEnd                                     // IdlingEnd has Duration of the idling
+)
+
+func (t DrivingEventType) String() string {
+	switch t {
+	case HarshAcceleration:
+		return "harsh acceleration"
+	case HarshBraking:
+		return "harsh braking"
+	case HarshCornering:
+		return "harsh cornering"
+	case OverspeedStart:
+		return "overspeed start"
+	case OverspeedEnd:
+		return "overspeed end"
+	case IdlingStart:
+		return "idling start"
+	case IdlingEnd:
+		return "idling end"
+	}
+	return fmt.Sprintf("DrivingEventType(%d)", int(t))
+}
+
+// DrivingEvent is an event of the driving behaviour reported by the device
+type DrivingEvent struct {
+	Type     DrivingEventType
+	Time     time.Time     // Time of the record
+	Position Point         // Position of the record
+	Value    float64       // Value of a harsh event, g for acceleration and braking, rad for cornering
+	Speed    uint16        // Speed in km/h, the Over Speeding IO for over speeding events, the speed of the record otherwise
+	Duration time.Duration // Duration of the over speeding or idling for the end events
+}
+
+// Defaults of Driving
+const (
+	DefaultDrivingHarshPenalty     = 2   // DefaultDrivingHarshPenalty is used when HarshPenalty is not set
+	DefaultDrivingOverspeedPenalty = 1   // DefaultDrivingOverspeedPenalty is used when OverspeedPenalty is not set
+	DefaultDrivingIdlePenalty      = 0.5 // DefaultDrivingIdlePenalty is used when IdlePenalty is not set
+	DefaultDrivingMinDistance      = 10  // DefaultDrivingMinDistance in km is used when MinDistance is not set
+)
+
+// DrivingScore rates the driving during a trip
+type DrivingScore struct {
+	Accelerations int           // Accelerations is the number of harsh accelerations
+	Brakings      int           // Brakings is the number of harsh brakings
+	Cornerings    int           // Cornerings is the number of harsh cornerings
+	Overspeeds    int           // Overspeeds is the number of over speedings
+	OverspeedTime time.Duration // OverspeedTime is the time of over speeding during the trip
+	IdleTime      time.Duration // IdleTime is the time of idling during the trip reported by the Idling IO
+	Score         float64       // Score from 0 to 100, 100 is the best
+}
+
+// Driving turns the green driving IO 253 and 254, the over speeding IO 255 and the idling IO 251 of one device into DrivingEvents
+// and scores trips by them. An eventual IO is taken from the record which it triggered, its EventID is the IO ID,
+// other records may repeat the last value. Records have to come in order of time, older records are ignored.
+//
+// The device sends the same Over Speeding IO at the start and at the end of over speeding, so without SpeedLimit
+// the events alternate and a lost record swaps them until Reset. With SpeedLimit a value above it is the start and other values are the end,
+// a repeated start or an end without start is skipped.
+//
+// Score starts at 100 and it is lowered by HarshPenalty for every harsh event per 100 km, by OverspeedPenalty for every percent
+// of the trip time over speeding and by IdlePenalty for every percent of the trip time idling.
+// The zero value is ready to use, it is not safe for concurrent use.
+type Driving struct {
+	HarshPenalty     float64 // HarshPenalty for a harsh event per 100 km, zero means DefaultDrivingHarshPenalty
+	OverspeedPenalty float64 // OverspeedPenalty for a percent of the time over speeding, zero means DefaultDrivingOverspeedPenalty
+	IdlePenalty      float64 // IdlePenalty for a percent of the time idling, zero means DefaultDrivingIdlePenalty
+	MinDistance      float64 // MinDistance in km over which harsh events are counted for shorter trips, zero means DefaultDrivingMinDistance
+	SpeedLimit       uint16  // SpeedLimit in km/h of the over speeding scenario of the device, zero means unknown
+
+	started        bool
+	last           time.Time // time of the last record
+	overspeedSince time.Time // start of the over speeding, zero if the vehicle does not over speed
+	idlingSince    time.Time // start of the idling, zero if the vehicle does not idle
+	ecoScore       float64
+	hasEcoScore    bool
+}
+
+// Add takes the next record of the device and returns its driving events, usually none
+func (d *Driving) Add(data teltonikaparser.AvlData) []DrivingEvent {
+	t := recordTime(&data)
+	if d.started && !t.After(d.last) {
+		return nil
+	}
+	d.started, d.last = true, t
+
+	if value, ok := ioValue(&data, ioEcoScore); ok {
+		d.ecoScore, d.hasEcoScore = float64(value)/100, true
+	}
+
+	event := DrivingEvent{Time: t, Position: Position(&data), Speed: data.Speed}
+	switch data.EventID {
+	case ioGreenDrivingType, ioGreenDrivingValue:
+		kind, ok := ioValue(&data, ioGreenDrivingType)
+		if !ok || kind < 1 || kind > 3 {
+			return nil
+		}
+		event.Type = HarshAcceleration + DrivingEventType(kind-1)
+		if value, ok := ioValue(&data, ioGreenDrivingValue); ok {
+			event.Value = float64(value) / 100
+		}
+	case ioOverSpeeding:
+		speed, ok := ioValue(&data, ioOverSpeeding)
+		if !ok {
+			return nil
+		}
+		event.Speed = uint16(speed)
+		start := d.overspeedSince.IsZero()
+		if d.SpeedLimit != 0 {
+			// the value tells the start from the end, so a lost record does not swap them
+			start = speed > uint64(d.SpeedLimit)
+			if start != d.overspeedSince.IsZero() {
+				return nil
+			}
+		}
+		if start {
+			event.Type, d.overspeedSince = OverspeedStart, t
+		} else {
+			event.Type, event.Duration, d.overspeedSince = OverspeedEnd, t.Sub(d.overspeedSince), time.Time{}
+		}
+	case ioIdling:
+		idling, ok := ioValue(&data, ioIdling)
+		switch {
+		case !ok:
+			return nil
+		case idling != 0 && d.idlingSince.IsZero():
+			event.Type, d.idlingSince = IdlingStart, t
+		case idling == 0 && !d.idlingSince.IsZero():
+			event.Type, event.Duration, d.idlingSince = IdlingEnd, t.Sub(d.idlingSince), time.Time{}
+		default:
+			return nil
+		}
+	default:
+		return nil
+	}
+	return []DrivingEvent{event}
+}
+
+// EcoScore returns the last Eco Score IO 15 of the device, it is the average amount of events on some distance, lower is better
+func (d *Driving) EcoScore() (float64, bool) {
+	return d.ecoScore, d.hasEcoScore
+}
+
+// Reset forgets the open over speeding and idling, e.g. after a gap in records in which their end events were lost
+func (d *Driving) Reset() {
+	d.overspeedSince, d.idlingSince = time.Time{}, time.Time{}
+}
+
+// Score rates the trip by the driving events, events out of the trip are skipped
+// and over speeding and idling periods are cut to the trip by their end events.
+// A period which is still open counts up to the end of the trip.
+func (d *Driving) Score(trip *Trip, events []DrivingEvent) DrivingScore {
+	var score DrivingScore
+	for _, event := range events {
+		if event.Time.Before(trip.Start) || event.Time.After(trip.End) {
+			// a period which ends after the trip is cut to it
+			if event.Time.After(trip.End) && event.Time.Add(-event.Duration).Before(trip.End) {
+				d.addPeriod(&score, trip, event)
+			}
+			continue
+		}
+		switch event.Type {
+		case HarshAcceleration:
+			score.Accelerations++
+		case HarshBraking:
+			score.Brakings++
+		case HarshCornering:
+			score.Cornerings++
+		case OverspeedStart:
+			score.Overspeeds++
+		case OverspeedEnd, IdlingEnd:
+			d.addPeriod(&score, trip, event)
+		}
+	}
+	if !d.overspeedSince.IsZero() {
+		d.addPeriod(&score, trip, DrivingEvent{Type: OverspeedEnd, Time: trip.End, Duration: trip.End.Sub(d.overspeedSince)})
+	}
+	if !d.idlingSince.IsZero() {
+		d.addPeriod(&score, trip, DrivingEvent{Type: IdlingEnd, Time: trip.End, Duration: trip.End.Sub(d.idlingSince)})
+	}
+
+	score.Score = 100
+	harsh := float64(score.Accelerations + score.Brakings + score.Cornerings)
+	score.Score -= d.harshPenalty() * harsh / (math.Max(trip.Distance/1000, d.minDistance()) / 100)
+	if duration := trip.Duration(); duration > 0 {
+		score.Score -= d.overspeedPenalty() * 100 * float64(score.OverspeedTime) / float64(duration)
+		score.Score -= d.idlePenalty() * 100 * float64(score.IdleTime) / float64(duration)
+	}
+	score.Score = math.Max(0, math.Min(100, score.Score))
+	return score
+}
+
+// addPeriod adds the part of the over speeding or idling period which is in the trip
+func (d *Driving) addPeriod(score *DrivingScore, trip *Trip, event DrivingEvent) {
+	start, end := event.Time.Add(-event.Duration), event.Time
+	if start.Before(trip.Start) {
+		start = trip.Start
+	}
+	if end.After(trip.End) {
+		end = trip.End
+	}
+	if !end.After(start) {
+		return
+	}
+	switch event.Type {
+	case OverspeedEnd:
+		score.OverspeedTime += end.Sub(start)
+	case IdlingEnd:
+		score.IdleTime += end.Sub(start)
+	}
+}
+
+func (d *Driving) harshPenalty() float64 {
+	if d.HarshPenalty == 0 {
+		return DefaultDrivingHarshPenalty
+	}
+	return d.HarshPenalty
+}
+
+func (d *Driving) overspeedPenalty() float64 {
+	if d.OverspeedPenalty == 0 {
+		return DefaultDrivingOverspeedPenalty
+	}
+	return d.OverspeedPenalty
+}
+
+func (d *Driving) idlePenalty() float64 {
+	if d.IdlePenalty == 0 {
+		return DefaultDrivingIdlePenalty
+	}
+	return d.IdlePenalty
+}
+
+func (d *Driving) minDistance() float64 {
+	if d.MinDistance == 0 {
+		return DefaultDrivingMinDistance
+	}
+	return d.MinDistance
+}
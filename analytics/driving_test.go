@@ -0,0 +1,222 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// trigger sets the EventID of the record and appends 1 byte IO elements given as ID and value pairs
+func trigger(data *teltonikaparser.AvlData, eventID uint16, io ...uint16) {
+	data.EventID = eventID
+	for i := 0; i+1 < len(io); i += 2 {
+		data.Elements = append(data.Elements, teltonikaparser.Element{Length: 1, IOID: io[i], Value: []byte{byte(io[i+1])}})
+	}
+}
+
+// drivingTrack returns 30 records with harsh events, over speeding from the second 80 to 140 and idling from the second 200 to 230
+func drivingTrack() []teltonikaparser.AvlData {
+	records := (&track{lat: 49.2, lng: 16.6}).add(30, 40, 0.001, nil).records
+	trigger(&records[2], ioGreenDrivingType, ioGreenDrivingType, 1, ioGreenDrivingValue, 35)
+	trigger(&records[5], ioGreenDrivingType, ioGreenDrivingType, 2, ioGreenDrivingValue, 120)
+	// the record was not triggered by the green driving
+	trigger(&records[6], 0, ioGreenDrivingType, 3, ioGreenDrivingValue, 80)
+	trigger(&records[8], ioOverSpeeding, ioOverSpeeding, 95)
+	records[10].Elements = append(records[10].Elements, teltonikaparser.Element{Length: 2, IOID: ioEcoScore, Value: []byte{0, 123}})
+	trigger(&records[14], ioOverSpeeding, ioOverSpeeding, 92)
+	trigger(&records[20], ioIdling, ioIdling, 1)
+	trigger(&records[23], ioIdling, ioIdling, 0)
+	trigger(&records[24], ioIdling, ioIdling, 0)
+	trigger(&records[25], ioGreenDrivingValue, ioGreenDrivingType, 3, ioGreenDrivingValue, 50)
+	return records
+}
+
+func TestDrivingEvents(t *testing.T) {
+	records := drivingTrack()
+	driving := &Driving{}
+	var events []DrivingEvent
+	for _, data := range records {
+		events = append(events, driving.Add(data)...)
+	}
+	// the older record is ignored
+	events = append(events, driving.Add(records[2])...)
+
+	expected := []DrivingEvent{
+		{Type: HarshAcceleration, Time: at(20), Value: 0.35, Speed: 40},
+		{Type: HarshBraking, Time: at(50), Value: 1.2, Speed: 40},
+		{Type: OverspeedStart, Time: at(80), Speed: 95},
+		{Type: OverspeedEnd, Time: at(140), Speed: 92, Duration: time.Minute},
+		{Type: IdlingStart, Time: at(200), Speed: 40},
+		{Type: IdlingEnd, Time: at(230), Speed: 40, Duration: 30 * time.Second},
+		{Type: HarshCornering, Time: at(250), Value: 0.5, Speed: 40},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %v events, got %v, %+v", len(expected), len(events), events)
+	}
+	for i, event := range events {
+		want := expected[i]
+		if event.Type != want.Type || !event.Time.Equal(want.Time) || math.Abs(event.Value-want.Value) > 1e-9 ||
+			event.Speed != want.Speed || event.Duration != want.Duration || !event.Position.valid() {
+			t.Errorf("Event %v is %+v, want %+v", i, event, want)
+		}
+	}
+
+	if score, ok := driving.EcoScore(); !ok || math.Abs(score-1.23) > 1e-9 {
+		t.Errorf("Eco score is %v, %v", score, ok)
+	}
+	if _, ok := (&Driving{}).EcoScore(); ok {
+		t.Errorf("Eco score of no records is known")
+	}
+}
+
+func TestDrivingScore(t *testing.T) {
+	driving := &Driving{}
+	var events []DrivingEvent
+	for _, data := range drivingTrack() {
+		events = append(events, driving.Add(data)...)
+	}
+
+	testCases := []struct {
+		Name     string
+		Driving  *Driving
+		Trip     Trip
+		Expected DrivingScore
+	}{
+		{
+			Name:    "Whole",
+			Driving: driving,
+			Trip:    Trip{Start: at(0), End: at(290), Distance: 3225},
+			// 3 harsh events on the minimal 10 km, 20.69 % over speeding and 10.34 % idling
+			Expected: DrivingScore{Accelerations: 1, Brakings: 1, Cornerings: 1, Overspeeds: 1, OverspeedTime: time.Minute, IdleTime: 30 * time.Second, Score: 14.14},
+		},
+		{
+			Name:     "Long",
+			Driving:  driving,
+			Trip:     Trip{Start: at(0), End: at(290), Distance: 300000},
+			Expected: DrivingScore{Accelerations: 1, Brakings: 1, Cornerings: 1, Overspeeds: 1, OverspeedTime: time.Minute, IdleTime: 30 * time.Second, Score: 72.14},
+		},
+		{
+			Name:    "Cut",
+			Driving: driving,
+			Trip:    Trip{Start: at(100), End: at(220), Distance: 1000},
+			// the over speeding from 80 and the idling to 230 are cut to the trip
+			Expected: DrivingScore{OverspeedTime: 40 * time.Second, IdleTime: 20 * time.Second, Score: 58.33},
+		},
+		{
+			Name:     "Penalties",
+			Driving:  &Driving{HarshPenalty: 1, OverspeedPenalty: 0.5, IdlePenalty: 1, MinDistance: 1},
+			Trip:     Trip{Start: at(100), End: at(220), Distance: 1000},
+			Expected: DrivingScore{OverspeedTime: 40 * time.Second, IdleTime: 20 * time.Second, Score: 66.67},
+		},
+		{
+			Name:     "Worst",
+			Driving:  &Driving{HarshPenalty: 100},
+			Trip:     Trip{Start: at(0), End: at(290), Distance: 3225},
+			Expected: DrivingScore{Accelerations: 1, Brakings: 1, Cornerings: 1, Overspeeds: 1, OverspeedTime: time.Minute, IdleTime: 30 * time.Second},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			score := testCase.Driving.Score(&testCase.Trip, events)
+			expected := testCase.Expected
+			if math.Abs(score.Score-expected.Score) > 0.01 {
+				test.Logf("Score is %v, want %v", score.Score, expected.Score)
+				test.Fail()
+			}
+			score.Score, expected.Score = 0, 0
+			if score != expected {
+				test.Logf("Driving score is %+v, want %+v", score, expected)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestDrivingOverspeedResync(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Driving  *Driving
+		Speeds   map[int]uint16 // Over Speeding IO by the index of the record
+		Reset    int            // index of the record before which Driving is reset, zero means never
+		Expected []DrivingEventType
+	}{
+		{
+			Name:    "MissingEnd",
+			Driving: &Driving{},
+			Speeds:  map[int]uint16{8: 95, 14: 97, 20: 96},
+			// without the speed limit the second start is taken for the end
+			Expected: []DrivingEventType{OverspeedStart, OverspeedEnd, OverspeedStart},
+		},
+		{
+			Name:     "Reset",
+			Driving:  &Driving{},
+			Speeds:   map[int]uint16{8: 95, 14: 97, 20: 85},
+			Reset:    14,
+			Expected: []DrivingEventType{OverspeedStart, OverspeedStart, OverspeedEnd},
+		},
+		{
+			Name:     "SpeedLimit",
+			Driving:  &Driving{SpeedLimit: 90},
+			Speeds:   map[int]uint16{4: 85, 8: 95, 14: 97, 20: 85, 22: 88, 26: 96},
+			Expected: []DrivingEventType{OverspeedStart, OverspeedEnd, OverspeedStart},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			records := (&track{lat: 49.2, lng: 16.6}).add(30, 40, 0.001, nil).records
+			for i, speed := range testCase.Speeds {
+				trigger(&records[i], ioOverSpeeding, ioOverSpeeding, speed)
+			}
+
+			var types []DrivingEventType
+			for i, data := range records {
+				if testCase.Reset != 0 && i == testCase.Reset {
+					testCase.Driving.Reset()
+				}
+				for _, event := range testCase.Driving.Add(data) {
+					types = append(types, event.Type)
+				}
+			}
+			if len(types) != len(testCase.Expected) {
+				test.Fatalf("Expected events %v, got %v", testCase.Expected, types)
+			}
+			for i := range types {
+				if types[i] != testCase.Expected[i] {
+					test.Logf("Expected events %v, got %v", testCase.Expected, types)
+					test.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestDrivingScoreOpen(t *testing.T) {
+	records := (&track{lat: 49.2, lng: 16.6}).add(30, 40, 0.001, nil).records
+	trigger(&records[8], ioOverSpeeding, ioOverSpeeding, 95)
+	trigger(&records[20], ioIdling, ioIdling, 1)
+
+	driving := &Driving{}
+	var events []DrivingEvent
+	for _, data := range records {
+		events = append(events, driving.Add(data)...)
+	}
+
+	// the over speeding from 80 and the idling from 200 have no end events yet
+	score := driving.Score(&Trip{Start: at(100), End: at(290), Distance: 3225}, events)
+	if score.OverspeedTime != 190*time.Second || score.IdleTime != 90*time.Second {
+		t.Errorf("Open periods are counted as %v over speeding and %v idling, want 3m10s and 1m30s", score.OverspeedTime, score.IdleTime)
+	}
+	// the trip ended before the idling
+	score = driving.Score(&Trip{Start: at(0), End: at(150), Distance: 1000}, events)
+	if score.Overspeeds != 1 || score.OverspeedTime != 70*time.Second || score.IdleTime != 0 {
+		t.Errorf("Driving score is %+v, want 1 over speeding of 1m10s and no idling", score)
+	}
+}
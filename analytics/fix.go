@@ -0,0 +1,242 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"fmt"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// IO elements of the GNSS receiver
+const (
+	ioGNSSStatus = 69  // GNSS Status, its values differ by the device family, see gnssFix
+	ioPDOP       = 181 // GNSS PDOP multiplied by 10
+	ioHDOP       = 182 // GNSS HDOP multiplied by 10
+)
+
+// FixQuality classifies the position of a record
+type FixQuality int
+
+// Qualities of the position, a higher one is better
+const (
+	FixInvalid FixQuality = iota + 1 // FixInvalid is the zero position or coordinates out of range
+	FixNone                          // FixNone is the last known position sent without a GPS fix
+	FixPoor                          // FixPoor is a fix with few satellites or a high PDOP or HDOP
+	FixGood                          // FixGood is a fix which is fine for mapping
+)
+
+func (q FixQuality) String() string {
+	switch q {
+	case FixInvalid:
+		return "invalid"
+	case FixNone:
+		return "no fix"
+	case FixPoor:
+		return "poor fix"
+	case FixGood:
+		return "good fix"
+	}
+	return fmt.Sprintf("FixQuality(%d)", int(q))
+}
+
+// gnssFix tells by the GNSS Status IO 69 of the device family whether the receiver has a fix, values which are not listed are unknown.
+// IO 69 of FM64 is a tachograph element, so no value is known. An unknown family has only the values which mean no fix in every family.
+var gnssFix = map[string]map[uint64]bool{
+	"FMBXY":  {0: false, 1: true, 2: false, 3: false},
+	"FM36":   {0: false, 1: false, 2: false, 3: true, 4: false, 5: false},
+	"FM11XY": {0: false, 2: false, 3: true, 4: false, 5: false},
+	"FM64":   {},
+	"":       {0: false, 2: false, 4: false, 5: false},
+}
+
+// Defaults of FixClassifier
+const (
+	DefaultFixMinSatellites = 4  // DefaultFixMinSatellites is used when MinSatellites is not set
+	DefaultFixMaxPDOP       = 10 // DefaultFixMaxPDOP is used when MaxPDOP is not set
+	DefaultFixMaxHDOP       = 5  // DefaultFixMaxHDOP is used when MaxHDOP is not set
+)
+
+// FixClassifier classifies the position of records by the coordinates, the number of visible satellites,
+// the GNSS Status IO 69 and the GNSS PDOP IO 181 and HDOP IO 182. The meaning of IO 69 depends on Device,
+// a status which is unknown for the family is skipped and the satellites, PDOP and HDOP decide. The zero value is ready to use.
+type FixClassifier struct {
+	Device        string  // Device family ["FMBXY", "FM64", "FM36", "FM11XY"] of the records, empty means unknown
+	MinSatellites uint8   // MinSatellites of a good fix, zero means DefaultFixMinSatellites
+	MaxPDOP       float64 // MaxPDOP of a good fix, zero means DefaultFixMaxPDOP
+	MaxHDOP       float64 // MaxHDOP of a good fix, zero means DefaultFixMaxHDOP
+}
+
+// Classify returns the quality of the position of the record
+func (c *FixClassifier) Classify(data *teltonikaparser.AvlData) FixQuality {
+	if (data.Lat == 0 && data.Lng == 0) || data.Lat < -900000000 || data.Lat > 900000000 || data.Lng < -1800000000 || data.Lng > 1800000000 {
+		return FixInvalid
+	}
+	if data.VisSat == 0 {
+		return FixNone
+	}
+	if status, ok := ioValue(data, ioGNSSStatus); ok {
+		if fix, known := gnssFix[c.Device][status]; known && !fix {
+			return FixNone
+		}
+	}
+	if data.VisSat < c.minSatellites() {
+		return FixPoor
+	}
+	if pdop, ok := ioValue(data, ioPDOP); ok && float64(pdop)/10 > c.maxPDOP() {
+		return FixPoor
+	}
+	if hdop, ok := ioValue(data, ioHDOP); ok && float64(hdop)/10 > c.maxHDOP() {
+		return FixPoor
+	}
+	return FixGood
+}
+
+func (c *FixClassifier) minSatellites() uint8 {
+	if c.MinSatellites == 0 {
+		return DefaultFixMinSatellites
+	}
+	return c.MinSatellites
+}
+
+func (c *FixClassifier) maxPDOP() float64 {
+	if c.MaxPDOP == 0 {
+		return DefaultFixMaxPDOP
+	}
+	return c.MaxPDOP
+}
+
+func (c *FixClassifier) maxHDOP() float64 {
+	if c.MaxHDOP == 0 {
+		return DefaultFixMaxHDOP
+	}
+	return c.MaxHDOP
+}
+
+// Defaults of PositionFilter
+const (
+	DefaultFilterMinQuality  = FixPoor // DefaultFilterMinQuality is used when MinQuality is not set
+	DefaultFilterMaxSpeed    = 300     // DefaultFilterMaxSpeed in km/h is used when MaxSpeed is not set
+	DefaultFilterSpeedMargin = 30      // DefaultFilterSpeedMargin in km/h is used when SpeedMargin is not set
+	DefaultFilterJitter      = 100     // DefaultFilterJitter in meters is used when Jitter is not set
+	DefaultFilterMaxRejected = 3       // DefaultFilterMaxRejected is used when MaxRejected is not set
+)
+
+// PositionStatus is the result of PositionFilter for one record
+type PositionStatus struct {
+	Quality   FixQuality // Quality of the position
+	Jump      bool       // Jump is true if the position is implausibly far from the last plausible one
+	Distance  float64    // Distance in meters from the last plausible position, zero for the first one
+	Plausible bool       // Plausible is true if the position can be used, e.g. for mapping
+}
+
+// PositionFilter marks implausible positions of one device. A position is implausible if its quality is under MinQuality,
+// or if it jumped from the last plausible position faster than the reported speed of the records plus SpeedMargin or than MaxSpeed.
+// Jitter is allowed on top of it. After MaxRejected jumps in a row the last plausible position was probably wrong,
+// so the position is taken as plausible again. Records have to come in order of time.
+// The zero value is ready to use, it is not safe for concurrent use.
+type PositionFilter struct {
+	Classifier  FixClassifier // Classifier of the positions
+	MinQuality  FixQuality    // MinQuality of a plausible position, zero means DefaultFilterMinQuality
+	MaxSpeed    float64       // MaxSpeed in km/h a vehicle can drive, zero means DefaultFilterMaxSpeed
+	SpeedMargin float64       // SpeedMargin in km/h over the reported speed, zero means DefaultFilterSpeedMargin
+	Jitter      float64       // Jitter in meters allowed between any two positions, zero means DefaultFilterJitter
+	MaxRejected int           // MaxRejected jumps in a row, zero means DefaultFilterMaxRejected
+
+	last     *teltonikaparser.AvlData // last plausible record
+	rejected int                      // jumps in a row
+}
+
+// Check classifies the position of the next record of the device and checks it against the last plausible one
+func (f *PositionFilter) Check(data *teltonikaparser.AvlData) PositionStatus {
+	status := PositionStatus{Quality: f.Classifier.Classify(data)}
+	if status.Quality < f.minQuality() {
+		return status
+	}
+
+	if f.last != nil {
+		status.Distance = Distance(Position(f.last), Position(data))
+		status.Jump = f.jump(f.last, data, status.Distance)
+	}
+	if status.Jump {
+		f.rejected++
+		if f.rejected < f.maxRejected() {
+			return status
+		}
+		status.Jump = false
+	}
+
+	last := *data
+	f.last, f.rejected = &last, 0
+	status.Plausible = true
+	return status
+}
+
+// Filter returns the records with plausible positions
+func (f *PositionFilter) Filter(records []teltonikaparser.AvlData) []teltonikaparser.AvlData {
+	var plausible []teltonikaparser.AvlData
+	for i := range records {
+		if f.Check(&records[i]).Plausible {
+			plausible = append(plausible, records[i])
+		}
+	}
+	return plausible
+}
+
+// jump returns true if the vehicle could not drive the distance between the records
+func (f *PositionFilter) jump(from, to *teltonikaparser.AvlData, distance float64) bool {
+	if distance <= f.jitter() {
+		return false
+	}
+	elapsed := recordTime(to).Sub(recordTime(from))
+	if elapsed <= 0 {
+		return true
+	}
+
+	speed := from.Speed
+	if to.Speed > speed {
+		speed = to.Speed
+	}
+	allowed := float64(speed) + f.speedMargin()
+	if allowed > f.maxSpeed() {
+		allowed = f.maxSpeed()
+	}
+	return distance-f.jitter() > allowed/3.6*elapsed.Seconds()
+}
+
+func (f *PositionFilter) minQuality() FixQuality {
+	if f.MinQuality == 0 {
+		return DefaultFilterMinQuality
+	}
+	return f.MinQuality
+}
+
+func (f *PositionFilter) maxSpeed() float64 {
+	if f.MaxSpeed == 0 {
+		return DefaultFilterMaxSpeed
+	}
+	return f.MaxSpeed
+}
+
+func (f *PositionFilter) speedMargin() float64 {
+	if f.SpeedMargin == 0 {
+		return DefaultFilterSpeedMargin
+	}
+	return f.SpeedMargin
+}
+
+func (f *PositionFilter) jitter() float64 {
+	if f.Jitter == 0 {
+		return DefaultFilterJitter
+	}
+	return f.Jitter
+}
+
+func (f *PositionFilter) maxRejected() int {
+	if f.MaxRejected == 0 {
+		return DefaultFilterMaxRejected
+	}
+	return f.MaxRejected
+}
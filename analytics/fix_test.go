@@ -0,0 +1,164 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"encoding/hex"
+	"sort"
+	"testing"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// gnss returns a record at a valid position with the satellites and 2 byte IO elements
+func gnss(satellites uint8, io ...uint16) teltonikaparser.AvlData {
+	data := teltonikaparser.AvlData{Lat: 492000000, Lng: 166000000, VisSat: satellites}
+	for i := 0; i+1 < len(io); i += 2 {
+		data.Elements = append(data.Elements, teltonikaparser.Element{Length: 2, IOID: io[i], Value: []byte{byte(io[i+1] >> 8), byte(io[i+1])}})
+	}
+	return data
+}
+
+func TestFixClassifier(t *testing.T) {
+	zero := gnss(9)
+	zero.Lat, zero.Lng = 0, 0
+	outOfRange := gnss(9)
+	outOfRange.Lat = 950000000
+
+	testCases := []struct {
+		Name       string
+		Classifier FixClassifier
+		Data       teltonikaparser.AvlData
+		Expected   FixQuality
+	}{
+		{Name: "Good", Data: gnss(9, ioGNSSStatus, 1, ioPDOP, 12, ioHDOP, 8), Expected: FixGood},
+		{Name: "WithoutIO", Data: gnss(4), Expected: FixGood},
+		{Name: "ZeroPosition", Data: zero, Expected: FixInvalid},
+		{Name: "OutOfRange", Data: outOfRange, Expected: FixInvalid},
+		{Name: "NoSatellites", Data: gnss(0), Expected: FixNone},
+		{Name: "WithoutFix", Data: gnss(9, ioGNSSStatus, 2), Expected: FixNone},
+		{Name: "Sleep", Classifier: FixClassifier{Device: "FMBXY"}, Data: gnss(9, ioGNSSStatus, 3), Expected: FixNone},
+		{Name: "FM36WithFix", Classifier: FixClassifier{Device: "FM36"}, Data: gnss(9, ioGNSSStatus, 3), Expected: FixGood},
+		{Name: "FM36AntennaDisconnected", Classifier: FixClassifier{Device: "FM36"}, Data: gnss(9, ioGNSSStatus, 1), Expected: FixNone},
+		{Name: "FM11XYWithFix", Classifier: FixClassifier{Device: "FM11XY"}, Data: gnss(9, ioGNSSStatus, 3), Expected: FixGood},
+		{Name: "FM64Tachograph", Classifier: FixClassifier{Device: "FM64"}, Data: gnss(9, ioGNSSStatus, 2), Expected: FixGood},
+		// 3 is a fix or the sleep state by the family, so the satellites decide
+		{Name: "UnknownDevice", Data: gnss(9, ioGNSSStatus, 3), Expected: FixGood},
+		{Name: "UnknownDeviceFewSatellites", Data: gnss(2, ioGNSSStatus, 3), Expected: FixPoor},
+		{Name: "UnknownStatus", Classifier: FixClassifier{Device: "FMBXY"}, Data: gnss(9, ioGNSSStatus, 7), Expected: FixGood},
+		{Name: "FewSatellites", Data: gnss(3), Expected: FixPoor},
+		{Name: "HighPDOP", Data: gnss(9, ioPDOP, 120), Expected: FixPoor},
+		{Name: "HighHDOP", Data: gnss(9, ioHDOP, 60), Expected: FixPoor},
+		{Name: "MaxHDOP", Classifier: FixClassifier{MaxHDOP: 8}, Data: gnss(9, ioHDOP, 60), Expected: FixGood},
+		{Name: "MinSatellites", Classifier: FixClassifier{MinSatellites: 10}, Data: gnss(9), Expected: FixPoor},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			if quality := testCase.Classifier.Classify(&testCase.Data); quality != testCase.Expected {
+				test.Logf("Quality is %v, want %v", quality, testCase.Expected)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestPositionFilter(t *testing.T) {
+	// about 111.2 m between records at 50 km/h
+	drive := func() []teltonikaparser.AvlData {
+		return (&track{lat: 49.2, lng: 16.6}).add(10, 50, 0.001, nil).records
+	}
+
+	spike := drive()
+	spike[4].Lat += 100000
+	spike[7].VisSat = 0
+	// the first position is wrong, so the following ones jump until MaxRejected
+	first := drive()
+	first[0].Lat += 500000
+	stopped := (&track{lat: 49.2, lng: 16.6}).add(3, 0, 0, nil).add(1, 0, 0.003, nil).add(2, 0, 0, nil).records
+	slow := drive()
+
+	testCases := []struct {
+		Name     string
+		Filter   *PositionFilter
+		Records  []teltonikaparser.AvlData
+		Rejected []int
+		Jumps    []int
+	}{
+		{Name: "Plausible", Filter: &PositionFilter{}, Records: drive()},
+		{Name: "Spike", Filter: &PositionFilter{}, Records: spike, Rejected: []int{4, 7}, Jumps: []int{4}},
+		{Name: "WrongFirst", Filter: &PositionFilter{}, Records: first, Rejected: []int{1, 2}, Jumps: []int{1, 2}},
+		{Name: "Stopped", Filter: &PositionFilter{}, Records: stopped, Rejected: []int{3, 4}, Jumps: []int{3, 4}},
+		{Name: "MaxSpeed", Filter: &PositionFilter{MaxSpeed: 20, Jitter: 10}, Records: slow, Rejected: []int{1, 2, 4, 5, 7, 8}, Jumps: []int{1, 2, 4, 5, 7, 8}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			var rejected, jumps []int
+			for i := range testCase.Records {
+				status := testCase.Filter.Check(&testCase.Records[i])
+				if !status.Plausible {
+					rejected = append(rejected, i)
+				}
+				if status.Jump {
+					jumps = append(jumps, i)
+				}
+			}
+			if !equalInts(rejected, testCase.Rejected) {
+				test.Logf("Rejected records are %v, want %v", rejected, testCase.Rejected)
+				test.Fail()
+			}
+			if !equalInts(jumps, testCase.Jumps) {
+				test.Logf("Jumps are %v, want %v", jumps, testCase.Jumps)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestPositionFilterRecords(t *testing.T) {
+	records := (&track{lat: 49.2, lng: 16.6}).add(5, 50, 0.001, nil).records
+	records[2].Lng += 100000
+
+	filtered := (&PositionFilter{}).Filter(records)
+	if len(filtered) != 4 || filtered[2].UtimeMs != records[3].UtimeMs {
+		t.Errorf("Filtered records are %+v", filtered)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPositionFilterFM1100(t *testing.T) {
+	// Codec8 packet from FM1100 with GNSS Status 3, working with a fix, and 18 or 19 satellites
+	bs, _ := hex.DecodeString(`01e4cafe0128000f333532303934303839333937343634080400000163c803eb02010a2524c01d4a377d00d3012f130032421b0a4503f00150051503ef01510052005900be00c1000ab50008b60006426fd8cd3d1ece605a5400005500007300005a0000c0000007c70000000df1000059d910002d33c65300000000570000000064000000f7bf000000000000000163c803e6e8010a2530781d4a316f00d40131130031421b0a4503f00150051503ef01510052005900be00c1000ab50008b60005426fcbcd3d1ece605a5400005500007300005a0000c0000007c70000000ef1000059d910002d33b95300000000570000000064000000f7bf000000000000000163c803df18010a2536961d4a2e4f00d50134130033421b0a4503f00150051503ef01510052005900be00c1000ab50008b6000542702bcd3d1ece605a5400005500007300005a0000c0000007c70000001ef1000059d910002d33aa5300000000570000000064000000f7bf000000000000000163c8039ce2010a25d8d41d49f42c00dc0123120058421b0a4503f00150051503ef01510052005900be00c1000ab50009b60005427031cd79d8ce605a5400005500007300005a0000c0000007c700000019f1000059d910002d32505300000000570000000064000000f7bf000000000004`)
+	decoded, err := teltonikaparser.Decode(&bs)
+	if err != nil {
+		t.Fatalf("Failed to decode packet. %v", err)
+	}
+	// the packet has the newest record first
+	sort.Slice(decoded.Data, func(i, j int) bool { return decoded.Data[i].UtimeMs < decoded.Data[j].UtimeMs })
+
+	for _, device := range []string{"", "FM11XY"} {
+		filter := &PositionFilter{Classifier: FixClassifier{Device: device}}
+		for i := range decoded.Data {
+			if quality := filter.Classifier.Classify(&decoded.Data[i]); quality != FixGood {
+				t.Errorf("Device %q: record %v is %v, want good fix", device, i, quality)
+			}
+		}
+		if plausible := filter.Filter(decoded.Data); len(plausible) != len(decoded.Data) {
+			t.Errorf("Device %q: %v of %v records are plausible", device, len(plausible), len(decoded.Data))
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// Defaults of Odometer
+const (
+	DefaultOdometerMinSpeed = 5    // DefaultOdometerMinSpeed in km/h is used when MinSpeed is not set
+	DefaultOdometerMaxJump  = 1000 // DefaultOdometerMaxJump in meters is used when MaxJump is not set
+	DefaultOdometerMaxDrift = 20   // DefaultOdometerMaxDrift in meters is used when MaxDrift is not set
+)
+
+// OdometerEventType is a kind of an inconsistency found by Odometer
+type OdometerEventType int
+
+// Types of odometer events
+const (
+	OdometerReset OdometerEventType = iota + 1 // OdometerReset is a decrease of an odometer IO, the trip odometer is reset at the start of every trip
+	OdometerJump                               // OdometerJump is an increase of an odometer IO by more than MaxJump over the GNSS distance
+	GNSSDrift                                  // GNSSDrift is a change of the position of a standing vehicle by more than MaxDrift
+)
+
+func (t OdometerEventType) String() string {
+	switch t {
+	case OdometerReset:
+		return "odometer reset"
+	case OdometerJump:
+		return "odometer jump"
+	case GNSSDrift:
+		return "GNSS drift"
+	}
+	return fmt.Sprintf("OdometerEventType(%d)", int(t))
+}
+
+// OdometerEvent is an inconsistency of the odometer IO or of the position
+type OdometerEvent struct {
+	Type     OdometerEventType
+	Time     time.Time // Time of the record
+	IOID     uint16    // IOID of the odometer, 16 or 199, zero for GNSSDrift
+	Previous uint64    // Previous value of the odometer in meters
+	Current  uint64    // Current value of the odometer in meters
+	GNSS     float64   // GNSS distance in meters since the previous value of the odometer, or of the drift
+}
+
+// OdometerSummary compares the distances measured by GNSS and by the odometer of the device
+type OdometerSummary struct {
+	GNSS     float64 // GNSS distance in meters without the drift of the standing vehicle
+	Odometer float64 // Odometer distance in meters by the Total Odometer IO 16 or the Trip Odometer IO 199, without resets and jumps
+	Resets   int     // Resets of the odometer IOs
+	Jumps    int     // Jumps of the odometer IOs
+	Drifts   int     // Drifts of the position of the standing vehicle
+}
+
+// Difference returns the odometer distance minus the GNSS distance in meters
+func (s OdometerSummary) Difference() float64 {
+	return s.Odometer - s.GNSS
+}
+
+// Odometer accumulates the GNSS distance of one device and reconciles it with the Total Odometer IO 16 and the Trip Odometer IO 199.
+// The vehicle stands while its speed is under MinSpeed and the movement IO 240 is not 1, changes of its position are drift
+// which is not added to the distance. Records have to come in order of time, older records are ignored.
+// The zero value is ready to use, it is not safe for concurrent use.
+type Odometer struct {
+	MinSpeed uint16  // MinSpeed in km/h under which the vehicle stands, zero means DefaultOdometerMinSpeed
+	MaxJump  float64 // MaxJump in meters of the odometer over the GNSS distance, zero means DefaultOdometerMaxJump
+	MaxDrift float64 // MaxDrift in meters of the position of the standing vehicle between two records, zero means DefaultOdometerMaxDrift
+
+	summary  OdometerSummary
+	started  bool
+	last     time.Time          // time of the last record
+	lastPos  Point              // last known position
+	standing bool               // standing is true if the vehicle stood at the last record
+	total    bool               // total is true if the device reports the Total Odometer IO
+	values   map[uint16]uint64  // last values of the odometer IOs
+	gnssAt   map[uint16]float64 // GNSS distance at the last values of the odometer IOs
+}
+
+// Add takes the next record of the device and returns inconsistencies found by it, usually none
+func (o *Odometer) Add(data teltonikaparser.AvlData) []OdometerEvent {
+	t := recordTime(&data)
+	if o.started && !t.After(o.last) {
+		return nil
+	}
+	o.started, o.last = true, t
+
+	var events []OdometerEvent
+	movement, hasMovement := ioValue(&data, ioMovement)
+	standing := data.Speed < o.minSpeed() && !(hasMovement && movement != 0)
+
+	if position := Position(&data); position.valid() {
+		if o.lastPos.valid() {
+			step := Distance(o.lastPos, position)
+			switch {
+			case !standing || !o.standing:
+				o.summary.GNSS += step
+			case step > o.maxDrift():
+				o.summary.Drifts++
+				events = append(events, OdometerEvent{Type: GNSSDrift, Time: t, GNSS: step})
+			}
+		}
+		o.lastPos = position
+	}
+	o.standing = standing
+
+	if o.values == nil {
+		o.values, o.gnssAt = make(map[uint16]uint64), make(map[uint16]float64)
+	}
+	for _, id := range []uint16{ioTotalOdometer, ioTripOdometer} {
+		value, ok := ioValue(&data, id)
+		if !ok {
+			continue
+		}
+		if id == ioTotalOdometer {
+			o.total = true
+		}
+		// only one odometer is counted, the total one if the device reports it
+		counted := id == ioTotalOdometer || !o.total
+
+		previous, seen := o.values[id]
+		gnss := o.summary.GNSS - o.gnssAt[id]
+		o.values[id], o.gnssAt[id] = value, o.summary.GNSS
+		if !seen {
+			continue
+		}
+
+		switch {
+		case value < previous:
+			o.summary.Resets++
+			events = append(events, OdometerEvent{Type: OdometerReset, Time: t, IOID: id, Previous: previous, Current: value, GNSS: gnss})
+			if counted && id == ioTripOdometer {
+				// the trip odometer counts from zero since the reset
+				o.summary.Odometer += float64(value)
+			}
+		case float64(value-previous)-gnss > o.maxJump():
+			o.summary.Jumps++
+			events = append(events, OdometerEvent{Type: OdometerJump, Time: t, IOID: id, Previous: previous, Current: value, GNSS: gnss})
+		case counted:
+			o.summary.Odometer += float64(value - previous)
+		}
+	}
+	return events
+}
+
+// Summary returns the distances accumulated so far
+func (o *Odometer) Summary() OdometerSummary {
+	return o.summary
+}
+
+func (o *Odometer) minSpeed() uint16 {
+	if o.MinSpeed == 0 {
+		return DefaultOdometerMinSpeed
+	}
+	return o.MinSpeed
+}
+
+func (o *Odometer) maxJump() float64 {
+	if o.MaxJump == 0 {
+		return DefaultOdometerMaxJump
+	}
+	return o.MaxJump
+}
+
+func (o *Odometer) maxDrift() float64 {
+	if o.MaxDrift == 0 {
+		return DefaultOdometerMaxDrift
+	}
+	return o.MaxDrift
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// withOdometer sets the odometer IO of the records to the values
+func withOdometer(records []teltonikaparser.AvlData, id uint16, values ...uint32) []teltonikaparser.AvlData {
+	for i, value := range values {
+		bs := make([]byte, 4)
+		binary.BigEndian.PutUint32(bs, value)
+		records[i].Elements = append(records[i].Elements, teltonikaparser.Element{Length: 4, IOID: id, Value: bs})
+	}
+	return records
+}
+
+func TestOdometer(t *testing.T) {
+	// 3 standing records drifting by about 33 m and 10 moving records about 111.2 m apart
+	drive := func() []teltonikaparser.AvlData {
+		return (&track{lat: 49.2, lng: 16.6}).add(1, 0, 0, nil).add(2, 0, 0.0003, nil).add(10, 50, 0.001, nil).records
+	}
+
+	total := withOdometer(drive(), ioTotalOdometer,
+		100000, 100000, 100000, 100111, 100222, 100333, 100444, 100555, 105666, 105777, 50, 161, 272)
+	trip := withOdometer(drive(), ioTripOdometer, 500, 500, 500, 600, 0, 100)
+	// the trip odometer is not counted when the device reports the total one
+	both := withOdometer(withOdometer(drive(), ioTotalOdometer, 0, 0, 0, 111, 222, 333), ioTripOdometer, 0, 0, 0, 111, 0, 111)
+	// the older record is ignored
+	late := append(drive(), drive()[3])
+
+	testCases := []struct {
+		Name     string
+		Odometer *Odometer
+		Records  []teltonikaparser.AvlData
+		Expected OdometerSummary
+		Events   []OdometerEventType
+	}{
+		{
+			Name:     "Total",
+			Odometer: &Odometer{},
+			Records:  total,
+			Expected: OdometerSummary{GNSS: 1111.9, Odometer: 888, Resets: 1, Jumps: 1, Drifts: 2},
+			Events:   []OdometerEventType{GNSSDrift, GNSSDrift, OdometerJump, OdometerReset},
+		},
+		{
+			Name:     "Trip",
+			Odometer: &Odometer{},
+			Records:  trip,
+			Expected: OdometerSummary{GNSS: 1111.9, Odometer: 200, Resets: 1, Drifts: 2},
+			Events:   []OdometerEventType{GNSSDrift, GNSSDrift, OdometerReset},
+		},
+		{
+			Name:     "Both",
+			Odometer: &Odometer{},
+			Records:  both,
+			Expected: OdometerSummary{GNSS: 1111.9, Odometer: 333, Resets: 1, Drifts: 2},
+			Events:   []OdometerEventType{GNSSDrift, GNSSDrift, OdometerReset},
+		},
+		{
+			Name:     "MaxDrift",
+			Odometer: &Odometer{MaxDrift: 50},
+			Records:  late,
+			Expected: OdometerSummary{GNSS: 1111.9},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			var events []OdometerEvent
+			for _, data := range testCase.Records {
+				events = append(events, testCase.Odometer.Add(data)...)
+			}
+
+			summary := testCase.Odometer.Summary()
+			if math.Abs(summary.GNSS-testCase.Expected.GNSS) > 1 || summary.Odometer != testCase.Expected.Odometer ||
+				summary.Resets != testCase.Expected.Resets || summary.Jumps != testCase.Expected.Jumps || summary.Drifts != testCase.Expected.Drifts {
+				test.Logf("Summary is %+v, want %+v", summary, testCase.Expected)
+				test.Fail()
+			}
+			if len(events) != len(testCase.Events) {
+				test.Fatalf("Expected %v events, got %v, %+v", len(testCase.Events), len(events), events)
+			}
+			for i, event := range events {
+				if event.Type != testCase.Events[i] {
+					test.Logf("Event %v is %v, want %v", i, event.Type, testCase.Events[i])
+					test.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestOdometerEvents(t *testing.T) {
+	records := withOdometer((&track{lat: 49.2, lng: 16.6}).add(3, 50, 0.001, nil).records, ioTotalOdometer, 1000, 1111, 9000)
+
+	odometer := &Odometer{}
+	var events []OdometerEvent
+	for _, data := range records {
+		events = append(events, odometer.Add(data)...)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Events are %+v", events)
+	}
+	event := events[0]
+	if event.Type != OdometerJump || event.IOID != ioTotalOdometer || event.Previous != 1111 || event.Current != 9000 ||
+		!event.Time.Equal(at(20)) || math.Abs(event.GNSS-111.2) > 1 {
+		t.Errorf("Event is %+v", event)
+	}
+	if difference := odometer.Summary().Difference(); math.Abs(difference-(111-222.4)) > 1 {
+		t.Errorf("Difference is %v", difference)
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// Defaults of TripDetector
+const (
+	DefaultTripMinSpeed      = 5                // DefaultTripMinSpeed in km/h is used when MinSpeed is not set
+	DefaultTripStopDuration  = 3 * time.Minute  // DefaultTripStopDuration is used when StopDuration is not set
+	DefaultTripMaxGap        = 10 * time.Minute // DefaultTripMaxGap is used when MaxGap is not set
+	DefaultTripReorderWindow = time.Minute      // DefaultTripReorderWindow is used when ReorderWindow is not set
+)
+
+// Trip is a drive of a vehicle
+type Trip struct {
+	Start         time.Time     // Start is the time of the first record
+	End           time.Time     // End is the time of the last record
+	StartPosition Point         // StartPosition is the first known position, zero if no record had a position
+	EndPosition   Point         // EndPosition is the last known position
+	Distance      float64       // Distance in meters between positions of the records
+	MaxSpeed      uint16        // MaxSpeed in km/h
+	AvgSpeed      float64       // AvgSpeed in km/h is the distance over the duration
+	Idle          time.Duration // Idle is the time the vehicle stood during the trip, e.g. with ignition on or at traffic lights
+	Records       int           // Records of the trip
+}
+
+// Duration returns the time from the start to the end of the trip
+func (t *Trip) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// TripDetector splits records of one device into trips. A trip of a device with the ignition IO 239 lasts from the record
+// with ignition on to the record with ignition off. Other devices drive while the movement IO 240 is 1 or, without it,
+// while the speed is at least MinSpeed, and a trip ends at the first record of a stop longer than StopDuration.
+// A gap between records longer than MaxGap ends a trip too.
+//
+// Records are sorted by their time in ReorderWindow, so records which arrive late, e.g. retransmitted after a reconnect,
+// are taken in order, older records and records with the same time as another one are dropped.
+// The zero value is ready to use, it is not safe for concurrent use.
+type TripDetector struct {
+	MinSpeed      uint16        // MinSpeed in km/h under which the vehicle stands, zero means DefaultTripMinSpeed
+	StopDuration  time.Duration // StopDuration ends a trip of a device without ignition, zero means DefaultTripStopDuration
+	MaxGap        time.Duration // MaxGap between records ends a trip, zero means DefaultTripMaxGap
+	ReorderWindow time.Duration // ReorderWindow delays records to sort late ones in, zero means DefaultTripReorderWindow, negative disables it
+	MinDuration   time.Duration // MinDuration of a trip, shorter trips are not emitted
+	MinDistance   float64       // MinDistance of a trip in meters, shorter trips are not emitted
+
+	pending    []teltonikaparser.AvlData // records waiting in the reorder window, sorted by time
+	newest     time.Time                 // time of the newest record added
+	processed  bool                      // processed is true after the first record was processed
+	last       time.Time                 // time of the last processed record
+	lastSpeed  uint16                    // speed of the last processed record
+	lastPos    Point                     // last known position of the trip
+	ignition   bool                      // ignition is true if the device reports the ignition IO
+	ignitionOn bool                      // ignitionOn is the last known state of the ignition
+	trip       *Trip                     // trip in progress
+	stopped    *Trip                     // stopped is the trip at the first record of the current stop, devices without ignition
+	dropped    int                       // records which were late or duplicated
+}
+
+// Add takes the next record of the device and returns trips which ended, usually none
+func (d *TripDetector) Add(data teltonikaparser.AvlData) []Trip {
+	t := recordTime(&data)
+	if d.processed && !t.After(d.last) {
+		d.dropped++
+		return nil
+	}
+
+	i := sort.Search(len(d.pending), func(i int) bool { return !recordTime(&d.pending[i]).Before(t) })
+	if i < len(d.pending) && recordTime(&d.pending[i]).Equal(t) {
+		d.dropped++
+		return nil
+	}
+	d.pending = append(d.pending, teltonikaparser.AvlData{})
+	copy(d.pending[i+1:], d.pending[i:])
+	d.pending[i] = data
+	if t.After(d.newest) {
+		d.newest = t
+	}
+
+	var trips []Trip
+	for len(d.pending) > 0 && d.newest.Sub(recordTime(&d.pending[0])) > d.reorderWindow() {
+		trips = append(trips, d.process(&d.pending[0])...)
+		d.pending = d.pending[1:]
+	}
+	return trips
+}
+
+// Flush processes records waiting in the reorder window and ends the trip in progress, call it when no more records are expected
+func (d *TripDetector) Flush() []Trip {
+	var trips []Trip
+	for i := range d.pending {
+		trips = append(trips, d.process(&d.pending[i])...)
+	}
+	d.pending = nil
+
+	if d.trip != nil {
+		trips = d.end(trips)
+	}
+	return trips
+}
+
+// Dropped returns the number of records which were dropped because they came too late or had the same time as another one
+func (d *TripDetector) Dropped() int {
+	return d.dropped
+}
+
+// process takes records in order of their time
+func (d *TripDetector) process(data *teltonikaparser.AvlData) []Trip {
+	var trips []Trip
+	t, position := recordTime(data), Position(data)
+
+	if value, ok := ioValue(data, ioIgnition); ok {
+		d.ignition, d.ignitionOn = true, value != 0
+	}
+	var active bool
+	movement, hasMovement := ioValue(data, ioMovement)
+	switch {
+	case d.ignition:
+		active = d.ignitionOn
+	case hasMovement:
+		active = movement != 0
+	default:
+		active = data.Speed >= d.minSpeed()
+	}
+
+	if d.trip != nil && t.Sub(d.last) > d.maxGap() {
+		trips = d.end(trips)
+	}
+	// the stop was long enough even if the device sent no other record during it, this record starts a new trip
+	if d.trip != nil && active && d.stopped != nil && t.Sub(d.stopped.End) >= d.stopDuration() {
+		trips = d.end(trips)
+	}
+
+	switch {
+	case d.trip == nil && active:
+		d.trip = &Trip{Start: t, End: t, StartPosition: position, EndPosition: position, MaxSpeed: data.Speed, Records: 1}
+		d.lastPos = position
+	case d.trip != nil:
+		trips = d.extend(trips, data, t, position, active)
+	}
+
+	d.processed, d.last, d.lastSpeed = true, t, data.Speed
+	return trips
+}
+
+// extend adds the record to the trip in progress
+func (d *TripDetector) extend(trips []Trip, data *teltonikaparser.AvlData, t time.Time, position Point, active bool) []Trip {
+	trip := d.trip
+	if position.valid() {
+		if d.lastPos.valid() {
+			trip.Distance += Distance(d.lastPos, position)
+		}
+		if !trip.StartPosition.valid() {
+			trip.StartPosition = position
+		}
+		trip.EndPosition, d.lastPos = position, position
+	}
+	if d.lastSpeed < d.minSpeed() {
+		trip.Idle += t.Sub(d.last)
+	}
+	if data.Speed > trip.MaxSpeed {
+		trip.MaxSpeed = data.Speed
+	}
+	trip.End = t
+	trip.Records++
+
+	switch {
+	case d.ignition:
+		if !active {
+			// the record with ignition off is the last one of the trip
+			return d.end(trips)
+		}
+	case active:
+		d.stopped = nil
+	case d.stopped == nil:
+		stopped := *trip
+		d.stopped = &stopped
+	case t.Sub(d.stopped.End) >= d.stopDuration():
+		return d.end(trips)
+	}
+	return trips
+}
+
+// end finishes the trip in progress, a trip of a device without ignition ends at the first record of the stop
+func (d *TripDetector) end(trips []Trip) []Trip {
+	trip := d.trip
+	if d.stopped != nil {
+		trip = d.stopped
+	}
+	d.trip, d.stopped = nil, nil
+
+	if hours := trip.Duration().Hours(); hours > 0 {
+		trip.AvgSpeed = trip.Distance / 1000 / hours
+	}
+	if trip.Duration() < d.MinDuration || trip.Distance < d.MinDistance {
+		return trips
+	}
+	return append(trips, *trip)
+}
+
+func (d *TripDetector) minSpeed() uint16 {
+	if d.MinSpeed == 0 {
+		return DefaultTripMinSpeed
+	}
+	return d.MinSpeed
+}
+
+func (d *TripDetector) stopDuration() time.Duration {
+	if d.StopDuration == 0 {
+		return DefaultTripStopDuration
+	}
+	return d.StopDuration
+}
+
+func (d *TripDetector) maxGap() time.Duration {
+	if d.MaxGap == 0 {
+		return DefaultTripMaxGap
+	}
+	return d.MaxGap
+}
+
+func (d *TripDetector) reorderWindow() time.Duration {
+	if d.ReorderWindow == 0 {
+		return DefaultTripReorderWindow
+	}
+	return d.ReorderWindow
+}
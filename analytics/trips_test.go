@@ -0,0 +1,189 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// origin is the time of the first record of synthetic tracks
+var origin = time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+
+// track builds synthetic records, a record is made every 10 seconds
+type track struct {
+	records []teltonikaparser.AvlData
+	second  int
+	lat     float64
+	lng     float64
+}
+
+// add appends n records with the speed and IO elements, the position moves by step degrees of latitude between records
+func (tr *track) add(n int, speed uint16, step float64, io map[uint16]byte) *track {
+	for i := 0; i < n; i++ {
+		if i > 0 || len(tr.records) > 0 {
+			tr.lat += step
+		}
+		t := origin.Add(time.Duration(tr.second) * time.Second)
+		data := teltonikaparser.AvlData{
+			UtimeMs: uint64(t.UnixNano() / int64(time.Millisecond)),
+			Utime:   uint64(t.Unix()),
+			Lat:     int32(math.Round(tr.lat * 1e7)),
+			Lng:     int32(math.Round(tr.lng * 1e7)),
+			VisSat:  9,
+			Speed:   speed,
+		}
+		for id, value := range io {
+			data.Elements = append(data.Elements, teltonikaparser.Element{Length: 1, IOID: id, Value: []byte{value}})
+		}
+		tr.records = append(tr.records, data)
+		tr.second += 10
+	}
+	return tr
+}
+
+// at returns the time of the record at the second of the track
+func at(second int) time.Time {
+	return origin.Add(time.Duration(second) * time.Second)
+}
+
+func TestTripDetector(t *testing.T) {
+	off, on := map[uint16]byte{ioIgnition: 0}, map[uint16]byte{ioIgnition: 1}
+	// about 11.1 m between records
+	const step = 0.0001
+
+	ignition := (&track{lat: 49.2, lng: 16.6}).add(2, 0, 0, off).add(1, 0, 0, on).add(10, 36, step, on).add(3, 0, 0, on).add(1, 0, 0, off).add(3, 0, 0, off).records
+	speed := (&track{lat: 49.2, lng: 16.6}).add(3, 0, 0, nil).add(8, 40, step, nil).add(6, 0, 0, nil).add(4, 40, step, nil).add(30, 0, 0, nil).records
+	movement := (&track{lat: 49.2, lng: 16.6}).add(5, 0, step, map[uint16]byte{ioMovement: 1}).add(20, 0, 0, map[uint16]byte{ioMovement: 0}).records
+	gap := (&track{lat: 49.2, lng: 16.6}).add(5, 50, step, on)
+	gap.second += 20 * 60
+	gapped := gap.add(5, 50, step, on).add(1, 0, 0, off).records
+
+	// a single stopped record and the next one 9 minutes later already moving
+	stop := (&track{lat: 49.2, lng: 16.6}).add(5, 40, step, nil).add(1, 0, 0, nil)
+	stop.second += 9*60 - 10
+	singleStop := stop.add(5, 40, step, nil).records
+
+	// shuffled has neighbouring records swapped and a duplicate record
+	shuffled := append([]teltonikaparser.AvlData{}, ignition...)
+	for i := 0; i+1 < len(shuffled); i += 3 {
+		shuffled[i], shuffled[i+1] = shuffled[i+1], shuffled[i]
+	}
+	shuffled = append(shuffled[:6], append([]teltonikaparser.AvlData{ignition[4]}, shuffled[6:]...)...)
+
+	ignitionTrip := Trip{Start: at(20), End: at(160), StartPosition: Point{49.2, 16.6}, EndPosition: Point{49.201, 16.6},
+		Distance: 111.2, MaxSpeed: 36, Idle: 40 * time.Second, Records: 15}
+
+	testCases := []struct {
+		Name     string
+		Detector *TripDetector
+		Records  []teltonikaparser.AvlData
+		Expected []Trip
+		Dropped  int
+	}{
+		{Name: "Ignition", Detector: &TripDetector{}, Records: ignition, Expected: []Trip{ignitionTrip}},
+		{Name: "OutOfOrder", Detector: &TripDetector{}, Records: shuffled, Expected: []Trip{ignitionTrip}, Dropped: 1},
+		{
+			Name:     "Speed",
+			Detector: &TripDetector{},
+			Records:  speed,
+			// the trip ends at the first record of the stop which is longer than StopDuration
+			Expected: []Trip{{Start: at(30), End: at(210), StartPosition: Point{49.2001, 16.6}, EndPosition: Point{49.2012, 16.6},
+				Distance: 122.3, MaxSpeed: 40, Idle: 60 * time.Second, Records: 19}},
+		},
+		{
+			Name:     "SingleStoppedRecord",
+			Detector: &TripDetector{},
+			Records:  singleStop,
+			Expected: []Trip{
+				{Start: at(0), End: at(50), StartPosition: Point{49.2, 16.6}, EndPosition: Point{49.2004, 16.6}, Distance: 44.5, MaxSpeed: 40, Records: 6},
+				{Start: at(590), End: at(630), StartPosition: Point{49.2005, 16.6}, EndPosition: Point{49.2009, 16.6}, Distance: 44.5, MaxSpeed: 40, Records: 5},
+			},
+		},
+		{Name: "SpeedShortTrip", Detector: &TripDetector{MinDistance: 1000}, Records: speed},
+		{
+			Name:     "Movement",
+			Detector: &TripDetector{},
+			Records:  movement,
+			Expected: []Trip{{Start: at(0), End: at(50), StartPosition: Point{49.2, 16.6}, EndPosition: Point{49.2004, 16.6},
+				Distance: 44.5, Idle: 50 * time.Second, Records: 6}},
+		},
+		{
+			Name:     "Gap",
+			Detector: &TripDetector{},
+			Records:  gapped,
+			Expected: []Trip{
+				{Start: at(0), End: at(40), StartPosition: Point{49.2, 16.6}, EndPosition: Point{49.2004, 16.6}, Distance: 44.5, MaxSpeed: 50, Records: 5},
+				{Start: at(1250), End: at(1300), StartPosition: Point{49.2005, 16.6}, EndPosition: Point{49.2009, 16.6}, Distance: 44.5, MaxSpeed: 50, Records: 6},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			var trips []Trip
+			for _, data := range testCase.Records {
+				trips = append(trips, testCase.Detector.Add(data)...)
+			}
+			trips = append(trips, testCase.Detector.Flush()...)
+
+			if len(trips) != len(testCase.Expected) {
+				test.Fatalf("Expected %v trips, got %v, %+v", len(testCase.Expected), len(trips), trips)
+			}
+			for i, trip := range trips {
+				expected := testCase.Expected[i]
+				if !trip.Start.Equal(expected.Start) || !trip.End.Equal(expected.End) || trip.Records != expected.Records ||
+					trip.MaxSpeed != expected.MaxSpeed || trip.Idle != expected.Idle {
+					test.Logf("Trip %v is %+v, want %+v", i, trip, expected)
+					test.Fail()
+				}
+				if !near(trip.StartPosition, expected.StartPosition) || !near(trip.EndPosition, expected.EndPosition) {
+					test.Logf("Trip %v is from %v to %v, want from %v to %v", i, trip.StartPosition, trip.EndPosition, expected.StartPosition, expected.EndPosition)
+					test.Fail()
+				}
+				if math.Abs(trip.Distance-expected.Distance) > 1 {
+					test.Logf("Distance of trip %v is %v, want %v", i, trip.Distance, expected.Distance)
+					test.Fail()
+				}
+				if avg := trip.Distance / 1000 / trip.Duration().Hours(); math.Abs(trip.AvgSpeed-avg) > 1e-9 {
+					test.Logf("Average speed of trip %v is %v, want %v", i, trip.AvgSpeed, avg)
+					test.Fail()
+				}
+			}
+			if dropped := testCase.Detector.Dropped(); dropped != testCase.Dropped {
+				test.Logf("Dropped %v records, want %v", dropped, testCase.Dropped)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestTripDetectorLateRecord(t *testing.T) {
+	on := map[uint16]byte{ioIgnition: 1}
+	records := (&track{lat: 49.2, lng: 16.6}).add(20, 50, 0.0001, on).records
+
+	detector := &TripDetector{ReorderWindow: 30 * time.Second}
+	for _, data := range records[1:] {
+		detector.Add(data)
+	}
+	// the first record is older than the processed ones
+	detector.Add(records[0])
+
+	trips := detector.Flush()
+	if len(trips) != 1 || trips[0].Records != 19 || !trips[0].Start.Equal(at(10)) {
+		t.Errorf("Trips are %+v", trips)
+	}
+	if detector.Dropped() != 1 {
+		t.Errorf("Dropped %v records, want 1", detector.Dropped())
+	}
+}
+
+// near returns true if the points are closer than 1 m
+func near(a, b Point) bool {
+	return Distance(a, b) < 1
+}
@@ -0,0 +1,533 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command teltonika-decode decodes Teltonika packets pasted from device logs or captured to files.
+//
+// Usage:
+//
+//	teltonika-decode [flags] [packet ...]
+//	teltonika-decode -f packet.bin -f dump.txt
+//	cat dump.txt | teltonika-decode -device FMBXY -o json
+//
+// Packets are read from arguments, from files given by -f or from stdin when there are neither.
+// Text input holds one packet per line in hex or base64, raw input is one packet per file.
+// UDP and TCP AVL packets and Codec 12 commands and responses are recognised automatically,
+// -dissect prints every field of the packets with its Byte range and marks the Byte where decoding failed.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// Packet types
+const (
+	typeAuto            = "auto"
+	typeUDP             = "udp"
+	typeTCP             = "tcp"
+	typeCommandRequest  = "command-request"
+	typeCommandResponse = "command-response"
+	typeCommandUDP      = "command-response-udp"
+)
+
+// input is one packet read from an argument, stdin or a file
+type input struct {
+	Source string
+	Data   []byte
+	Err    error // error when reading the input, e.g. invalid hex
+}
+
+// result is the decoded packet as printed
+type result struct {
+	Source  string   `json:"source"`
+	Type    string   `json:"type,omitempty"`
+	Length  int      `json:"length"`
+	Packet  *packet  `json:"packet,omitempty"`
+	Command *command `json:"command,omitempty"`
+	Error   string   `json:"error,omitempty"`
+	Offset  *int     `json:"offset,omitempty"` // Offset of the Byte where decoding failed
+	Fields  []field  `json:"fields,omitempty"` // Fields of the dissected packet
+	raw     []byte   // raw packet for the error context
+	dump    string   // dissection rendered as text
+}
+
+type field struct {
+	Name   string `json:"name"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	Value  string `json:"value,omitempty"`
+	Depth  int    `json:"depth"`
+}
+
+type packet struct {
+	IMEI        string   `json:"imei,omitempty"`
+	CodecID     byte     `json:"codecId"`
+	PacketID    uint16   `json:"packetId,omitempty"`
+	AVLPacketID byte     `json:"avlPacketId,omitempty"`
+	NoOfData    uint8    `json:"noOfData"`
+	Records     []record `json:"records"`
+	Response    string   `json:"response"`
+}
+
+type record struct {
+	Time     time.Time `json:"time"`
+	Priority uint8     `json:"priority"`
+	Lat      float64   `json:"lat"`
+	Lng      float64   `json:"lng"`
+	Altitude int16     `json:"altitude"`
+	Angle    uint16    `json:"angle"`
+	VisSat   uint8     `json:"satellites"`
+	Speed    uint16    `json:"speed"`
+	EventID  uint16    `json:"eventId"`
+	Elements []element `json:"elements"`
+}
+
+type element struct {
+	IOID   uint16      `json:"id"`
+	Length uint16      `json:"length"`
+	Value  string      `json:"value"`
+	Name   string      `json:"name,omitempty"`
+	Final  interface{} `json:"final,omitempty"`
+	Label  string      `json:"label,omitempty"`
+	Units  string      `json:"units,omitempty"`
+	Error  string      `json:"humanError,omitempty"`
+}
+
+type command struct {
+	IMEI string `json:"imei,omitempty"`
+	Type byte   `json:"type"`
+	Text string `json:"text"`
+}
+
+// stringsFlag collects repeated flag values
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func main() {
+	var files stringsFlag
+	flag.Var(&files, "f", "file with packets, repeatable")
+	format := flag.String("in", "auto", "input format, auto, hex, base64 or raw")
+	packetType := flag.String("type", typeAuto, "packet type, auto, udp, tcp, command-request, command-response or command-response-udp")
+	device := flag.String("device", "", "decode IO elements by the dictionary of the device family, FMBXY, FM64, FM36 or FM11XY")
+	output := flag.String("o", "text", "output format, text, json or ndjson")
+	dissectPackets := flag.Bool("dissect", false, "print every field of packets with its Byte range instead of decoded records")
+	flag.Parse()
+
+	inputs, err := readInputs(flag.Args(), files, os.Stdin, *format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var human *teltonikaparser.HumanDecoder
+	if *device != "" {
+		if human, err = teltonikaparser.NewHumanDecoder(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	results := make([]result, 0, len(inputs))
+	failed := false
+	for _, in := range inputs {
+		var r result
+		if *dissectPackets {
+			r = dissect(in, *packetType)
+		} else {
+			r = decode(in, *packetType, human, *device)
+		}
+		failed = failed || r.Error != ""
+		results = append(results, r)
+	}
+
+	if err := write(os.Stdout, results, *output); err != nil {
+		log.Fatal(err)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// readInputs returns packets from arguments, files or stdin if there are neither
+func readInputs(args []string, files []string, stdin io.Reader, format string) ([]input, error) {
+	var inputs []input
+
+	for i, arg := range args {
+		data, err := parseText(arg, format)
+		inputs = append(inputs, input{Source: fmt.Sprintf("arg %v", i+1), Data: data, Err: err})
+	}
+
+	for _, name := range files {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, splitContent(name, content, format)...)
+	}
+
+	if len(args) == 0 && len(files) == 0 {
+		content, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, splitContent("stdin", content, format)...)
+	}
+
+	return inputs, nil
+}
+
+// splitContent returns the raw content as one packet or text content as one packet per line
+func splitContent(source string, content []byte, format string) []input {
+	if format == "raw" || (format == "auto" && !isText(content)) {
+		return []input{{Source: source, Data: content}}
+	}
+
+	var inputs []input
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		data, err := parseText(text, format)
+		inputs = append(inputs, input{Source: fmt.Sprintf("%v:%v", source, line), Data: data, Err: err})
+	}
+	return inputs
+}
+
+// isText returns true if content is printable ASCII, hex and base64 dumps are
+func isText(content []byte) bool {
+	for _, b := range content {
+		if (b < 0x20 || b > 0x7e) && b != '\n' && b != '\r' && b != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseText decodes a hex or base64 packet, hex may contain spaces, colons and 0x prefix
+func parseText(text string, format string) ([]byte, error) {
+	if format == "hex" || format == "auto" {
+		cleaned := strings.NewReplacer(" ", "", ":", "", "\t", "", "0x", "", "0X", "").Replace(text)
+		data, err := hex.DecodeString(cleaned)
+		if err == nil || format == "hex" {
+			return data, err
+		}
+	}
+
+	if format == "base64" || format == "auto" {
+		for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			if data, err := encoding.DecodeString(text); err == nil {
+				return data, nil
+			}
+		}
+		return nil, fmt.Errorf("Unable to parse %q as hex or base64", shorten(text))
+	}
+
+	return nil, fmt.Errorf("Unknown input format %q, want auto, hex, base64 or raw", format)
+}
+
+// detectType recognises the packet by its framing
+func detectType(data []byte) string {
+	if len(data) >= 11 && bytes.Equal(data[:4], []byte{0, 0, 0, 0}) {
+		if data[8] == teltonikaparser.CodecID {
+			if data[10] == teltonikaparser.CommandTypeRequest {
+				return typeCommandRequest
+			}
+			return typeCommandResponse
+		}
+		return typeTCP
+	}
+
+	if len(data) >= 8 {
+		codecIndex := 8 + (int(data[6])<<8 | int(data[7]))
+		if codecIndex < len(data) && data[codecIndex] == teltonikaparser.CodecID {
+			return typeCommandUDP
+		}
+	}
+	return typeUDP
+}
+
+// decode runs the decoder for the packet type, human is nil when IO elements are not decoded by a dictionary
+func decode(in input, packetType string, human *teltonikaparser.HumanDecoder, device string) result {
+	r := result{Source: in.Source, Length: len(in.Data), raw: in.Data}
+	if in.Err != nil {
+		r.Error = in.Err.Error()
+		return r
+	}
+
+	if packetType == typeAuto {
+		packetType = detectType(in.Data)
+	}
+	r.Type = packetType
+
+	var err error
+	switch packetType {
+	case typeUDP, typeTCP:
+		var decoded teltonikaparser.Decoded
+		if packetType == typeUDP {
+			decoded, err = teltonikaparser.Decode(&in.Data)
+		} else {
+			decoded, err = teltonikaparser.DecodeTCP(&in.Data)
+		}
+		if err == nil {
+			r.Packet = newPacket(&decoded, human, device)
+		}
+	case typeCommandRequest:
+		var request teltonikaparser.CommandRequest
+		if request, err = teltonikaparser.DecodeCommandRequest(&in.Data); err == nil {
+			r.Command = &command{Type: request.Type, Text: string(request.Command)}
+		}
+	case typeCommandResponse:
+		var response teltonikaparser.CommandResponse
+		if response, err = teltonikaparser.DecodeCommandResponse(&in.Data); err == nil {
+			r.Command = &command{Type: response.Type, Text: string(response.Response)}
+		}
+	case typeCommandUDP:
+		var imei string
+		var response teltonikaparser.CommandResponse
+		if imei, response, err = teltonikaparser.DecodeCommandResponseUDP(&in.Data); err == nil {
+			r.Command = &command{IMEI: imei, Type: response.Type, Text: string(response.Response)}
+		}
+	default:
+		err = fmt.Errorf("Unknown packet type %q", packetType)
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+		var decodeErr *teltonikaparser.DecodeError
+		if errors.As(err, &decodeErr) {
+			offset := decodeErr.Offset
+			r.Offset = &offset
+		}
+	}
+	return r
+}
+
+// dissect splits the packet into fields, Codec 12 packets are dissected by their framing
+func dissect(in input, packetType string) result {
+	r := result{Source: in.Source, Length: len(in.Data), raw: in.Data}
+	if in.Err != nil {
+		r.Error = in.Err.Error()
+		return r
+	}
+
+	if packetType == typeAuto {
+		packetType = detectType(in.Data)
+	}
+	r.Type = packetType
+
+	var d teltonikaparser.Dissection
+	switch packetType {
+	case typeUDP, typeCommandUDP:
+		d = teltonikaparser.DissectUDP(&in.Data)
+	case typeTCP, typeCommandRequest, typeCommandResponse:
+		d = teltonikaparser.DissectTCP(&in.Data)
+	default:
+		r.Error = fmt.Sprintf("Unknown packet type %q", packetType)
+		return r
+	}
+
+	for _, f := range d.Fields {
+		r.Fields = append(r.Fields, field{Name: f.Name, Offset: f.Offset, Length: f.Length, Value: f.Value, Depth: f.Depth})
+	}
+	r.dump = d.String()
+	if d.Err != nil {
+		r.Error = d.Err.Error()
+		offset := d.Err.Offset
+		r.Offset = &offset
+	}
+	return r
+}
+
+// newPacket converts the decoded packet for printing
+func newPacket(decoded *teltonikaparser.Decoded, human *teltonikaparser.HumanDecoder, device string) *packet {
+	p := &packet{
+		IMEI:        decoded.IMEI,
+		CodecID:     decoded.CodecID,
+		PacketID:    decoded.PacketID,
+		AVLPacketID: decoded.AVLPacketID,
+		NoOfData:    decoded.NoOfData,
+		Response:    hex.EncodeToString(decoded.Response),
+		Records:     make([]record, 0, len(decoded.Data)),
+	}
+
+	for _, data := range decoded.Data {
+		rec := record{
+			Time:     time.Unix(0, int64(data.UtimeMs)*int64(time.Millisecond)).UTC(),
+			Priority: data.Priority,
+			Lat:      float64(data.Lat) / 1e7,
+			Lng:      float64(data.Lng) / 1e7,
+			Altitude: data.Altitude,
+			Angle:    data.Angle,
+			VisSat:   data.VisSat,
+			Speed:    data.Speed,
+			EventID:  data.EventID,
+			Elements: make([]element, 0, len(data.Elements)),
+		}
+
+		for i := range data.Elements {
+			rec.Elements = append(rec.Elements, newElement(&data.Elements[i], human, device))
+		}
+		p.Records = append(p.Records, rec)
+	}
+	return p
+}
+
+// newElement converts the element, it is decoded by the dictionary if human is not nil
+func newElement(el *teltonikaparser.Element, human *teltonikaparser.HumanDecoder, device string) element {
+	e := element{IOID: el.IOID, Length: el.Length, Value: hex.EncodeToString(el.Value)}
+	if human == nil {
+		return e
+	}
+
+	h, err := human.Human(el, device)
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+
+	e.Name = h.AvlEncodeKey.PropertyName
+	e.Units = h.AvlEncodeKey.Units
+	if e.Final, err = h.GetFinalValue(); err != nil {
+		e.Error = err.Error()
+		return e
+	}
+	if len(h.AvlEncodeKey.Values) > 0 {
+		e.Label, _ = h.GetLabel()
+	}
+	return e
+}
+
+// write prints results in the format
+func write(w io.Writer, results []result, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		for _, r := range results {
+			if err := encoder.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "text":
+		for _, r := range results {
+			writeText(w, &r)
+		}
+		return nil
+	}
+	return fmt.Errorf("Unknown output format %q, want text, json or ndjson", format)
+}
+
+// writeText prints one result in human readable form
+func writeText(w io.Writer, r *result) {
+	if r.dump != "" {
+		fmt.Fprintf(w, "%v: %v packet of %v Bytes\n%v\n", r.Source, r.Type, r.Length, r.dump)
+		return
+	}
+
+	if r.Error != "" {
+		if r.Offset != nil {
+			fmt.Fprintf(w, "%v: %v packet of %v Bytes, error at Byte %v: %v\n", r.Source, r.Type, r.Length, *r.Offset, r.Error)
+			fmt.Fprintf(w, "  %v\n", errorContext(r.raw, *r.Offset))
+		} else {
+			fmt.Fprintf(w, "%v: error: %v\n", r.Source, r.Error)
+		}
+		return
+	}
+
+	if r.Command != nil {
+		fmt.Fprintf(w, "%v: %v", r.Source, r.Type)
+		if r.Command.IMEI != "" {
+			fmt.Fprintf(w, " IMEI %v", r.Command.IMEI)
+		}
+		fmt.Fprintf(w, "\n  %q\n", r.Command.Text)
+		return
+	}
+
+	p := r.Packet
+	fmt.Fprintf(w, "%v: %v Codec %#02x", r.Source, r.Type, p.CodecID)
+	if p.IMEI != "" {
+		fmt.Fprintf(w, " IMEI %v AVL packet ID %v", p.IMEI, p.AVLPacketID)
+	}
+	fmt.Fprintf(w, ", %v records, response %v\n", p.NoOfData, p.Response)
+
+	for i, rec := range p.Records {
+		fmt.Fprintf(w, "  record %v: %v priority %v lat %.7f lng %.7f altitude %v angle %v satellites %v speed %v event %v\n",
+			i+1, rec.Time.Format(time.RFC3339Nano), rec.Priority, rec.Lat, rec.Lng, rec.Altitude, rec.Angle, rec.VisSat, rec.Speed, rec.EventID)
+		for _, e := range rec.Elements {
+			fmt.Fprintf(w, "    IO %-5v %v", e.IOID, e.Value)
+			if e.Name != "" {
+				fmt.Fprintf(w, "  %v = %v", e.Name, e.Final)
+				if e.Units != "" && e.Units != "-" {
+					fmt.Fprintf(w, " %v", e.Units)
+				}
+				if e.Label != "" {
+					fmt.Fprintf(w, " (%v)", e.Label)
+				}
+			}
+			if e.Error != "" {
+				fmt.Fprintf(w, "  (%v)", e.Error)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// errorContext returns up to 8 Bytes around offset with the failing Byte in brackets
+func errorContext(data []byte, offset int) string {
+	from, to := offset-8, offset+9
+	if from < 0 {
+		from = 0
+	}
+	if to > len(data) {
+		to = len(data)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%06x:", from)
+	for i := from; i < to; i++ {
+		if i == offset {
+			fmt.Fprintf(&b, " [%02x]", data[i])
+		} else {
+			fmt.Fprintf(&b, " %02x", data[i])
+		}
+	}
+	if offset >= len(data) {
+		b.WriteString(" [end of packet]")
+	}
+	return b.String()
+}
+
+// shorten returns up to 40 characters of s
+func shorten(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "..."
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// the first Codec 8 example of the Teltonika wiki
+const wikiTCP = "000000000000003608010000016b40d8ea30010000000000000000000000000000000105021503010101425e0f01f10000601a014e0000000000000000010000c7cf"
+
+func TestParseText(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Text      string
+		Format    string
+		Expected  string
+		ErrorCase bool
+	}{
+		{Name: "Hex", Text: "000000000000000f", Format: "auto", Expected: "000000000000000f"},
+		{Name: "HexWithSpaces", Text: "00 0f:ca 0xfe", Format: "auto", Expected: "000fcafe"},
+		{Name: "Base64", Text: "AA/K/g==", Format: "auto", Expected: "000fcafe"},
+		{Name: "Base64Forced", Text: "AA/K/g==", Format: "base64", Expected: "000fcafe"},
+		{Name: "InvalidHex", Text: "AA/K/g==", Format: "hex", ErrorCase: true},
+		{Name: "Garbage", Text: "not a packet!", Format: "auto", ErrorCase: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			data, err := parseText(testCase.Text, testCase.Format)
+			if testCase.ErrorCase {
+				if err == nil {
+					test.Logf("This is an error case but there is no error.")
+					test.Fail()
+				}
+				return
+			}
+			if err != nil {
+				test.Fatalf("Failed to parse %q. %v", testCase.Text, err)
+			}
+			if actual := hex.EncodeToString(data); actual != testCase.Expected {
+				test.Logf("Expected value: %v, Actual value: %v", testCase.Expected, actual)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestReadInputs(t *testing.T) {
+	stdin := strings.NewReader("# captured on 2019-06-10\n" + wikiTCP + "\n\n000fcafe\n")
+	inputs, err := readInputs(nil, nil, stdin, "auto")
+	if err != nil {
+		t.Fatalf("Failed to read inputs. %v", err)
+	}
+	if len(inputs) != 2 || inputs[0].Source != "stdin:2" || inputs[1].Source != "stdin:4" || len(inputs[0].Data) != 66 {
+		t.Errorf("Unexpected inputs %+v", inputs)
+	}
+
+	// binary content is one raw packet
+	raw := splitContent("packet.bin", []byte{0x00, 0x0f, 0xca, 0xfe}, "auto")
+	if len(raw) != 1 || hex.EncodeToString(raw[0].Data) != "000fcafe" {
+		t.Errorf("Unexpected raw input %+v", raw)
+	}
+}
+
+func TestDetectType(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Packet   string
+		Expected string
+	}{
+		{Name: "TCP", Packet: wikiTCP, Expected: typeTCP},
+		{Name: "CommandRequest", Packet: "000000000000000f0c010500000007676574696e666f0100004312", Expected: typeCommandRequest},
+		{Name: "CommandResponse", Packet: "00000000000000900c010600000088494e493a323031392f372f323220373a3232205254433a323031392f372f323220373a3533205253543a32204552523a312053523a302042523a302043463a302046473a3020464c3a302054553a302f302055543a3020534d533a30204e4f4750533a303a3330204750533a31205341543a302052533a332052463a36352053463a31204d443a30010000c78f", Expected: typeCommandResponse},
+		{Name: "UDP", Packet: "0086cafe0101000f3335323039333038353639383230368e01", Expected: typeUDP},
+		{Name: "CommandResponseUDP", Packet: "0023cafe0101000f3335323039333038353639383230360c0106", Expected: typeCommandUDP},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			data, _ := hex.DecodeString(testCase.Packet)
+			if actual := detectType(data); actual != testCase.Expected {
+				test.Logf("Expected value: %v, Actual value: %v", testCase.Expected, actual)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	data, _ := hex.DecodeString(wikiTCP)
+	corrupted, _ := hex.DecodeString(wikiTCP[:len(wikiTCP)-2] + "00")
+
+	results := []result{
+		decode(input{Source: "arg 1", Data: data}, typeAuto, nil, ""),
+		decode(input{Source: "arg 2", Data: corrupted}, typeAuto, nil, ""),
+	}
+
+	var out bytes.Buffer
+	if err := write(&out, results, "ndjson"); err != nil {
+		t.Fatalf("Failed to write results. %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %q", out.String())
+	}
+
+	var ok, failed result
+	if err := json.Unmarshal([]byte(lines[0]), &ok); err != nil {
+		t.Fatalf("Failed to parse %q. %v", lines[0], err)
+	}
+	if ok.Type != typeTCP || ok.Packet == nil || len(ok.Packet.Records) != 1 || ok.Packet.Response != "00000001" {
+		t.Errorf("Unexpected result %v", lines[0])
+	}
+	if record := ok.Packet.Records[0]; record.Time.Format("2006-01-02T15:04:05Z07:00") != "2019-06-10T10:04:46Z" || record.Elements[2].Value != "5e0f" {
+		t.Errorf("Unexpected record %+v", record)
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &failed); err != nil {
+		t.Fatalf("Failed to parse %q. %v", lines[1], err)
+	}
+	if failed.Error == "" || failed.Offset == nil || *failed.Offset != 62 {
+		t.Errorf("Unexpected result %v", lines[1])
+	}
+}
+
+func TestErrorContext(t *testing.T) {
+	data := []byte{0x00, 0x01, 0x02, 0x03}
+	if actual := errorContext(data, 2); actual != "000000: 00 01 [02] 03" {
+		t.Errorf("Unexpected context %q", actual)
+	}
+	if actual := errorContext(data, 4); actual != "000000: 00 01 02 03 [end of packet]" {
+		t.Errorf("Unexpected context %q", actual)
+	}
+}
+
+func TestDissect(t *testing.T) {
+	corrupted, _ := hex.DecodeString(wikiTCP[:len(wikiTCP)-2] + "00")
+
+	r := dissect(input{Source: "arg 1", Data: corrupted}, typeAuto)
+	if r.Type != typeTCP || r.Offset == nil || *r.Offset != 62 || len(r.Fields) == 0 {
+		t.Fatalf("Unexpected result %+v", r)
+	}
+
+	var out bytes.Buffer
+	if err := write(&out, []result{r}, "text"); err != nil {
+		t.Fatalf("Failed to write results. %v", err)
+	}
+	if !strings.Contains(out.String(), "IO 78: ") || !strings.Contains(out.String(), "[00]") {
+		t.Errorf("Unexpected dissection\n%v", out.String())
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command teltonika-pcap decodes Teltonika traffic captured by tcpdump or Wireshark.
+//
+// Usage:
+//
+//	tcpdump -i any -w gateway.pcap port 5027
+//	teltonika-pcap -ports 5027 gateway.pcap
+//	teltonika-pcap -o ndjson gateway.pcapng > records.ndjson
+//
+// Classic pcap and pcapng files are supported, TCP streams are reassembled. Every packet sent by a device is printed
+// with its capture timestamp, statistics of the capture including undecodable packets are printed to stderr.
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser/pcap"
+)
+
+// message is a decoded packet as printed
+type message struct {
+	Time     time.Time `json:"time"`
+	Network  string    `json:"network"`
+	Src      string    `json:"src"`
+	Dst      string    `json:"dst"`
+	IMEI     string    `json:"imei,omitempty"`
+	CodecID  byte      `json:"codecId,omitempty"`
+	Records  []record  `json:"records,omitempty"`
+	Response string    `json:"response,omitempty"` // Codec 12 response
+	Error    string    `json:"error,omitempty"`
+	Packet   string    `json:"packet,omitempty"` // undecodable packet in hex
+}
+
+type record struct {
+	Time     time.Time         `json:"time"`
+	Priority uint8             `json:"priority"`
+	Lat      float64           `json:"lat"`
+	Lng      float64           `json:"lng"`
+	Altitude int16             `json:"altitude"`
+	Angle    uint16            `json:"angle"`
+	VisSat   uint8             `json:"satellites"`
+	Speed    uint16            `json:"speed"`
+	EventID  uint16            `json:"eventId"`
+	IO       map[uint16]string `json:"io"`
+}
+
+func main() {
+	ports := flag.String("ports", "5027", "comma separated ports of the server, empty decodes traffic to all ports")
+	output := flag.String("o", "text", "output format, text, json or ndjson")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: teltonika-pcap [flags] capture.pcap ...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	if *output != "text" && *output != "json" && *output != "ndjson" {
+		log.Fatalf("Unknown output format %q, want text, json or ndjson", *output)
+	}
+
+	replayer := &pcap.Replayer{}
+	for _, item := range strings.Split(*ports, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		port, err := strconv.ParseUint(item, 10, 16)
+		if err != nil {
+			log.Fatalf("Invalid port %q", item)
+		}
+		replayer.Ports = append(replayer.Ports, uint16(port))
+	}
+
+	var all []message
+	encoder := json.NewEncoder(os.Stdout)
+	for _, name := range flag.Args() {
+		stats, err := replayFile(replayer, name, func(m message) error {
+			switch *output {
+			case "json":
+				all = append(all, m)
+				return nil
+			case "ndjson":
+				return encoder.Encode(m)
+			}
+			writeText(os.Stdout, &m)
+			return nil
+		})
+		printStats(os.Stderr, name, stats)
+		if err != nil {
+			log.Fatalf("%v: %v", name, err)
+		}
+	}
+
+	if *output == "json" {
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(all); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// replayFile decodes the capture file and passes its messages to emit
+func replayFile(replayer *pcap.Replayer, name string, emit func(message) error) (pcap.Stats, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return pcap.Stats{}, err
+	}
+	defer f.Close()
+
+	return replayer.Replay(f, func(m *pcap.Message) error {
+		return emit(newMessage(m))
+	})
+}
+
+// newMessage converts the message for printing
+func newMessage(m *pcap.Message) message {
+	out := message{Time: m.Time, Network: "udp", Src: m.Src.String(), Dst: m.Dst.String(), IMEI: m.IMEI}
+	if m.TCP {
+		out.Network = "tcp"
+	}
+
+	switch {
+	case m.Err != nil:
+		out.Error = m.Err.Error()
+		out.Packet = hex.EncodeToString(m.Packet)
+	case m.Command != nil:
+		out.CodecID = m.Command.CodecID
+		out.Response = string(m.Command.Response)
+	case m.Decoded != nil:
+		out.CodecID = m.Decoded.CodecID
+		for _, data := range m.Decoded.Data {
+			r := record{
+				Time:     time.Unix(0, int64(data.UtimeMs)*int64(time.Millisecond)).UTC(),
+				Priority: data.Priority,
+				Lat:      float64(data.Lat) / 1e7,
+				Lng:      float64(data.Lng) / 1e7,
+				Altitude: data.Altitude,
+				Angle:    data.Angle,
+				VisSat:   data.VisSat,
+				Speed:    data.Speed,
+				EventID:  data.EventID,
+				IO:       make(map[uint16]string, len(data.Elements)),
+			}
+			for _, el := range data.Elements {
+				r.IO[el.IOID] = hex.EncodeToString(el.Value)
+			}
+			out.Records = append(out.Records, r)
+		}
+	}
+	return out
+}
+
+// writeText prints one message in human readable form
+func writeText(w io.Writer, m *message) {
+	fmt.Fprintf(w, "%v %v %v -> %v", m.Time.Format(time.RFC3339Nano), m.Network, m.Src, m.Dst)
+	if m.IMEI != "" {
+		fmt.Fprintf(w, " IMEI %v", m.IMEI)
+	}
+
+	switch {
+	case m.Error != "":
+		fmt.Fprintf(w, " undecodable: %v\n  %v\n", m.Error, m.Packet)
+	case m.Response != "" || m.CodecID == 0x0c:
+		fmt.Fprintf(w, " Codec 12 response %q\n", m.Response)
+	default:
+		fmt.Fprintf(w, " Codec %#02x, %v records\n", m.CodecID, len(m.Records))
+		for _, r := range m.Records {
+			fmt.Fprintf(w, "  %v lat %.7f lng %.7f altitude %v angle %v satellites %v speed %v event %v, %v IO elements\n",
+				r.Time.Format(time.RFC3339), r.Lat, r.Lng, r.Altitude, r.Angle, r.VisSat, r.Speed, r.EventID, len(r.IO))
+		}
+	}
+}
+
+// printStats prints statistics of the capture
+func printStats(w io.Writer, name string, s pcap.Stats) {
+	fmt.Fprintf(w, "%v: packets: %v skipped: %v truncated: %v udp datagrams: %v tcp segments: %v retransmitted: %v gaps: %v skipped bytes: %v\n",
+		name, s.Packets, s.Skipped, s.Truncated, s.Datagrams, s.Segments, s.Retransmitted, s.Gaps, s.SkippedBytes)
+	fmt.Fprintf(w, "%v: logins: %v decoded: %v records: %v commands: %v undecodable: %v\n",
+		name, s.Logins, s.Decoded, s.Records, s.Commands, s.Undecodable)
+}
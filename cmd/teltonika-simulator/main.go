@@ -0,0 +1,199 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command teltonika-simulator emulates a fleet of Teltonika devices sending records to a server.
+//
+// Usage:
+//
+//	teltonika-simulator -addr localhost:5027 -network tcp -devices 100 -codec 8e -period 1s
+//
+// Every device has a valid IMEI, drives along the route, retransmits packets which are not acknowledged
+// and answers getinfo, getver and getgps Codec 12 commands. Statistics are printed every 10 seconds.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser/simulator"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:5027", "address of the server")
+	network := flag.String("network", "udp", "udp or tcp")
+	devices := flag.Int("devices", 1, "number of simulated devices")
+	first := flag.Int("first", 1, "serial number of the first IMEI")
+	codec := flag.String("codec", "8", "codec of packets, 8, 8e or 16")
+	period := flag.Duration("period", time.Second, "period of records")
+	records := flag.Int("records", 1, "records per packet")
+	speed := flag.Float64("speed", 50, "speed in km/h")
+	ioList := flag.String("io", "239,240,21,24,66,16", "IO elements of records, known IDs or id:size for zero valued elements")
+	routeFile := flag.String("route", "", "file with lat,lng points of the route, one per line, default is a loop in Brno")
+	ackTimeout := flag.Duration("ack-timeout", 5*time.Second, "time to wait for an acknowledgement before retransmission")
+	retries := flag.Int("retries", 3, "retransmissions before reconnecting, 0 reconnects after the first timeout")
+	duration := flag.Duration("duration", 0, "stop after the duration, zero runs until interrupted")
+	flag.Parse()
+
+	codecID, err := parseCodec(*codec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	io, err := parseIO(*ioList)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the flag counts retransmissions, Device takes zero for the default and a negative value for none
+	if *retries == 0 {
+		*retries = -1
+	}
+
+	route := simulator.DefaultRoute
+	if *routeFile != "" {
+		if route, err = readRoute(*routeFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fleet := simulator.Fleet(&simulator.Device{
+		Network:    *network,
+		Addr:       *addr,
+		Codec:      codecID,
+		Route:      route,
+		Speed:      *speed,
+		Period:     *period,
+		Records:    *records,
+		IO:         io,
+		AckTimeout: *ackTimeout,
+		Retries:    *retries,
+	}, *first, *devices)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	go report(ctx, fleet)
+
+	err = simulator.RunFleet(ctx, fleet)
+	printStats(fleet)
+	if err != nil && err != context.Canceled && err != context.DeadlineExceeded {
+		log.Fatal(err)
+	}
+}
+
+// report prints statistics every 10 seconds
+func report(ctx context.Context, fleet []*simulator.Device) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			printStats(fleet)
+		}
+	}
+}
+
+func printStats(fleet []*simulator.Device) {
+	total := simulator.Stats{}
+	for _, device := range fleet {
+		total = total.Add(device.Stats())
+	}
+	fmt.Printf("devices: %v packets: %v retransmitted: %v acknowledged records: %v commands: %v reconnects: %v\n",
+		len(fleet), total.Packets, total.Retransmitted, total.Acknowledged, total.Commands, total.Reconnects)
+}
+
+func parseCodec(codec string) (byte, error) {
+	switch strings.ToLower(codec) {
+	case "8":
+		return simulator.Codec8, nil
+	case "8e":
+		return simulator.Codec8Extended, nil
+	case "16":
+		return simulator.Codec16, nil
+	}
+	return 0, fmt.Errorf("Unknown codec %q, want 8, 8e or 16", codec)
+}
+
+// parseIO parses comma separated known IO IDs or id:size pairs
+func parseIO(list string) ([]simulator.IO, error) {
+	var io []simulator.IO
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		idText, sizeText, sized := strings.Cut(item, ":")
+		id, err := strconv.ParseUint(idText, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid IO %q, %v", item, err)
+		}
+
+		if !sized {
+			known, ok := simulator.KnownIO(uint16(id))
+			if !ok {
+				return nil, fmt.Errorf("Unknown IO %v, use id:size", id)
+			}
+			io = append(io, known)
+			continue
+		}
+
+		size, err := strconv.Atoi(sizeText)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("Invalid size of IO %q", item)
+		}
+		io = append(io, simulator.IO{ID: uint16(id), Size: size})
+	}
+	return io, nil
+}
+
+// readRoute reads lat,lng points, one per line
+func readRoute(name string) (simulator.Route, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var route simulator.Route
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		latText, lngText, ok := strings.Cut(text, ",")
+		lat, latErr := strconv.ParseFloat(strings.TrimSpace(latText), 64)
+		lng, lngErr := strconv.ParseFloat(strings.TrimSpace(lngText), 64)
+		if !ok || latErr != nil || lngErr != nil {
+			return nil, fmt.Errorf("%v:%v: want lat,lng, got %q", name, line, text)
+		}
+		route = append(route, simulator.Point{Lat: lat, Lng: lng})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(route) < 2 {
+		return nil, fmt.Errorf("%v: route needs at least 2 points", name)
+	}
+	return route, nil
+}
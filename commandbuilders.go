@@ -0,0 +1,204 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package teltonikaparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Command is a validated text of a standard device command, create it by the builders like GetGPS or SetDigOut
+// and send it by Encode, EncodeUDP or as a text
+type Command struct {
+	text string
+}
+
+// String returns the text of the command
+func (c Command) String() string {
+	return c.text
+}
+
+// Encode returns the Codec 12 TCP packet of the command, it is the same as EncodeCommandRequest(c.String())
+func (c Command) Encode() ([]byte, error) {
+	return EncodeCommandRequest(c.text)
+}
+
+// EncodeUDP returns the Codec 12 UDP packet of the command, it is the same as EncodeCommandRequestUDP with c.String()
+func (c Command) EncodeUDP(imei string, packetID uint16, avlPacketID byte) ([]byte, error) {
+	return EncodeCommandRequestUDP(imei, packetID, avlPacketID, c.text)
+}
+
+// GetInfo returns the getinfo command, the device answers with runtime information like RTC time, resets and GPS state
+func GetInfo() Command {
+	return Command{text: "getinfo"}
+}
+
+// GetVer returns the getver command, the device answers with firmware and hardware versions and the IMEI
+func GetVer() Command {
+	return Command{text: "getver"}
+}
+
+// GetStatus returns the getstatus command, the device answers with the state of data link, GPRS and GSM
+func GetStatus() Command {
+	return Command{text: "getstatus"}
+}
+
+// GetGPS returns the getgps command, the device answers with the current GPS position, speed and time
+func GetGPS() Command {
+	return Command{text: "getgps"}
+}
+
+// GetIO returns the getio command, the device answers with states of digital inputs, outputs and analog inputs
+func GetIO() Command {
+	return Command{text: "getio"}
+}
+
+// CPUReset returns the cpureset command which restarts the device
+func CPUReset() Command {
+	return Command{text: "cpureset"}
+}
+
+// DeleteRecords returns the deleterecords command which deletes all records saved on the device
+func DeleteRecords() Command {
+	return Command{text: "deleterecords"}
+}
+
+// ReadIO returns the readio command, the device answers with the value of the IO element id
+func ReadIO(id uint16) Command {
+	return Command{text: fmt.Sprintf("readio %v", id)}
+}
+
+// GetParam returns the getparam command for one or more configuration parameters
+func GetParam(ids ...uint16) (Command, error) {
+	if len(ids) == 0 {
+		return Command{}, fmt.Errorf("Missing parameter ID of getparam")
+	}
+
+	texts := make([]string, len(ids))
+	for i, id := range ids {
+		texts[i] = strconv.Itoa(int(id))
+	}
+	return Command{text: "getparam " + strings.Join(texts, ";")}, nil
+}
+
+// Param is a configuration parameter set by SetParam
+type Param struct {
+	ID    uint16 // ID of the parameter, e.g. 2004 for the server domain
+	Value string // Value of the parameter as sent in the command
+}
+
+// SetParam returns the setparam command for one or more configuration parameters in the given order,
+// a value can not contain ';' which separates the parameters
+func SetParam(params ...Param) (Command, error) {
+	if len(params) == 0 {
+		return Command{}, fmt.Errorf("Missing parameter of setparam")
+	}
+
+	texts := make([]string, len(params))
+	for i, param := range params {
+		if strings.ContainsAny(param.Value, ";\r\n") {
+			return Command{}, fmt.Errorf("Invalid value %q of parameter %v, it can not contain ';' or a new line", param.Value, param.ID)
+		}
+		texts[i] = fmt.Sprintf("%v:%v", param.ID, param.Value)
+	}
+	return Command{text: "setparam " + strings.Join(texts, ";")}, nil
+}
+
+// OutputState is a requested state of a digital output
+type OutputState int
+
+// States of digital outputs
+const (
+	OutputUnchanged OutputState = iota // OutputUnchanged keeps the output as it is, '?' in the command
+	OutputOff                          // OutputOff switches the output off, '0' in the command
+	OutputOn                           // OutputOn switches the output on, '1' in the command
+)
+
+// DigitalOutput is the requested state of one output of SetDigOut
+type DigitalOutput struct {
+	State   OutputState   // State of the output
+	Timeout time.Duration // Timeout after which the output returns back, zero means the state is kept, it must be whole seconds
+}
+
+// maxOutputs is the most digital outputs of a device
+const maxOutputs = 4
+
+// SetDigOut returns the setdigout command, outputs are DOUT1, DOUT2 ... in this order. Timeouts are added only if an output has one,
+// e.g. SetDigOut(DigitalOutput{State: OutputOn, Timeout: time.Minute}, DigitalOutput{}, DigitalOutput{}) returns "setdigout 1?? 60 0 0".
+func SetDigOut(outputs ...DigitalOutput) (Command, error) {
+	if len(outputs) == 0 || len(outputs) > maxOutputs {
+		return Command{}, fmt.Errorf("Invalid number of digital outputs, want 1 to %v, got %v", maxOutputs, len(outputs))
+	}
+
+	var states strings.Builder
+	timeouts := make([]string, len(outputs))
+	changed, timed := false, false
+	for i, output := range outputs {
+		switch output.State {
+		case OutputUnchanged:
+			states.WriteByte('?')
+		case OutputOff:
+			states.WriteByte('0')
+		case OutputOn:
+			states.WriteByte('1')
+		default:
+			return Command{}, fmt.Errorf("Invalid state %v of DOUT%v", output.State, i+1)
+		}
+		changed = changed || output.State != OutputUnchanged
+
+		if output.Timeout < 0 || output.Timeout%time.Second != 0 {
+			return Command{}, fmt.Errorf("Invalid timeout %v of DOUT%v, want whole seconds", output.Timeout, i+1)
+		}
+		if output.Timeout > 0 && output.State == OutputUnchanged {
+			return Command{}, fmt.Errorf("Invalid timeout %v of DOUT%v which is not changed", output.Timeout, i+1)
+		}
+		timed = timed || output.Timeout > 0
+		timeouts[i] = strconv.FormatInt(int64(output.Timeout/time.Second), 10)
+	}
+
+	if !changed {
+		return Command{}, fmt.Errorf("No digital output is changed")
+	}
+
+	text := "setdigout " + states.String()
+	if timed {
+		text += " " + strings.Join(timeouts, " ")
+	}
+	return Command{text: text}, nil
+}
+
+// Flush returns the flush command which makes the device connect to another server, protocol is "TCP" or "UDP".
+// The device closes the current connection, sends its records to the new server and uses it until a restart.
+func Flush(imei string, apn string, login string, password string, host string, port uint16, protocol string) (Command, error) {
+	if (len(imei) != 15 && len(imei) != 16) || !isDigits(imei) {
+		return Command{}, fmt.Errorf("Invalid IMEI %q, want 15 or 16 digits", imei)
+	}
+	if host == "" {
+		return Command{}, fmt.Errorf("Missing host of flush")
+	}
+	if port == 0 {
+		return Command{}, fmt.Errorf("Missing port of flush")
+	}
+
+	var mode int
+	switch strings.ToUpper(protocol) {
+	case "TCP":
+		mode = 0
+	case "UDP":
+		mode = 1
+	default:
+		return Command{}, fmt.Errorf("Invalid protocol %q, want TCP or UDP", protocol)
+	}
+
+	for _, field := range []string{apn, login, password, host} {
+		if strings.ContainsAny(field, ", \r\n") {
+			return Command{}, fmt.Errorf("Invalid argument %q of flush, it can not contain ',' or white space", field)
+		}
+	}
+
+	return Command{text: fmt.Sprintf("flush %v,%v,%v,%v,%v,%v,%v", imei, apn, login, password, host, port, mode)}, nil
+}
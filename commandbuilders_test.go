@@ -0,0 +1,99 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package teltonikaparser
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestCommandBuilders(t *testing.T) {
+	on := func(timeout time.Duration) DigitalOutput { return DigitalOutput{State: OutputOn, Timeout: timeout} }
+
+	testCases := []struct {
+		Name      string
+		Build     func() (Command, error)
+		Expected  string
+		ErrorCase bool
+	}{
+		{Name: "GetInfo", Build: func() (Command, error) { return GetInfo(), nil }, Expected: "getinfo"},
+		{Name: "GetVer", Build: func() (Command, error) { return GetVer(), nil }, Expected: "getver"},
+		{Name: "GetStatus", Build: func() (Command, error) { return GetStatus(), nil }, Expected: "getstatus"},
+		{Name: "GetGPS", Build: func() (Command, error) { return GetGPS(), nil }, Expected: "getgps"},
+		{Name: "GetIO", Build: func() (Command, error) { return GetIO(), nil }, Expected: "getio"},
+		{Name: "CPUReset", Build: func() (Command, error) { return CPUReset(), nil }, Expected: "cpureset"},
+		{Name: "DeleteRecords", Build: func() (Command, error) { return DeleteRecords(), nil }, Expected: "deleterecords"},
+		{Name: "ReadIO", Build: func() (Command, error) { return ReadIO(66), nil }, Expected: "readio 66"},
+		{Name: "GetParam", Build: func() (Command, error) { return GetParam(2004, 2005) }, Expected: "getparam 2004;2005"},
+		{Name: "GetParamNone", Build: func() (Command, error) { return GetParam() }, ErrorCase: true},
+		{Name: "SetParam", Build: func() (Command, error) {
+			return SetParam(Param{ID: 2004, Value: "gps.example.com"}, Param{ID: 2005, Value: "5027"})
+		}, Expected: "setparam 2004:gps.example.com;2005:5027"},
+		{Name: "SetParamSeparator", Build: func() (Command, error) { return SetParam(Param{ID: 2004, Value: "a;2005:1"}) }, ErrorCase: true},
+		{Name: "SetParamNone", Build: func() (Command, error) { return SetParam() }, ErrorCase: true},
+		{Name: "SetDigOutTimer", Build: func() (Command, error) { return SetDigOut(on(time.Minute), DigitalOutput{}, DigitalOutput{}) }, Expected: "setdigout 1?? 60 0 0"},
+		{Name: "SetDigOutNoTimer", Build: func() (Command, error) { return SetDigOut(DigitalOutput{State: OutputOff}, on(0)) }, Expected: "setdigout 01"},
+		{Name: "SetDigOutTimers", Build: func() (Command, error) {
+			return SetDigOut(on(5*time.Second), DigitalOutput{State: OutputOff, Timeout: 10 * time.Second})
+		}, Expected: "setdigout 10 5 10"},
+		{Name: "SetDigOutUnchanged", Build: func() (Command, error) { return SetDigOut(DigitalOutput{}, DigitalOutput{}) }, ErrorCase: true},
+		{Name: "SetDigOutTimerOfUnchanged", Build: func() (Command, error) { return SetDigOut(on(0), DigitalOutput{Timeout: time.Second}) }, ErrorCase: true},
+		{Name: "SetDigOutFraction", Build: func() (Command, error) { return SetDigOut(on(1500 * time.Millisecond)) }, ErrorCase: true},
+		{Name: "SetDigOutInvalidState", Build: func() (Command, error) { return SetDigOut(DigitalOutput{State: 7}) }, ErrorCase: true},
+		{Name: "SetDigOutTooMany", Build: func() (Command, error) { return SetDigOut(on(0), on(0), on(0), on(0), on(0)) }, ErrorCase: true},
+		{Name: "SetDigOutNone", Build: func() (Command, error) { return SetDigOut() }, ErrorCase: true},
+		{Name: "Flush", Build: func() (Command, error) {
+			return Flush("352093085698206", "internet", "", "", "gps.example.com", 5027, "tcp")
+		}, Expected: "flush 352093085698206,internet,,,gps.example.com,5027,0"},
+		{Name: "FlushUDP", Build: func() (Command, error) {
+			return Flush("352093085698206", "internet", "user", "secret", "192.0.2.1", 5027, "UDP")
+		}, Expected: "flush 352093085698206,internet,user,secret,192.0.2.1,5027,1"},
+		{Name: "FlushInvalidIMEI", Build: func() (Command, error) { return Flush("35209308569820X", "internet", "", "", "192.0.2.1", 5027, "TCP") }, ErrorCase: true},
+		{Name: "FlushInvalidProtocol", Build: func() (Command, error) {
+			return Flush("352093085698206", "internet", "", "", "192.0.2.1", 5027, "SCTP")
+		}, ErrorCase: true},
+		{Name: "FlushMissingPort", Build: func() (Command, error) { return Flush("352093085698206", "internet", "", "", "192.0.2.1", 0, "TCP") }, ErrorCase: true},
+		{Name: "FlushComma", Build: func() (Command, error) {
+			return Flush("352093085698206", "inter,net", "", "", "192.0.2.1", 5027, "TCP")
+		}, ErrorCase: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			command, err := testCase.Build()
+			if testCase.ErrorCase {
+				if err == nil {
+					test.Logf("This is an error case but there is no error, got %q", command)
+					test.Fail()
+				}
+				return
+			}
+			if err != nil {
+				test.Fatalf("Failed to build command. %v", err)
+			}
+
+			if command.String() != testCase.Expected {
+				test.Logf("Expected value: %v, Actual value: %v", testCase.Expected, command)
+				test.Fail()
+			}
+
+			// the packets are the same as of the text
+			actual, _ := command.Encode()
+			expected, _ := EncodeCommandRequest(testCase.Expected)
+			if !bytes.Equal(actual, expected) {
+				test.Logf("Expected packet: %x, Actual packet: %x", expected, actual)
+				test.Fail()
+			}
+
+			actual, _ = command.EncodeUDP("352093085698206", 0xcafe, 0x01)
+			expected, _ = EncodeCommandRequestUDP("352093085698206", 0xcafe, 0x01, testCase.Expected)
+			if !bytes.Equal(actual, expected) {
+				test.Logf("Expected UDP packet: %x, Actual UDP packet: %x", expected, actual)
+				test.Fail()
+			}
+		})
+	}
+}
@@ -0,0 +1,485 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package teltonikaparser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResponseError is returned by the response parsers for a text which is not a valid response to the command,
+// e.g. "Unknown command" or a response of another command
+type ResponseError struct {
+	Command  string // Command of the parser, e.g. getgps
+	Response string // Response which can not be parsed
+	Err      error  // Err describes the failure
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("Unable to parse %v response %q, %v", e.Command, e.Response, e.Err)
+}
+
+// Unwrap returns the failure
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// GPSResponse is the response to getgps
+type GPSResponse struct {
+	Valid      bool              // Valid is true if the position is fixed, GPS:1
+	Satellites int               // Satellites in use
+	Lat        float64           // Lat is the latitude in degrees
+	Lng        float64           // Lng is the longitude in degrees
+	Altitude   int               // Altitude in meters
+	Speed      int               // Speed in km/h
+	Angle      int               // Angle is the heading in degrees, Dir
+	Time       time.Time         // Time of the position in UTC, zero if the response has no date
+	Fields     map[string]string // Fields are all fields of the response as sent
+}
+
+// VersionResponse is the response to getver
+type VersionResponse struct {
+	Firmware    string            // Firmware version, e.g. 03.27.07_04
+	GPSFirmware string            // GPSFirmware is the version of the GPS module
+	Hardware    string            // Hardware is the device model, e.g. FMB920
+	Mod         string            // Mod is the hardware modification
+	IMEI        string            // IMEI of the device
+	Init        time.Time         // Init is the time of the device initialization in UTC
+	Uptime      time.Duration     // Uptime since the last start
+	MAC         string            // MAC address of the Bluetooth module
+	Fields      map[string]string // Fields are all fields of the response as sent
+}
+
+// InfoResponse is the response to getinfo
+type InfoResponse struct {
+	Init          time.Time         // Init is the time of the device initialization in UTC, INI
+	RTC           time.Time         // RTC is the time of the device clock in UTC
+	Restarts      int               // Restarts counter, RST
+	Errors        int               // Errors counter, ERR
+	SentRecords   int               // SentRecords counter, SR
+	BrokenRecords int               // BrokenRecords counter, BR
+	SentSMS       int               // SentSMS counter, SMS
+	NoGPS         time.Duration     // NoGPS is the time without a GPS fix
+	GPSState      int               // GPSState, 0 off, 1 restarting, 2 on without a fix, 3 on with a fix, 4 sleep
+	Satellites    int               // Satellites is the average number of satellites, SAT
+	ResetSource   int               // ResetSource identifies the cause of the last restart, RS
+	Fields        map[string]string // Fields are all fields of the response as sent
+}
+
+// StatusResponse is the response to getstatus
+type StatusResponse struct {
+	DataLink bool              // DataLink is true if the device is connected to the server
+	GPRS     bool              // GPRS is true if the GPRS session is open
+	Phone    int               // Phone is the state of the voice call
+	SIM      int               // SIM is the state of the SIM card
+	Operator string            // Operator code, MCC and MNC
+	Signal   int               // Signal strength from 0 to 5
+	NewSMS   bool              // NewSMS is true if an unread SMS was received
+	Roaming  bool              // Roaming is true in a foreign network
+	SMSFull  bool              // SMSFull is true if the SMS storage is full
+	LAC      int               // LAC is the location area code
+	CellID   int               // CellID of the GSM cell
+	NetType  int               // NetType is the network type, 0 is 3G, 1 is 2G
+	Fields   map[string]string // Fields are all fields of the response as sent
+}
+
+// IOResponse is the response to getio, slices are indexed from the first input or output, e.g. DigitalInputs[0] is DI1
+type IOResponse struct {
+	DigitalInputs  []bool            // DigitalInputs DI1, DI2 ...
+	AnalogInputs   []float64         // AnalogInputs AIN1, AIN2 ... as sent by the device
+	DigitalOutputs []bool            // DigitalOutputs DO1, DO2 ...
+	Fields         map[string]string // Fields are all fields of the response as sent
+}
+
+// responseKey matches a key of a response field, only the known keys "Data Link" and "Cell ID" of getstatus have two words.
+// Values like "7:22" of "Init:2019-7-22 7:22" do not match because a key starts by a letter.
+var responseKey = regexp.MustCompile(`(?:^|\s)(Data Link|Cell ID|[A-Za-z][A-Za-z0-9_]*):`)
+
+// responseFields are fields of a response in the form "Key:Value Key: Value ..."
+type responseFields struct {
+	command string
+	text    string
+	keys    []string          // keys in the order of the response
+	fields  map[string]string // values by the key as sent
+	lower   map[string]string // values by the key in lower case
+	err     error             // the first failure of the getters
+}
+
+// parseFields splits the response into its fields
+func parseFields(command string, text string) (*responseFields, error) {
+	r := &responseFields{command: command, text: text, fields: make(map[string]string), lower: make(map[string]string)}
+
+	matches := responseKey.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 || strings.TrimSpace(text[:matches[0][0]]) != "" {
+		return nil, r.fail(fmt.Errorf("Invalid format, want Key:Value fields"))
+	}
+
+	for i, match := range matches {
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		key, value := text[match[2]:match[3]], strings.TrimSpace(text[match[1]:end])
+		if _, ok := r.fields[key]; !ok {
+			r.keys = append(r.keys, key)
+		}
+		r.fields[key] = value
+		r.lower[strings.ToLower(key)] = value
+	}
+	return r, nil
+}
+
+// fail returns ResponseError of the response
+func (r *responseFields) fail(err error) error {
+	return &ResponseError{Command: r.command, Response: r.text, Err: err}
+}
+
+// value returns the value of the first key found, the keys are compared case insensitively
+func (r *responseFields) value(keys ...string) (string, bool) {
+	for _, key := range keys {
+		if value, ok := r.lower[strings.ToLower(key)]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// require records a failure if none of the keys is found
+func (r *responseFields) require(keys ...string) {
+	if _, ok := r.value(keys...); !ok && r.err == nil {
+		r.err = fmt.Errorf("Missing field %v", keys[0])
+	}
+}
+
+// int returns the integer value of the key, zero if it is missing
+func (r *responseFields) int(keys ...string) int {
+	value, ok := r.value(keys...)
+	if !ok {
+		return 0
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("Invalid integer %q of field %v", value, keys[0])
+	}
+	return number
+}
+
+// float returns the decimal value of the key, zero if it is missing
+func (r *responseFields) float(keys ...string) float64 {
+	value, ok := r.value(keys...)
+	if !ok {
+		return 0
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil && r.err == nil {
+		r.err = fmt.Errorf("Invalid number %q of field %v", value, keys[0])
+	}
+	return number
+}
+
+// bool returns true if the value of the key is 1
+func (r *responseFields) bool(keys ...string) bool {
+	value, ok := r.value(keys...)
+	if !ok {
+		return false
+	}
+	if value != "0" && value != "1" {
+		if r.err == nil {
+			r.err = fmt.Errorf("Invalid flag %q of field %v, want 0 or 1", value, keys[0])
+		}
+		return false
+	}
+	return value == "1"
+}
+
+// timeLayouts are formats of times in responses, days, hours, minutes and seconds may have one digit
+var timeLayouts = []string{"2006/1/2 15:4:5", "2006/1/2 15:4", "2006-1-2 15:4:5", "2006-1-2 15:4"}
+
+// time returns the time of the value, times are in UTC
+func (r *responseFields) time(value string, key string) time.Time {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	if r.err == nil {
+		r.err = fmt.Errorf("Invalid time %q of field %v", value, key)
+	}
+	return time.Time{}
+}
+
+// timeOf returns the time of the key, zero if it is missing
+func (r *responseFields) timeOf(keys ...string) time.Time {
+	value, ok := r.value(keys...)
+	if !ok {
+		return time.Time{}
+	}
+	return r.time(value, keys[0])
+}
+
+// duration returns the value of the key in seconds, "7256" or "7256s"
+func (r *responseFields) duration(keys ...string) time.Duration {
+	value, ok := r.value(keys...)
+	if !ok {
+		return 0
+	}
+	seconds, err := strconv.Atoi(strings.TrimSuffix(value, "s"))
+	if err != nil || seconds < 0 {
+		if r.err == nil {
+			r.err = fmt.Errorf("Invalid duration %q of field %v", value, keys[0])
+		}
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// clock returns the value of the key in hours and minutes, "4:34"
+func (r *responseFields) clock(keys ...string) time.Duration {
+	value, ok := r.value(keys...)
+	if !ok {
+		return 0
+	}
+	hours, minutes, found := strings.Cut(value, ":")
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if !found || errH != nil || errM != nil || h < 0 || m < 0 || m > 59 {
+		if r.err == nil {
+			r.err = fmt.Errorf("Invalid duration %q of field %v, want hours:minutes", value, keys[0])
+		}
+		return 0
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+}
+
+// result returns ResponseError if a getter failed
+func (r *responseFields) result() error {
+	if r.err != nil {
+		return r.fail(r.err)
+	}
+	return nil
+}
+
+// ParseGPSResponse parses the response to getgps, e.g.
+// "GPS:1 Sat:7 Lat:54.713017 Long:25.303152 Alt:147 Speed:0 Dir:77 Date: 2019/7/23 Time: 11:27:42"
+func ParseGPSResponse(text string) (GPSResponse, error) {
+	r, err := parseFields("getgps", text)
+	if err != nil {
+		return GPSResponse{}, err
+	}
+	r.require("GPS")
+	r.require("Lat")
+	r.require("Long")
+
+	response := GPSResponse{
+		Valid:      r.bool("GPS"),
+		Satellites: r.int("Sat"),
+		Lat:        r.float("Lat"),
+		Lng:        r.float("Long"),
+		Altitude:   r.int("Alt"),
+		Speed:      r.int("Speed"),
+		Angle:      r.int("Dir"),
+		Fields:     r.fields,
+	}
+	date, hasDate := r.value("Date")
+	clock, hasTime := r.value("Time")
+	if hasDate && hasTime {
+		response.Time = r.time(date+" "+clock, "Date")
+	}
+	if response.Lat < -90 || response.Lat > 90 || response.Lng < -180 || response.Lng > 180 {
+		return GPSResponse{}, r.fail(fmt.Errorf("Invalid position %v, %v", response.Lat, response.Lng))
+	}
+
+	if err := r.result(); err != nil {
+		return GPSResponse{}, err
+	}
+	return response, nil
+}
+
+// ParseVersionResponse parses the response to getver, e.g.
+// "Ver:03.18.14_04 GPS:AXN_3.80 Hw:FMB920 Mod:10 IMEI:352093081452251 Init:2019-7-22 7:22 Uptime:7256 MAC:60C5A8D24E2F"
+func ParseVersionResponse(text string) (VersionResponse, error) {
+	r, err := parseFields("getver", text)
+	if err != nil {
+		return VersionResponse{}, err
+	}
+	r.require("Ver")
+
+	response := VersionResponse{
+		Firmware:    r.lower["ver"],
+		GPSFirmware: r.lower["gps"],
+		Hardware:    r.lower["hw"],
+		Mod:         r.lower["mod"],
+		IMEI:        r.lower["imei"],
+		Init:        r.timeOf("Init"),
+		Uptime:      r.duration("Uptime"),
+		MAC:         r.lower["mac"],
+		Fields:      r.fields,
+	}
+	if response.IMEI != "" && !isDigits(response.IMEI) {
+		return VersionResponse{}, r.fail(fmt.Errorf("Invalid IMEI %q", response.IMEI))
+	}
+
+	if err := r.result(); err != nil {
+		return VersionResponse{}, err
+	}
+	return response, nil
+}
+
+// ParseInfoResponse parses the response to getinfo, e.g.
+// "INI:2019/7/22 7:22 RTC:2019/7/22 7:53 RST:2 ERR:1 SR:0 BR:0 CF:0 FG:0 FL:0 TU:0/0 UT:0 SMS:0 NOGPS:0:30 GPS:1 SAT:0 RS:3 RF:65 SF:1 MD:0"
+func ParseInfoResponse(text string) (InfoResponse, error) {
+	r, err := parseFields("getinfo", text)
+	if err != nil {
+		return InfoResponse{}, err
+	}
+	r.require("RTC")
+
+	response := InfoResponse{
+		Init:          r.timeOf("INI", "Init"),
+		RTC:           r.timeOf("RTC"),
+		Restarts:      r.int("RST"),
+		Errors:        r.int("ERR"),
+		SentRecords:   r.int("SR"),
+		BrokenRecords: r.int("BR"),
+		SentSMS:       r.int("SMS"),
+		NoGPS:         r.clock("NOGPS"),
+		GPSState:      r.int("GPS"),
+		Satellites:    r.int("SAT"),
+		ResetSource:   r.int("RS"),
+		Fields:        r.fields,
+	}
+
+	if err := r.result(); err != nil {
+		return InfoResponse{}, err
+	}
+	return response, nil
+}
+
+// ParseStatusResponse parses the response to getstatus, e.g.
+// "Data Link: 1 GPRS: 1 Phone: 0 SIM: 0 OP: 24602 Signal: 5 NewSMS: 0 Roaming: 0 SMSFull: 0 LAC: 1 Cell ID: 864 NetType: 1 FwUpd:-"
+func ParseStatusResponse(text string) (StatusResponse, error) {
+	r, err := parseFields("getstatus", text)
+	if err != nil {
+		return StatusResponse{}, err
+	}
+	r.require("Data Link")
+
+	response := StatusResponse{
+		DataLink: r.bool("Data Link"),
+		GPRS:     r.bool("GPRS"),
+		Phone:    r.int("Phone"),
+		SIM:      r.int("SIM"),
+		Operator: r.lower["op"],
+		Signal:   r.int("Signal"),
+		NewSMS:   r.bool("NewSMS"),
+		Roaming:  r.bool("Roaming"),
+		SMSFull:  r.bool("SMSFull"),
+		LAC:      r.int("LAC"),
+		CellID:   r.int("Cell ID"),
+		NetType:  r.int("NetType"),
+		Fields:   r.fields,
+	}
+
+	if err := r.result(); err != nil {
+		return StatusResponse{}, err
+	}
+	return response, nil
+}
+
+// ParseIOResponse parses the response to getio, e.g. "DI1:0 DI2:0 DI3:0 AIN1:0 AIN2:0 DO1:0 DO2:0"
+func ParseIOResponse(text string) (IOResponse, error) {
+	r, err := parseFields("getio", text)
+	if err != nil {
+		return IOResponse{}, err
+	}
+
+	response := IOResponse{Fields: r.fields}
+	// in the order of the response, so the same response fails with the same error
+	for _, key := range r.keys {
+		upper := strings.ToUpper(key)
+		var prefix string
+		for _, p := range []string{"DI", "DO", "AIN"} {
+			if strings.HasPrefix(upper, p) && isDigits(upper[len(p):]) {
+				prefix = p
+			}
+		}
+		if prefix == "" {
+			continue
+		}
+
+		index, _ := strconv.Atoi(upper[len(prefix):])
+		if index < 1 || index > 16 {
+			return IOResponse{}, r.fail(fmt.Errorf("Invalid field %v", key))
+		}
+		switch prefix {
+		case "DI":
+			for len(response.DigitalInputs) < index {
+				response.DigitalInputs = append(response.DigitalInputs, false)
+			}
+			response.DigitalInputs[index-1] = r.bool(key)
+		case "DO":
+			for len(response.DigitalOutputs) < index {
+				response.DigitalOutputs = append(response.DigitalOutputs, false)
+			}
+			response.DigitalOutputs[index-1] = r.bool(key)
+		case "AIN":
+			for len(response.AnalogInputs) < index {
+				response.AnalogInputs = append(response.AnalogInputs, 0)
+			}
+			response.AnalogInputs[index-1] = r.float(key)
+		}
+	}
+	if response.DigitalInputs == nil && response.DigitalOutputs == nil && response.AnalogInputs == nil {
+		return IOResponse{}, r.fail(fmt.Errorf("Missing inputs and outputs"))
+	}
+
+	if err := r.result(); err != nil {
+		return IOResponse{}, err
+	}
+	return response, nil
+}
+
+// ParseParamResponse parses the response to getparam, e.g. "Param ID:2004 Value:my.server.com" or
+// "Param ID:2004 Value:my.server.com;Param ID:2005 Value:5027" for more parameters. The value is the rest of the text
+// up to the next parameter, so it may contain spaces and colons.
+func ParseParamResponse(text string) ([]Param, error) {
+	fail := func(err error) ([]Param, error) {
+		return nil, &ResponseError{Command: "getparam", Response: text, Err: err}
+	}
+
+	const marker = "Param ID:"
+	parts := strings.Split(text, marker)
+	if len(parts) < 2 || strings.TrimSpace(parts[0]) != "" {
+		return fail(fmt.Errorf("Invalid format, want %v", marker))
+	}
+
+	params := make([]Param, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		// the separator of more parameters
+		part = strings.TrimSuffix(part, ";")
+
+		id, value, found := strings.Cut(part, " ")
+		number, err := strconv.ParseUint(id, 10, 16)
+		if err != nil {
+			return fail(fmt.Errorf("Invalid parameter ID %q", id))
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case found && strings.HasPrefix(value, "Value:"):
+			value = value[len("Value:"):]
+		case found && strings.HasPrefix(value, "Val:"):
+			value = value[len("Val:"):]
+		default:
+			return fail(fmt.Errorf("Missing value of parameter %v", number))
+		}
+		params = append(params, Param{ID: uint16(number), Value: strings.TrimSpace(value)})
+	}
+	return params, nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package teltonikaparser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseResponses(t *testing.T) {
+	// parsers without Fields which are checked separately
+	gps := func(text string) (interface{}, error) {
+		r, err := ParseGPSResponse(text)
+		r.Fields = nil
+		return r, err
+	}
+	version := func(text string) (interface{}, error) {
+		r, err := ParseVersionResponse(text)
+		r.Fields = nil
+		return r, err
+	}
+	info := func(text string) (interface{}, error) {
+		r, err := ParseInfoResponse(text)
+		r.Fields = nil
+		return r, err
+	}
+	status := func(text string) (interface{}, error) {
+		r, err := ParseStatusResponse(text)
+		r.Fields = nil
+		return r, err
+	}
+	io := func(text string) (interface{}, error) {
+		r, err := ParseIOResponse(text)
+		r.Fields = nil
+		return r, err
+	}
+	param := func(text string) (interface{}, error) { return ParseParamResponse(text) }
+
+	testCases := []struct {
+		Name      string
+		Parse     func(string) (interface{}, error)
+		Response  string
+		Expected  interface{}
+		ErrorCase bool
+	}{
+		{
+			Name:     "GPS",
+			Parse:    gps,
+			Response: "GPS:1 Sat:7 Lat:54.713017 Long:25.303152 Alt:147 Speed:0 Dir:77 Date: 2019/7/23 Time: 11:27:42",
+			Expected: GPSResponse{Valid: true, Satellites: 7, Lat: 54.713017, Lng: 25.303152, Altitude: 147, Angle: 77,
+				Time: time.Date(2019, 7, 23, 11, 27, 42, 0, time.UTC)},
+		},
+		{
+			Name:     "GPSSimulator",
+			Parse:    gps,
+			Response: "GPS:1 Sat:11 Lat:49.195060 Long:16.606837 Alt:230 Speed:54 Dir:180 Date: 2024/1/1 Time: 12:0:5",
+			Expected: GPSResponse{Valid: true, Satellites: 11, Lat: 49.19506, Lng: 16.606837, Altitude: 230, Speed: 54, Angle: 180,
+				Time: time.Date(2024, 1, 1, 12, 0, 5, 0, time.UTC)},
+		},
+		{Name: "GPSNoFix", Parse: gps, Response: "GPS:0 Sat:0 Lat:0.000000 Long:0.000000 Alt:0 Speed:0 Dir:0", Expected: GPSResponse{}},
+		{Name: "GPSInvalidLat", Parse: gps, Response: "GPS:1 Sat:9 Lat:154.6 Long:25.2 Alt:120 Speed:0 Dir:0", ErrorCase: true},
+		{Name: "GPSInvalidDate", Parse: gps, Response: "GPS:1 Sat:9 Lat:54.6 Long:25.2 Date: 2024/13/1 Time: 12:00:00", ErrorCase: true},
+		{Name: "GPSMissingLong", Parse: gps, Response: "GPS:1 Sat:9 Lat:54.6 Alt:120", ErrorCase: true},
+		{Name: "GPSUnknownCommand", Parse: gps, Response: "Unknown command", ErrorCase: true},
+		{Name: "GPSOfGetver", Parse: gps, Response: "Ver:03.27.07_04 GPS:AXN_5.10 Hw:FMB920 Mod:13", ErrorCase: true},
+		{
+			Name:     "Version",
+			Parse:    version,
+			Response: "Ver:03.18.14_04 GPS:AXN_3.80_3333_16070400,0000,0000,1000, Hw:FMB920 Mod:10 IMEI:352093081452251 Init:2019-7-22 7:22 Uptime:7256 MAC:60C5A8D24E2F SPC:1(0) AXL:0 OBD:0 BL:1.7 BT:4",
+			Expected: VersionResponse{Firmware: "03.18.14_04", GPSFirmware: "AXN_3.80_3333_16070400,0000,0000,1000,", Hardware: "FMB920", Mod: "10",
+				IMEI: "352093081452251", Init: time.Date(2019, 7, 22, 7, 22, 0, 0, time.UTC), Uptime: 7256 * time.Second, MAC: "60C5A8D24E2F"},
+		},
+		{
+			Name:     "VersionSimulator",
+			Parse:    version,
+			Response: "Ver:03.27.07_00 GPS:AXN_5.10_3333 Hw:FMB920 Mod:15 IMEI:352093085698206 Init:2024/1/1 12:00 Uptime:60 BOOT:1.6",
+			Expected: VersionResponse{Firmware: "03.27.07_00", GPSFirmware: "AXN_5.10_3333", Hardware: "FMB920", Mod: "15",
+				IMEI: "352093085698206", Init: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Uptime: time.Minute},
+		},
+		{Name: "VersionInvalidIMEI", Parse: version, Response: "Ver:03.27.07_04 IMEI:35209308569820X", ErrorCase: true},
+		{Name: "VersionInvalidUptime", Parse: version, Response: "Ver:03.27.07_04 Uptime:long", ErrorCase: true},
+		{Name: "VersionMissingVer", Parse: version, Response: "Hw:FMB920 Mod:13", ErrorCase: true},
+		{
+			Name:     "Info",
+			Parse:    info,
+			Response: "INI:2019/7/22 7:22 RTC:2019/7/22 7:53 RST:2 ERR:1 SR:0 BR:0 CF:0 FG:0 FL:0 TU:0/0 UT:0 SMS:0 NOGPS:0:30 GPS:1 SAT:0 RS:3 RF:65 SF:1 MD:0",
+			Expected: InfoResponse{Init: time.Date(2019, 7, 22, 7, 22, 0, 0, time.UTC), RTC: time.Date(2019, 7, 22, 7, 53, 0, 0, time.UTC),
+				Restarts: 2, Errors: 1, NoGPS: 30 * time.Minute, GPSState: 1, ResetSource: 3},
+		},
+		{
+			Name:     "InfoCounters",
+			Parse:    info,
+			Response: "RTC:2019/7/23 11:45 Init:2019/7/22 7:22 UpTime:16454s PWR:SoftReset RST:1 GPS:3 SAT:9 NOGPS:4:34 SR:12 BR:1 SMS:2",
+			Expected: InfoResponse{Init: time.Date(2019, 7, 22, 7, 22, 0, 0, time.UTC), RTC: time.Date(2019, 7, 23, 11, 45, 0, 0, time.UTC),
+				Restarts: 1, SentRecords: 12, BrokenRecords: 1, SentSMS: 2, NoGPS: 4*time.Hour + 34*time.Minute, GPSState: 3, Satellites: 9},
+		},
+		{Name: "InfoInvalidNoGPS", Parse: info, Response: "RTC:2019/7/23 11:45 NOGPS:4", ErrorCase: true},
+		{Name: "InfoMissingRTC", Parse: info, Response: "INI:2019/7/22 7:22 RST:2", ErrorCase: true},
+		{
+			Name:     "Status",
+			Parse:    status,
+			Response: "Data Link: 1 GPRS: 1 Phone: 0 SIM: 0 OP: 24602 Signal: 5 NewSMS: 0 Roaming: 0 SMSFull: 0 LAC: 1 Cell ID: 864 NetType: 1 FwUpd:-",
+			Expected: StatusResponse{DataLink: true, GPRS: true, Operator: "24602", Signal: 5, LAC: 1, CellID: 864, NetType: 1},
+		},
+		{Name: "StatusInvalidFlag", Parse: status, Response: "Data Link: 2 GPRS: 1", ErrorCase: true},
+		{Name: "StatusOfGetgps", Parse: status, Response: "GPS:1 Sat:9 Lat:54.6 Long:25.2", ErrorCase: true},
+		{
+			Name:     "IO",
+			Parse:    io,
+			Response: "DI1:1 DI2:0 DI3:1 AIN1:12.5 AIN2:0 DO1:0 DO2:1",
+			Expected: IOResponse{DigitalInputs: []bool{true, false, true}, AnalogInputs: []float64{12.5, 0}, DigitalOutputs: []bool{false, true}},
+		},
+		{Name: "IOInvalidInput", Parse: io, Response: "DI1:x DO1:0", ErrorCase: true},
+		{Name: "IONone", Parse: io, Response: "Ver:03.27.07_04 Hw:FMB920", ErrorCase: true},
+		{Name: "Param", Parse: param, Response: "Param ID:2004 Value:gps.example.com", Expected: []Param{{ID: 2004, Value: "gps.example.com"}}},
+		{
+			Name:     "Params",
+			Parse:    param,
+			Response: "Param ID:2004 Value:gps.example.com;Param ID:2005 Value:5027;Param ID:2001 Value:my apn",
+			Expected: []Param{{ID: 2004, Value: "gps.example.com"}, {ID: 2005, Value: "5027"}, {ID: 2001, Value: "my apn"}},
+		},
+		{Name: "ParamEmptyValue", Parse: param, Response: "Param ID:2002 Value:", Expected: []Param{{ID: 2002, Value: ""}}},
+		{Name: "ParamInvalidID", Parse: param, Response: "Param ID:x Value:1", ErrorCase: true},
+		{Name: "ParamMissingValue", Parse: param, Response: "Param ID:2004", ErrorCase: true},
+		{Name: "ParamUnknown", Parse: param, Response: "Param ID:99999 is not available", ErrorCase: true},
+		{Name: "ParamOfSetparam", Parse: param, Response: "New value 2004:gps.example.com;", ErrorCase: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			response, err := testCase.Parse(testCase.Response)
+
+			if testCase.ErrorCase {
+				var responseErr *ResponseError
+				if !errors.As(err, &responseErr) {
+					test.Logf("This is an error case but the error is %v, want ResponseError", err)
+					test.Fail()
+				} else if responseErr.Response != testCase.Response {
+					test.Logf("Response of the error is %q", responseErr.Response)
+					test.Fail()
+				}
+				return
+			}
+
+			if err != nil {
+				test.Logf("Error %v", err)
+				test.Fail()
+				return
+			}
+			if !reflect.DeepEqual(response, testCase.Expected) {
+				test.Logf("Response is %+v, want %+v", response, testCase.Expected)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestParseResponseFields(t *testing.T) {
+	response, err := ParseVersionResponse("Ver:03.27.07_04 GPS:AXN_5.10 Hw:FMB920 Mod:13 IMEI:352093085698206 BL:1.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"Ver": "03.27.07_04", "GPS": "AXN_5.10", "Hw": "FMB920", "Mod": "13", "IMEI": "352093085698206", "BL": "1.7"}
+	if !reflect.DeepEqual(response.Fields, expected) {
+		t.Logf("Fields are %v, want %v", response.Fields, expected)
+		t.Fail()
+	}
+}
+
+func TestParseIOResponseError(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Response string
+		Expected string
+	}{
+		{Name: "InvalidValues", Response: "DI1:1 DI2:x AIN1:y DO1:z", Expected: `Invalid flag "x" of field DI2, want 0 or 1`},
+		{Name: "InvalidIndex", Response: "DI1:1 DO17:0 DI2:x", Expected: "Invalid field DO17"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			// the first invalid field of the response is reported every time
+			for i := 0; i < 20; i++ {
+				_, err := ParseIOResponse(testCase.Response)
+				var responseErr *ResponseError
+				if !errors.As(err, &responseErr) || responseErr.Err.Error() != testCase.Expected {
+					test.Fatalf("Error is %v, want %v", err, testCase.Expected)
+				}
+			}
+		})
+	}
+}
@@ -9,8 +9,10 @@ package teltonikaparser
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"github.com/basvdlei/gotsmart/crc16"
+	"io"
 	"unsafe"
 )
 
@@ -22,6 +24,16 @@ const (
 	CommandTypeResponse = 0x06
 )
 
+// Errors of inconsistent Codec12 packets, they are wrapped with details by the decoders
+var (
+	// ErrDataSize is returned when Data Size does not match the length of the packet or of the command or response
+	ErrDataSize = errors.New("data size mismatch")
+	// ErrQuantityMismatch is returned when Quantity 1 and Quantity 2 differ
+	ErrQuantityMismatch = errors.New("quantity mismatch")
+	// ErrCRCField is returned when the two upper bytes of the 4 bytes CRC field are not zero, CRC-16 uses only the lower two
+	ErrCRCField = errors.New("invalid CRC field")
+)
+
 type commandRequestPre struct {
 	// Preamble - the packet starts with four zero bytes.
 	Preamble uint32
@@ -29,7 +41,7 @@ type commandRequestPre struct {
 	DataSize uint32
 	// Codec ID - in Codec12 it is always 0x0C.
 	CodecID byte
-	// Command Quantity 1 - it is ignored when parsing the message.
+	// Command Quantity 1 - it has to be the same as Command Quantity 2.
 	CommandQuantity1 byte
 	// Type - it can be 0x05 to denote command or 0x06 to denote response.
 	Type byte
@@ -39,8 +51,7 @@ type commandRequestPre struct {
 
 type commandRequestPost struct {
 	// Command Quantity 2 - a byte which defines how many records (commands)
-	// are in the packet. This byte will not be parsed but it’s recommended
-	// that it should contain the same value as Command Quantity 1.
+	// are in the packet. It has to contain the same value as Command Quantity 1.
 	CommandQuantity2 byte
 	// Calculated from Codec ID to the Command Quantity 2. CRC (Cyclic
 	// Redundancy Check) is an error-detecting code using for detect
@@ -62,7 +73,7 @@ type commandResponsePre struct {
 	DataSize uint32
 	// Codec ID - in Codec12 it is always 0x0C.
 	CodecID byte
-	// Response Quantity 1 - it is ignored when parsing the message.
+	// Response Quantity 1 - it has to be the same as Response Quantity 2.
 	ResponseQuantity1 byte
 	// Type - it can be 0x05 to denote command or 0x06 to denote response.
 	Type byte
@@ -72,7 +83,7 @@ type commandResponsePre struct {
 
 type commandResponsePost struct {
 	// Response Quantity 2 - a byte which defines how many records (responses) are in the packet.
-	// This byte will not be parsed but it’s recommended that it should contain the same value as Response Quantity 1.
+	// It has to contain the same value as Response Quantity 1.
 	ResponseQuantity2 byte
 	// calculated from Codec ID to the Command Quantity 2. CRC (Cyclic Redundancy Check) is an error-detecting
 	// code using for detect accidental changes to RAW data. For calculation we are using CRC-16/IBM.
@@ -86,7 +97,19 @@ type CommandResponse struct {
 	commandResponsePost
 }
 
+// EncodeCommandRequest produces a Codec12 command packet for TCP, it is sent by the server to the device
 func EncodeCommandRequest(command string) ([]byte, error) {
+	return encodeCommand(CommandTypeRequest, command)
+}
+
+// EncodeCommandResponse produces a Codec12 response packet for TCP, it is sent by the device to the server.
+// It is meant for device emulators and tests, DecodeCommandResponse decodes it.
+func EncodeCommandResponse(response string) ([]byte, error) {
+	return encodeCommand(CommandTypeResponse, response)
+}
+
+// encodeCommand produces a Codec12 TCP packet of the type, requests and responses have the same layout
+func encodeCommand(commandType byte, command string) ([]byte, error) {
 	buffer := new(bytes.Buffer)
 
 	commandRequest := CommandRequest{
@@ -95,7 +118,7 @@ func EncodeCommandRequest(command string) ([]byte, error) {
 			DataSize:         uint32(7 + len(command) + 1), // 7 header bytes + actual command text + 1 byte more
 			CodecID:          CodecID,
 			CommandQuantity1: 0x01,
-			Type:             CommandTypeRequest, // 0x05 for command request, 0x06 for command response,
+			Type:             commandType, // 0x05 for command request, 0x06 for command response,
 			CommandSize:      uint32(len(command)),
 		},
 		Command: []byte(command),
@@ -138,17 +161,41 @@ func EncodeCommandRequest(command string) ([]byte, error) {
 	return buffer.Bytes(), nil
 }
 
+// DecodeCommandRequest decodes a Codec12 command packet received over TCP, it checks the preamble, codec ID, type and CRC
+// the same way as DecodeCommandResponse
 func DecodeCommandRequest(rawCommand *[]byte) (CommandRequest, error) {
 	var decoded CommandRequest
 
 	reader := bytes.NewReader(*rawCommand)
 
+	const minSize = int(unsafe.Sizeof(decoded.commandRequestPre))
+	if len(*rawCommand) < minSize {
+		return decoded, fmt.Errorf("only %d bytes received. Probably not a teltonika command request packet", len(*rawCommand))
+	}
+
 	// Read first part of the record until the dynamic sized section.
 	err := binary.Read(reader, binary.BigEndian, &decoded.commandRequestPre)
 	if err != nil {
 		return decoded, fmt.Errorf("%v", err)
 	}
 
+	if decoded.Preamble != RequestPreamble {
+		return decoded, fmt.Errorf("wrong preamble: 0x%x", decoded.Preamble)
+	}
+
+	if decoded.CodecID != CodecID {
+		return decoded, fmt.Errorf("wrong CodecID: 0x%x", decoded.CodecID)
+	}
+
+	if decoded.Type != CommandTypeRequest {
+		return decoded, fmt.Errorf("wrong type: 0x%x", decoded.Type)
+	}
+
+	err = checkDataSize(len(*rawCommand), decoded.DataSize, decoded.CommandSize)
+	if err != nil {
+		return decoded, err
+	}
+
 	// Allocate memory for the dynamic sized section. Actual size is defined in the first block.
 	decoded.Command = make([]byte, decoded.CommandSize)
 
@@ -166,6 +213,11 @@ func DecodeCommandRequest(rawCommand *[]byte) (CommandRequest, error) {
 		return decoded, fmt.Errorf("%v", err)
 	}
 
+	err = checkTrailer(decoded.CommandQuantity1, decoded.CommandQuantity2, decoded.CRC)
+	if err != nil {
+		return decoded, err
+	}
+
 	// Calculate CRC by my own and check if it is equal to the got CRC
 	d := (*rawCommand)[8 : len(*rawCommand)-4] // drop first 8 bytes and last 4 bytes and calculate CRC
 	crc := crc16.Checksum(d)
@@ -178,6 +230,7 @@ func DecodeCommandRequest(rawCommand *[]byte) (CommandRequest, error) {
 	return decoded, nil
 }
 
+// DecodeCommandResponse decodes a Codec12 response packet received over TCP
 func DecodeCommandResponse(rawResponse *[]byte) (CommandResponse, error) {
 	var decoded CommandResponse
 
@@ -206,6 +259,11 @@ func DecodeCommandResponse(rawResponse *[]byte) (CommandResponse, error) {
 		return decoded, fmt.Errorf("wrong type: 0x%x", decoded.Type)
 	}
 
+	err = checkDataSize(len(*rawResponse), decoded.DataSize, decoded.ResponseSize)
+	if err != nil {
+		return decoded, err
+	}
+
 	// Allocate memory for the dynamic sized section. Actual size is defined in the first block.
 	decoded.Response = make([]byte, decoded.ResponseSize)
 
@@ -219,6 +277,11 @@ func DecodeCommandResponse(rawResponse *[]byte) (CommandResponse, error) {
 		return decoded, fmt.Errorf("%v", err)
 	}
 
+	err = checkTrailer(decoded.ResponseQuantity1, decoded.ResponseQuantity2, decoded.CRC)
+	if err != nil {
+		return decoded, err
+	}
+
 	// Calculate CRC by my own and check if it is equal to the got CRC
 	d := (*rawResponse)[8 : len(*rawResponse)-4] // drop first 8 bytes and last 4 bytes and calculate CRC
 	calculatedCrc := crc16.Checksum(d)
@@ -230,3 +293,154 @@ func DecodeCommandResponse(rawResponse *[]byte) (CommandResponse, error) {
 
 	return decoded, nil
 }
+
+// checkDataSize checks Data Size of a TCP packet of length bytes against the packet and the command or response size,
+// Data Size counts the bytes from the Codec ID to the Quantity 2, 7 bytes of header, the text and 1 byte of quantity
+func checkDataSize(length int, dataSize uint32, size uint32) error {
+	// preamble, data size and CRC fields
+	if int64(dataSize) != int64(length)-12 {
+		return fmt.Errorf("%w: data size %d, packet has %d bytes of data", ErrDataSize, dataSize, length-12)
+	}
+	if uint64(size)+8 != uint64(dataSize) {
+		return fmt.Errorf("%w: data size %d does not fit the size %d", ErrDataSize, dataSize, size)
+	}
+	return nil
+}
+
+// checkTrailer checks that both quantities agree and that the CRC field holds only 16 bits
+func checkTrailer(quantity1 byte, quantity2 byte, crc uint32) error {
+	if quantity1 != quantity2 {
+		return fmt.Errorf("%w: quantity 1 is %d, quantity 2 is %d", ErrQuantityMismatch, quantity1, quantity2)
+	}
+	if crc>>16 != 0 {
+		return fmt.Errorf("%w: 0x%08x", ErrCRCField, crc)
+	}
+	return nil
+}
+
+type commandUDPHeader struct {
+	// Length - packet length, excluding this field.
+	Length uint16
+	// Packet ID - it is chosen by the server and it is not checked by the device.
+	PacketID uint16
+	// Not usable byte - always 0x01.
+	NotUsable byte
+	// AVL packet ID - it is chosen by the server.
+	AVLPacketID byte
+	// IMEI length - always 0x000F.
+	IMEILength uint16
+}
+
+type commandUDPBody struct {
+	// Codec ID - in Codec12 it is always 0x0C.
+	CodecID byte
+	// Quantity 1 - number of commands or responses.
+	Quantity1 byte
+	// Type - it can be 0x05 to denote command or 0x06 to denote response.
+	Type byte
+	// Size - command or response length.
+	Size uint32
+}
+
+// EncodeCommandRequestUDP produces a Codec12 command packet for UDP, it is framed by the UDP channel header
+// and the AVL packet header with the IMEI of the device instead of the preamble and CRC used over TCP.
+func EncodeCommandRequestUDP(imei string, packetID uint16, avlPacketID byte, command string) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+
+	header := commandUDPHeader{
+		Length:      uint16(2 + 1 + 1 + 2 + len(imei) + 7 + len(command) + 1), // from packet ID to the command quantity 2
+		PacketID:    packetID,
+		NotUsable:   0x01,
+		AVLPacketID: avlPacketID,
+		IMEILength:  uint16(len(imei)),
+	}
+	body := commandUDPBody{
+		CodecID:   CodecID,
+		Quantity1: 0x01,
+		Type:      CommandTypeRequest,
+		Size:      uint32(len(command)),
+	}
+
+	err := binary.Write(buffer, binary.BigEndian, header)
+	if err != nil {
+		return buffer.Bytes(), fmt.Errorf("%v", err)
+	}
+	buffer.WriteString(imei)
+
+	err = binary.Write(buffer, binary.BigEndian, body)
+	if err != nil {
+		return buffer.Bytes(), fmt.Errorf("%v", err)
+	}
+	buffer.WriteString(command)
+	buffer.WriteByte(0x01)
+
+	return buffer.Bytes(), nil
+}
+
+// DecodeCommandResponseUDP decodes a Codec12 response packet received over UDP and returns IMEI of the device with the response.
+// UDP packets have no preamble, data size and CRC, so these fields of CommandResponse are left zero.
+func DecodeCommandResponseUDP(rawResponse *[]byte) (string, CommandResponse, error) {
+	var decoded CommandResponse
+	var header commandUDPHeader
+	var body commandUDPBody
+
+	reader := bytes.NewReader(*rawResponse)
+
+	err := binary.Read(reader, binary.BigEndian, &header)
+	if err != nil {
+		return "", decoded, fmt.Errorf("%v", err)
+	}
+
+	if int(header.Length) != len(*rawResponse)-2 {
+		return "", decoded, fmt.Errorf("wrong length: %d, packet has %d bytes", header.Length, len(*rawResponse))
+	}
+
+	if header.IMEILength != 15 && header.IMEILength != 16 {
+		return "", decoded, fmt.Errorf("wrong IMEI length: %d", header.IMEILength)
+	}
+
+	imei := make([]byte, header.IMEILength)
+	_, err = io.ReadFull(reader, imei)
+	if err != nil {
+		return "", decoded, fmt.Errorf("%v", err)
+	}
+
+	err = binary.Read(reader, binary.BigEndian, &body)
+	if err != nil {
+		return "", decoded, fmt.Errorf("%v", err)
+	}
+
+	if body.CodecID != CodecID {
+		return "", decoded, fmt.Errorf("wrong CodecID: 0x%x", body.CodecID)
+	}
+
+	if body.Type != CommandTypeResponse {
+		return "", decoded, fmt.Errorf("wrong type: 0x%x", body.Type)
+	}
+
+	if int64(body.Size) != int64(reader.Len()-1) {
+		return "", decoded, fmt.Errorf("%d bytes of response were expected but got %d", body.Size, reader.Len()-1)
+	}
+
+	decoded.CodecID = body.CodecID
+	decoded.ResponseQuantity1 = body.Quantity1
+	decoded.Type = body.Type
+	decoded.ResponseSize = body.Size
+
+	decoded.Response = make([]byte, body.Size)
+	_, err = io.ReadFull(reader, decoded.Response)
+	if err != nil {
+		return "", decoded, fmt.Errorf("%v", err)
+	}
+
+	decoded.ResponseQuantity2, err = reader.ReadByte()
+	if err != nil {
+		return "", decoded, fmt.Errorf("%v", err)
+	}
+
+	if decoded.ResponseQuantity1 != decoded.ResponseQuantity2 {
+		return "", decoded, fmt.Errorf("%w: quantity 1 is %d, quantity 2 is %d", ErrQuantityMismatch, decoded.ResponseQuantity1, decoded.ResponseQuantity2)
+	}
+
+	return string(imei), decoded, nil
+}
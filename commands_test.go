@@ -8,6 +8,7 @@ package teltonikaparser
 
 import (
 	"encoding/hex"
+	"errors"
 	"reflect"
 	"strings"
 	"testing"
@@ -101,6 +102,105 @@ func TestCommandRequestDecoding(t *testing.T) {
 	}
 }
 
+func TestCommandRequestDecodeErrors(t *testing.T) {
+	testCases := []struct {
+		Name                 string
+		CommandRequest       string
+		ExpectedErrorMessage string
+	}{
+		{
+			Name:                 "WrongPreamble",
+			CommandRequest:       "000000010000000F0C010500000007676574696E666F0100004312",
+			ExpectedErrorMessage: "wrong preamble: 0x1",
+		},
+		{
+			Name:                 "WrongCodecID",
+			CommandRequest:       "000000000000000F08010500000007676574696E666F0100004312",
+			ExpectedErrorMessage: "wrong CodecID: 0x8",
+		},
+		{
+			Name:                 "WrongType",
+			CommandRequest:       "000000000000000F0C010600000007676574696E666F0100004312",
+			ExpectedErrorMessage: "wrong type: 0x6",
+		},
+		{
+			Name:                 "WrongCrc",
+			CommandRequest:       "000000000000000F0C010500000007676574696E666F0100004313",
+			ExpectedErrorMessage: "CRC check failed! ACTUAL: 4312 EXPECTED: 4313",
+		},
+		{
+			Name:                 "TooShort",
+			CommandRequest:       "000000000000000F0C0105",
+			ExpectedErrorMessage: "only 11 bytes received. Probably not a teltonika command request packet",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			rawCommandRequest, _ := hex.DecodeString(testCase.CommandRequest)
+
+			_, err := DecodeCommandRequest(&rawCommandRequest)
+			if err == nil {
+				test.Logf("This is an error case but there is no error.")
+				test.Fail()
+				return
+			}
+			if err.Error() != testCase.ExpectedErrorMessage {
+				test.Logf("Expected error message: %v, Actual error message: %v", testCase.ExpectedErrorMessage, err.Error())
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestCommandResponseGeneration(t *testing.T) {
+	testCases := []struct {
+		Name                   string
+		Response               string
+		ExpectedClientResponse string
+	}{
+		{
+			Name:                   "CommandCodec12GetIoResponse",
+			Response:               "DI1:1 DI2:0 DI3:0 AIN1:0 AIN2:16924 DO1:0 DO2:1",
+			ExpectedClientResponse: "00000000000000370C01060000002F4449313A31204449323A30204449333A302041494E313A302041494E323A313639323420444F313A3020444F323A3101000066E3",
+		},
+		{
+			Name:                   "CommandCodec12GetInfoResponse",
+			Response:               "INI:2019/7/22 7:22 RTC:2019/7/22 7:53 RST:2 ERR:1 SR:0 BR:0 CF:0 FG:0 FL:0 TU:0/0 UT:0 SMS:0 NOGPS:0:30 GPS:1 SAT:0 RS:3 RF:65 SF:1 MD:0",
+			ExpectedClientResponse: "00000000000000900C010600000088494E493A323031392F372F323220373A3232205254433A323031392F372F323220373A3533205253543A32204552523A312053523A302042523A302043463A302046473A3020464C3A302054553A302F302055543A3020534D533A30204E4F4750533A303A3330204750533A31205341543A302052533A332052463A36352053463A31204D443A30010000C78F",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			raw, err := EncodeCommandResponse(testCase.Response)
+			if err != nil {
+				test.Logf("Failed to encode command response. %v", err)
+				test.Fail()
+				return
+			}
+
+			actualHexStr := strings.ToLower(hex.EncodeToString(raw))
+			expectedHexStr := strings.ToLower(testCase.ExpectedClientResponse)
+			if actualHexStr != expectedHexStr {
+				test.Logf("Expected value: %v, Actual value: %v", expectedHexStr, actualHexStr)
+				test.Fail()
+			}
+
+			// the other side of the exchange
+			decoded, err := DecodeCommandResponse(&raw)
+			if err != nil || string(decoded.Response) != testCase.Response {
+				test.Logf("Failed to decode the encoded response %q. %v", decoded.Response, err)
+				test.Fail()
+			}
+			if _, err := DecodeCommandRequest(&raw); err == nil {
+				test.Logf("The response is decoded as a request.")
+				test.Fail()
+			}
+		})
+	}
+}
+
 func TestCommandResponseDecode(t *testing.T) {
 	testCases := []struct {
 		Name                    string
@@ -223,3 +323,137 @@ func TestCommandResponseDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandRequestUDPGeneration(t *testing.T) {
+	raw, err := EncodeCommandRequestUDP("352093085698206", 0x0102, 0x05, "getver")
+	if err != nil {
+		t.Fatalf("Failed to encode command request. %v", err)
+	}
+
+	expected := "0023" + "0102" + "01" + "05" + "000f" + hex.EncodeToString([]byte("352093085698206")) + "0c010500000006" + hex.EncodeToString([]byte("getver")) + "01"
+	if actual := hex.EncodeToString(raw); actual != expected {
+		t.Errorf("Expected value: %v, Actual value: %v", expected, actual)
+	}
+}
+
+func TestCommandResponseUDPDecode(t *testing.T) {
+	imei := hex.EncodeToString([]byte("352093085698206"))
+	response := hex.EncodeToString([]byte("Ver:03.27.07"))
+
+	testCases := []struct {
+		Name             string
+		ClientResponse   string
+		ErrorCase        bool
+		ExpectedIMEI     string
+		ExpectedResponse string
+	}{
+		{
+			Name:             "Valid",
+			ClientResponse:   "0029" + "0102" + "01" + "05" + "000f" + imei + "0c01060000000c" + response + "01",
+			ExpectedIMEI:     "352093085698206",
+			ExpectedResponse: "Ver:03.27.07",
+		},
+		{
+			Name:           "WrongLength",
+			ClientResponse: "002a" + "0102" + "01" + "05" + "000f" + imei + "0c01060000000c" + response + "01",
+			ErrorCase:      true,
+		},
+		{
+			Name:           "WrongType",
+			ClientResponse: "0029" + "0102" + "01" + "05" + "000f" + imei + "0c01050000000c" + response + "01",
+			ErrorCase:      true,
+		},
+		{
+			Name:           "WrongResponseSize",
+			ClientResponse: "0029" + "0102" + "01" + "05" + "000f" + imei + "0c01060000000d" + response + "01",
+			ErrorCase:      true,
+		},
+		{
+			Name:           "Truncated",
+			ClientResponse: "0029" + "0102" + "01" + "05" + "000f",
+			ErrorCase:      true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			raw, _ := hex.DecodeString(testCase.ClientResponse)
+			imei, decoded, err := DecodeCommandResponseUDP(&raw)
+
+			if testCase.ErrorCase {
+				if err == nil {
+					test.Logf("Expected error, got response %q", decoded.Response)
+					test.Fail()
+				}
+				return
+			}
+
+			if err != nil {
+				test.Logf("Failed to decode command response. %v", err)
+				test.Fail()
+				return
+			}
+
+			if imei != testCase.ExpectedIMEI || string(decoded.Response) != testCase.ExpectedResponse {
+				test.Logf("Expected value: %v %v, Actual value: %v %v", testCase.ExpectedIMEI, testCase.ExpectedResponse, imei, string(decoded.Response))
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestCommandConsistency(t *testing.T) {
+	request, _ := hex.DecodeString("000000000000000F0C010500000007676574696E666F0100004312")
+	response, _ := hex.DecodeString("00000000000000370C01060000002F4449313A31204449323A30204449333A302041494E313A302041494E323A313639323420444F313A3020444F323A3101000066E3")
+	udpResponse, _ := hex.DecodeString("0029" + "0102" + "01" + "05" + "000f" + hex.EncodeToString([]byte("352093085698206")) + "0c01060000000c" + hex.EncodeToString([]byte("Ver:03.27.07")) + "01")
+
+	decodeRequest := func(raw []byte) error { _, err := DecodeCommandRequest(&raw); return err }
+	decodeResponse := func(raw []byte) error { _, err := DecodeCommandResponse(&raw); return err }
+	decodeUDPResponse := func(raw []byte) error { _, _, err := DecodeCommandResponseUDP(&raw); return err }
+
+	// set returns a copy of the packet with the byte at index changed, a negative index counts from the end
+	set := func(packet []byte, index int, value byte) []byte {
+		out := append([]byte{}, packet...)
+		if index < 0 {
+			index += len(out)
+		}
+		out[index] = value
+		return out
+	}
+
+	testCases := []struct {
+		Name     string
+		Decode   func([]byte) error
+		Packet   []byte
+		Expected error
+	}{
+		{Name: "RequestPadded", Decode: decodeRequest, Packet: append(append([]byte{}, request...), 0x00), Expected: ErrDataSize},
+		{Name: "RequestTruncated", Decode: decodeRequest, Packet: request[:len(request)-1], Expected: ErrDataSize},
+		{Name: "RequestDataSize", Decode: decodeRequest, Packet: set(request, 7, 0x10), Expected: ErrDataSize},
+		{Name: "RequestCommandSize", Decode: decodeRequest, Packet: set(request, 14, 0x08), Expected: ErrDataSize},
+		{Name: "RequestQuantity", Decode: decodeRequest, Packet: set(request, -5, 0x02), Expected: ErrQuantityMismatch},
+		{Name: "RequestCRCField", Decode: decodeRequest, Packet: set(request, -3, 0x01), Expected: ErrCRCField},
+		{Name: "ResponsePadded", Decode: decodeResponse, Packet: append(append([]byte{}, response...), 0x00, 0x00), Expected: ErrDataSize},
+		{Name: "ResponseTruncated", Decode: decodeResponse, Packet: response[:len(response)-3], Expected: ErrDataSize},
+		{Name: "ResponseDataSize", Decode: decodeResponse, Packet: set(response, 7, 0x36), Expected: ErrDataSize},
+		{Name: "ResponseSize", Decode: decodeResponse, Packet: set(response, 14, 0x30), Expected: ErrDataSize},
+		{Name: "ResponseQuantity", Decode: decodeResponse, Packet: set(response, 9, 0x02), Expected: ErrQuantityMismatch},
+		{Name: "ResponseCRCField", Decode: decodeResponse, Packet: set(response, -4, 0x80), Expected: ErrCRCField},
+		{Name: "UDPResponseQuantity", Decode: decodeUDPResponse, Packet: set(udpResponse, -1, 0x02), Expected: ErrQuantityMismatch},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			err := testCase.Decode(testCase.Packet)
+			if !errors.Is(err, testCase.Expected) {
+				test.Logf("Expected error: %v, Actual error: %v", testCase.Expected, err)
+				test.Fail()
+			}
+		})
+	}
+
+	// the unchanged packets are consistent
+	if decodeRequest(request) != nil || decodeResponse(response) != nil || decodeUDPResponse(udpResponse) != nil {
+		t.Error("Failed to decode the unchanged packets")
+	}
+}
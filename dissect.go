@@ -0,0 +1,513 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package teltonikaparser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/basvdlei/gotsmart/crc16"
+	"github.com/filipkroca/b2n"
+)
+
+// Field is one field of a dissected packet with its Byte range
+type Field struct {
+	Name   string // Name of the field, e.g. "Codec ID" or "IO 239"
+	Offset int    // Offset of the first Byte from the beginning of the packet
+	Length int    // Length of the field in Bytes, groups like a record span all their fields
+	Value  string // Value in human readable form, empty for groups
+	Depth  int    // Depth in the tree of fields, fields of a record are 1, IO elements 2
+}
+
+// Dissection is a packet split into fields in the order of the Bytes, if the packet is malformed Fields end
+// at the failing field and Err holds the failure with its offset
+type Dissection struct {
+	Packet []byte       // Packet is the dissected packet
+	Fields []Field      // Fields of the packet
+	Err    *DecodeError // Err is the first failure, nil if the whole packet was dissected
+}
+
+// DissectUDP takes a pointer to a slice of bytes with one UDP packet and return its fields. Unlike Decode it also
+// dissects Codec 16 records and Codec 12 messages, malformed packets are dissected up to the failing field.
+func DissectUDP(bs *[]byte) Dissection {
+	d := &dissector{bs: *bs}
+
+	if !d.udpHeader() {
+		return d.dissection()
+	}
+	if d.offset < len(d.bs) && d.bs[d.offset] == CodecID {
+		d.command()
+	} else {
+		d.avlData()
+	}
+	d.end(len(d.bs))
+	return d.dissection()
+}
+
+// DissectTCP takes a pointer to a slice of bytes with one TCP packet (preamble, data field length, data, CRC) and return its fields,
+// the data can be AVL data of Codec 8, Codec 8 Extended and Codec 16 or a Codec 12 command or response
+func DissectTCP(bs *[]byte) Dissection {
+	d := &dissector{bs: *bs}
+
+	preamble, ok := d.field("Preamble", 4, func(v []byte) string { return fmt.Sprintf("%#08x", binary.BigEndian.Uint32(v)) })
+	if !ok {
+		return d.dissection()
+	}
+	if binary.BigEndian.Uint32(preamble) != 0 {
+		d.fail(0, fmt.Errorf("Invalid preamble, want 0x00000000, got %#08x", binary.BigEndian.Uint32(preamble)))
+		return d.dissection()
+	}
+
+	dataLen, ok := d.field("Data field length", 4, decimal)
+	if !ok {
+		return d.dissection()
+	}
+	if int(binary.BigEndian.Uint32(dataLen)) != len(d.bs)-12 {
+		d.fail(4, fmt.Errorf("Invalid data field length, want %v, got %v", len(d.bs)-12, binary.BigEndian.Uint32(dataLen)))
+		return d.dissection()
+	}
+
+	if d.bs[d.offset] == CodecID {
+		d.command()
+	} else {
+		d.avlData()
+	}
+	if d.err != nil {
+		return d.dissection()
+	}
+
+	crcStart := d.offset
+	crc := crc16.Checksum(d.bs[8:crcStart])
+	received, ok := d.field("CRC-16/IBM", 4, func(v []byte) string {
+		if binary.BigEndian.Uint32(v) == uint32(crc) {
+			return fmt.Sprintf("%#04x (valid)", binary.BigEndian.Uint32(v))
+		}
+		return fmt.Sprintf("%#04x (calculated %#04x)", binary.BigEndian.Uint32(v), crc)
+	})
+	if ok && binary.BigEndian.Uint32(received) != uint32(crc) {
+		d.fail(crcStart, fmt.Errorf("CRC check failed, calculated %#04x, received %#04x", crc, binary.BigEndian.Uint32(received)))
+		return d.dissection()
+	}
+	d.end(len(d.bs))
+	return d.dissection()
+}
+
+// dissector walks a packet and collects its fields until the first failure
+type dissector struct {
+	bs     []byte
+	offset int
+	depth  int
+	fields []Field
+	err    *DecodeError
+}
+
+func (d *dissector) dissection() Dissection {
+	return Dissection{Packet: d.bs, Fields: d.fields, Err: d.err}
+}
+
+// fail records the failure at offset
+func (d *dissector) fail(offset int, err error) {
+	if d.err == nil {
+		d.err = &DecodeError{Offset: offset, Err: err}
+	}
+}
+
+// field adds the field of n Bytes at the current offset and returns its Bytes, it fails if the packet is too short
+func (d *dissector) field(name string, n int, format func([]byte) string) ([]byte, bool) {
+	if d.err != nil {
+		return nil, false
+	}
+	if d.offset+n > len(d.bs) {
+		d.fail(d.offset, fmt.Errorf("Missing %v, want %v Bytes at Byte %v, got %v", name, n, d.offset, len(d.bs)-d.offset))
+		return nil, false
+	}
+
+	value := d.bs[d.offset : d.offset+n]
+	d.fields = append(d.fields, Field{Name: name, Offset: d.offset, Length: n, Value: format(value), Depth: d.depth})
+	d.offset += n
+	return value, true
+}
+
+// group opens a group of fields like a record, close it by the returned function
+func (d *dissector) group(name string) func() {
+	if d.err != nil {
+		return func() {}
+	}
+	index := len(d.fields)
+	d.fields = append(d.fields, Field{Name: name, Offset: d.offset, Depth: d.depth})
+	d.depth++
+	return func() {
+		d.depth--
+		d.fields[index].Length = d.offset - d.fields[index].Offset
+	}
+}
+
+// uint returns the big endian number of n Bytes at offset, the Bytes must have been dissected already
+func (d *dissector) uint(offset int, n int) (uint64, bool) {
+	if offset+n > len(d.bs) {
+		return 0, false
+	}
+	var x uint64
+	for _, b := range d.bs[offset : offset+n] {
+		x = x<<8 | uint64(b)
+	}
+	return x, true
+}
+
+// number adds a counter or ID field of n Bytes and returns its value
+func (d *dissector) number(name string, n int) (int, bool) {
+	if _, ok := d.field(name, n, decimal); !ok {
+		return 0, false
+	}
+	x, _ := d.uint(d.offset-n, n)
+	return int(x), true
+}
+
+// udpHeader dissects the UDP channel header and IMEI
+func (d *dissector) udpHeader() bool {
+	if _, ok := d.field("Length", 2, decimal); !ok {
+		return false
+	}
+	if _, ok := d.field("Packet ID", 2, hexadecimal); !ok {
+		return false
+	}
+	if length, _ := d.uint(0, 2); int(length) != len(d.bs)-2 {
+		d.fail(0, fmt.Errorf("Invalid packet length, declared %v Bytes, got %v Bytes after the length field", length, len(d.bs)-2))
+		return false
+	}
+	d.field("Not usable", 1, hexadecimal)
+	d.field("AVL packet ID", 1, decimal)
+
+	imeiLen, ok := d.number("IMEI length", 2)
+	if !ok {
+		return false
+	}
+	if imeiLen != 15 && imeiLen != 16 {
+		d.fail(6, fmt.Errorf("Error when determining IMEI len want 15 or 16, got %v", imeiLen))
+		return false
+	}
+
+	start := d.offset
+	if _, ok := d.field("IMEI", imeiLen, func(v []byte) string { return string(v) }); !ok {
+		return false
+	}
+	if _, err := b2n.ParseIMEI(&d.bs, start, imeiLen); err != nil {
+		d.fail(start, fmt.Errorf("Decode error, %v", err))
+		return false
+	}
+	return true
+}
+
+// avlData dissects Codec ID, records and the control number of data
+func (d *dissector) avlData() {
+	start := d.offset
+	codec, ok := d.field("Codec ID", 1, func(v []byte) string { return codecName(v[0]) })
+	if !ok {
+		return
+	}
+	codecID := codec[0]
+	if codecID != 0x08 && codecID != 0x8e && codecID != 0x10 {
+		d.fail(start, fmt.Errorf("Invalid Codec ID, want 0x08, 0x8E or 0x10, get %v", codecID))
+		return
+	}
+
+	noOfData, ok := d.number("Number of data 1", 1)
+	if !ok {
+		return
+	}
+
+	for i := 0; i < noOfData && d.err == nil; i++ {
+		closeRecord := d.group(fmt.Sprintf("Record %v", i+1))
+		d.record(codecID)
+		closeRecord()
+	}
+	if d.err != nil {
+		return
+	}
+
+	end := d.offset
+	noOfData2, ok := d.number("Number of data 2", 1)
+	if ok && noOfData2 != noOfData {
+		d.fail(end, fmt.Errorf("Unexpected byte representing control num. of data on end of parsing, want %#x, got %#x", noOfData, noOfData2))
+	}
+}
+
+// record dissects one AVL record
+func (d *dissector) record(codecID byte) {
+	d.field("Timestamp", 8, func(v []byte) string {
+		ms := int64(binary.BigEndian.Uint64(v))
+		return time.Unix(0, ms*int64(time.Millisecond)).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	})
+
+	start := d.offset
+	if priority, ok := d.field("Priority", 1, func(v []byte) string { return priorityName(v[0]) }); ok && priority[0] > 2 {
+		d.fail(start, fmt.Errorf("Invalid Priority value, want priority <= 2, got %v", priority[0]))
+	}
+
+	closeGPS := d.group("GPS element")
+	start = d.offset
+	if lng, ok := d.field("Longitude", 4, degrees); ok {
+		if x := int32(binary.BigEndian.Uint32(lng)); !(x > -1800000000 && x < 1800000000) {
+			d.fail(start, fmt.Errorf("Invalid Lat value, want lat > -1800000000 AND lat < 1800000000, got %v", x))
+		}
+	}
+	start = d.offset
+	if lat, ok := d.field("Latitude", 4, degrees); ok {
+		if x := int32(binary.BigEndian.Uint32(lat)); !(x > -850000000 && x < 850000000) {
+			d.fail(start, fmt.Errorf("Invalid Lat value, want lat > -850000000 AND lat < 850000000, got %v", x))
+		}
+	}
+	start = d.offset
+	if altitude, ok := d.field("Altitude", 2, func(v []byte) string { return fmt.Sprintf("%v m", int16(binary.BigEndian.Uint16(v))) }); ok {
+		if x := int16(binary.BigEndian.Uint16(altitude)); !(x > -5000 && x < 12000) {
+			d.fail(start, fmt.Errorf("Invalid Altitude value, want Altitude > -5000 AND Altitude < 12000, got %v", x))
+		}
+	}
+	start = d.offset
+	if angle, ok := d.field("Angle", 2, func(v []byte) string { return fmt.Sprintf("%v°", binary.BigEndian.Uint16(v)) }); ok {
+		if x := binary.BigEndian.Uint16(angle); x > 360 {
+			d.fail(start, fmt.Errorf("Invalid Angle value, want Angle <= 360, got %v", x))
+		}
+	}
+	d.field("Satellites", 1, decimal)
+	d.field("Speed", 2, func(v []byte) string { return fmt.Sprintf("%v km/h", binary.BigEndian.Uint16(v)) })
+	closeGPS()
+
+	// sizes of the event ID, counts and IO IDs
+	eventLen, countLen, idLen := 1, 1, 1
+	switch codecID {
+	case 0x8e:
+		eventLen, countLen, idLen = 2, 2, 2
+	case 0x10:
+		eventLen, idLen = 2, 2
+	}
+
+	d.number("Event IO ID", eventLen)
+	if codecID == 0x10 {
+		d.field("Generation type", 1, decimal)
+	}
+
+	closeIO := d.group("IO elements")
+	defer closeIO()
+
+	total, ok := d.number("Total IO count", countLen)
+	if !ok {
+		return
+	}
+
+	count := 0
+	for _, size := range []int{1, 2, 4, 8} {
+		n, ok := d.number(fmt.Sprintf("%v Byte IO count", size), countLen)
+		for i := 0; ok && i < n; i++ {
+			ok = d.element(idLen, size)
+		}
+		if !ok {
+			return
+		}
+		count += n
+	}
+
+	if codecID == 0x8e {
+		n, ok := d.number("Variable length IO count", 2)
+		for i := 0; ok && i < n; i++ {
+			ok = d.element(2, -1)
+		}
+		if !ok {
+			return
+		}
+		count += n
+	}
+
+	if count != total {
+		d.fail(d.offset, fmt.Errorf("Error when counting parsed IO Elements, want %v, got %v", total, count))
+	}
+}
+
+// element dissects one IO element with its ID and value, size -1 means a variable length element with 2 Bytes length
+func (d *dissector) element(idLen int, size int) bool {
+	if d.err != nil {
+		return false
+	}
+
+	start := d.offset
+	headerLen := idLen
+	if size < 0 {
+		headerLen += 2
+		if length, ok := d.uint(start+2, 2); ok {
+			size = int(length)
+		} else {
+			size = 0
+		}
+	}
+	if start+headerLen+size > len(d.bs) {
+		d.fail(start, fmt.Errorf("Missing IO element, want %v Bytes at Byte %v, got %v", headerLen+size, start, len(d.bs)-start))
+		return false
+	}
+
+	id, _ := d.uint(start, idLen)
+	value := d.bs[start+headerLen : start+headerLen+size]
+	d.fields = append(d.fields, Field{Name: fmt.Sprintf("IO %v", id), Offset: start, Length: headerLen + size, Value: ioValue(value), Depth: d.depth})
+	d.offset += headerLen + size
+	return true
+}
+
+// command dissects a Codec 12 message starting at the Codec ID
+func (d *dissector) command() {
+	d.field("Codec ID", 1, func(v []byte) string { return codecName(v[0]) })
+	quantity, ok := d.number("Quantity 1", 1)
+	if !ok {
+		return
+	}
+
+	d.field("Type", 1, func(v []byte) string {
+		switch v[0] {
+		case CommandTypeRequest:
+			return "0x05 (command)"
+		case CommandTypeResponse:
+			return "0x06 (response)"
+		}
+		return fmt.Sprintf("%#02x", v[0])
+	})
+	size, ok := d.number("Size", 4)
+	if !ok {
+		return
+	}
+	d.field("Text", size, func(v []byte) string { return fmt.Sprintf("%q", v) })
+
+	end := d.offset
+	quantity2, ok := d.number("Quantity 2", 1)
+	if ok && quantity2 != quantity {
+		d.fail(end, fmt.Errorf("Unexpected quantity 2, want %v, got %v", quantity, quantity2))
+	}
+}
+
+// end fails if there are Bytes after the last field
+func (d *dissector) end(length int) {
+	if d.err == nil && d.offset != length {
+		d.fail(d.offset, fmt.Errorf("Unexpected %v Bytes after the end of data", length-d.offset))
+	}
+}
+
+// String renders the fields with their Bytes like a packet dissector, followed by the failure and a hex dump of the packet
+// in which the failing Byte is marked by brackets
+func (d Dissection) String() string {
+	var b strings.Builder
+
+	for _, f := range d.Fields {
+		indent := strings.Repeat("  ", f.Depth)
+		last := f.Offset + f.Length - 1
+		if last < f.Offset {
+			last = f.Offset
+		}
+		if f.Value == "" {
+			fmt.Fprintf(&b, "%04x-%04x  %-26s %v%v (%v Bytes)\n", f.Offset, last, "", indent, f.Name, f.Length)
+			continue
+		}
+		fmt.Fprintf(&b, "%04x-%04x  %-26s %v%v: %v\n", f.Offset, last, hexBytes(d.Packet[f.Offset:f.Offset+f.Length]), indent, f.Name, f.Value)
+	}
+
+	if d.Err != nil {
+		if d.Err.Offset < len(d.Packet) {
+			fmt.Fprintf(&b, "%04x       %-26s Error: %v\n", d.Err.Offset, hexBytes(d.Packet[d.Err.Offset:]), d.Err)
+		} else {
+			fmt.Fprintf(&b, "%04x       %-26s Error: %v\n", d.Err.Offset, "(end of packet)", d.Err)
+		}
+	}
+
+	b.WriteString("\n")
+	for line := 0; line < len(d.Packet); line += 16 {
+		fmt.Fprintf(&b, "%04x ", line)
+		var ascii strings.Builder
+		for i := line; i < line+16; i++ {
+			separator := " "
+			if d.Err != nil && i == d.Err.Offset {
+				separator = "["
+			} else if d.Err != nil && i == d.Err.Offset+1 && i != line {
+				separator = "]"
+			}
+
+			if i >= len(d.Packet) {
+				b.WriteString(separator + "  ")
+				separator = " "
+				continue
+			}
+			b.WriteString(separator + fmt.Sprintf("%02x", d.Packet[i]))
+
+			if c := d.Packet[i]; c >= 0x20 && c < 0x7f {
+				ascii.WriteByte(c)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		if d.Err != nil && d.Err.Offset == line+15 {
+			b.WriteString("]")
+		} else {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, " %v\n", ascii.String())
+	}
+
+	return b.String()
+}
+
+// hexBytes returns up to 8 Bytes separated by spaces
+func hexBytes(v []byte) string {
+	if len(v) > 8 {
+		return fmt.Sprintf("% x ..", v[:7])
+	}
+	return fmt.Sprintf("% x", v)
+}
+
+func decimal(v []byte) string {
+	var x uint64
+	for _, b := range v {
+		x = x<<8 | uint64(b)
+	}
+	return fmt.Sprintf("%v", x)
+}
+
+func hexadecimal(v []byte) string {
+	return fmt.Sprintf("%#x", v)
+}
+
+func degrees(v []byte) string {
+	return fmt.Sprintf("%.7f", float64(int32(binary.BigEndian.Uint32(v)))/1e7)
+}
+
+// ioValue returns the value in hex, numbers up to 8 Bytes also in decimal
+func ioValue(v []byte) string {
+	if len(v) == 0 || len(v) > 8 {
+		return fmt.Sprintf("%#x", v)
+	}
+	return fmt.Sprintf("%#x (%v)", v, decimal(v))
+}
+
+func codecName(codecID byte) string {
+	switch codecID {
+	case 0x08:
+		return "0x08 (Codec 8)"
+	case 0x8e:
+		return "0x8e (Codec 8 Extended)"
+	case 0x10:
+		return "0x10 (Codec 16)"
+	case CodecID:
+		return "0x0c (Codec 12)"
+	}
+	return fmt.Sprintf("%#02x (unknown)", codecID)
+}
+
+func priorityName(priority byte) string {
+	switch priority {
+	case 0:
+		return "0 (Low)"
+	case 1:
+		return "1 (High)"
+	case 2:
+		return "2 (Panic)"
+	}
+	return fmt.Sprintf("%v (invalid)", priority)
+}
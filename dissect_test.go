@@ -0,0 +1,122 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package teltonikaparser
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDissect(t *testing.T) {
+	udpPacket := `0086cafe0101000f3335323039333038353639383230368e0100000167efa919800200000000000000000000000000000000fc0013000800ef0000f00000150500c80000450200010000710000fc00000900b5000000b600000042305600cd432a00ce6064001100090012ff22001303d1000f0000000200f1000059d90010000000000000000001`
+	tcpPacket := "000000000000003608010000016b40d8ea30010000000000000000000000000000000105021503010101425e0f01f10000601a014e0000000000000000010000c7cf"
+
+	testCases := []struct {
+		Name   string
+		Packet string
+		TCP    bool
+		Fields []string // names of some expected fields
+	}{
+		{Name: "UDPCodec8Extended", Packet: udpPacket, Fields: []string{"IMEI", "Record 1", "Variable length IO count", "IO 239", "Number of data 2"}},
+		{Name: "TCPCodec8", Packet: tcpPacket, TCP: true, Fields: []string{"Preamble", "IO 78", "CRC-16/IBM"}},
+		{Name: "TCPCodec12", Packet: "000000000000000f0c010500000007676574696e666f0100004312", TCP: true, Fields: []string{"Type", "Text", "Quantity 2", "CRC-16/IBM"}},
+		{Name: "UDPPacketID", Packet: udpPacket[:4] + "0007" + udpPacket[8:], Fields: []string{"Packet ID", "IMEI", "Record 1"}},
+		{Name: "UDPCodec12", Packet: "0024cafe0101000f3335323039333038353639383230360c010600000007676574696e666f01", Fields: []string{"IMEI", "Text", "Quantity 2"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			bs, _ := hex.DecodeString(testCase.Packet)
+
+			var d Dissection
+			if testCase.TCP {
+				d = DissectTCP(&bs)
+			} else {
+				d = DissectUDP(&bs)
+			}
+			if d.Err != nil {
+				test.Fatalf("Failed to dissect packet. %v at %v", d.Err, d.Err.Offset)
+			}
+
+			// fields with a value cover the packet without gaps
+			next := 0
+			names := make(map[string]bool)
+			for _, f := range d.Fields {
+				names[f.Name] = true
+				if f.Value == "" {
+					continue
+				}
+				if f.Offset != next {
+					test.Errorf("Field %v starts at %v, want %v", f.Name, f.Offset, next)
+				}
+				next = f.Offset + f.Length
+			}
+			if next != len(bs) {
+				test.Errorf("Fields end at %v, packet has %v Bytes", next, len(bs))
+			}
+
+			for _, name := range testCase.Fields {
+				if !names[name] {
+					test.Errorf("Missing field %v", name)
+				}
+			}
+		})
+	}
+}
+
+func TestDissectError(t *testing.T) {
+	udpPacket := `0086cafe0101000f3335323039333038353639383230368e0100000167efa919800200000000000000000000000000000000fc0013000800ef0000f00000150500c80000450200010000710000fc00000900b5000000b600000042305600cd432a00ce6064001100090012ff22001303d1000f0000000200f1000059d90010000000000000000001`
+	tcpPacket := "000000000000003608010000016b40d8ea30010000000000000000000000000000000105021503010101425e0f01f10000601a014e0000000000000000010000c7cf"
+
+	// the dissector fails at the same Byte as the decoder
+	testCases := []struct {
+		Name   string
+		Packet string
+		TCP    bool
+	}{
+		{Name: "InvalidLength", Packet: "0087" + udpPacket[4:]},
+		{Name: "InvalidIMEILength", Packet: udpPacket[:12] + "0010" + udpPacket[16:]},
+		{Name: "InvalidCodec", Packet: udpPacket[:46] + "07" + udpPacket[48:]},
+		{Name: "InvalidIOCount", Packet: udpPacket[:102] + "0014" + udpPacket[106:]},
+		{Name: "InvalidNoOfData2", Packet: udpPacket[:len(udpPacket)-2] + "02"},
+		{Name: "InvalidCRC", Packet: tcpPacket[:len(tcpPacket)-2] + "00", TCP: true},
+		{Name: "InvalidPriority", Packet: tcpPacket[:36] + "03" + tcpPacket[38:len(tcpPacket)-8] + "0000a44c", TCP: true},
+		{Name: "TruncatedElement", Packet: "0062" + udpPacket[4:200]},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			bs, _ := hex.DecodeString(testCase.Packet)
+
+			var d Dissection
+			var err error
+			if testCase.TCP {
+				d = DissectTCP(&bs)
+				_, err = DecodeTCP(&bs)
+			} else {
+				d = DissectUDP(&bs)
+				_, err = Decode(&bs)
+			}
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				test.Fatalf("Expected DecodeError, got %v", err)
+			}
+			if d.Err == nil {
+				test.Fatalf("This is an error case but there is no error.")
+			}
+			if d.Err.Offset != decodeErr.Offset {
+				test.Logf("Expected offset: %v (%v), Actual offset: %v (%v)", decodeErr.Offset, err, d.Err.Offset, d.Err)
+				test.Fail()
+			}
+
+			if dump := d.String(); !strings.Contains(dump, "Error: ") || !strings.Contains(dump, "[") {
+				test.Errorf("Failure is not marked in\n%v", dump)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geofence
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+	"github.com/filipkroca/teltonikaparser/analytics"
+)
+
+// Defaults of Engine
+const (
+	DefaultMargin    = 20              // DefaultMargin in meters is used when Margin is not set
+	DefaultDwellTime = 5 * time.Minute // DefaultDwellTime is used when DwellTime is not set
+)
+
+// EventType is a kind of an Event
+type EventType int
+
+// Types of events
+const (
+	Enter EventType = iota + 1 // Enter is emitted when a device enters a zone
+	Exit                       // Exit is emitted when a device leaves a zone
+	Dwell                      // Dwell is emitted once when a device stays in a zone for DwellTime
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Enter:
+		return "enter"
+	case Exit:
+		return "exit"
+	case Dwell:
+		return "dwell"
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event is a change of presence of a device in a zone
+type Event struct {
+	Type     EventType
+	IMEI     string          // IMEI of the device
+	Zone     string          // Zone is the ID of the zone
+	Time     time.Time       // Time of the record
+	Position analytics.Point // Position of the record
+}
+
+// Engine evaluates records of devices against the zones. A device enters a zone when its position is Margin meters inside
+// of the zone and leaves it when it is Margin meters outside, so a device driving along the boundary does not flap.
+// Inside of a zone smaller than Margin, e.g. a circle with a radius under 2×Margin, the half of its Depth is used instead.
+// The first position of a device is inside or outside by the boundary itself. Positions without a fix, see analytics.FixClassifier,
+// are skipped. Records of every device have to come in order of time, older records are dropped.
+// IDs of the zones have to be unique and zones are not changed while the engine is in use.
+// The zero value has no zones, it is safe for concurrent use.
+type Engine struct {
+	Zones     []Zone                  // Zones to evaluate
+	Margin    float64                 // Margin in meters of the hysteresis, zero means DefaultMargin
+	DwellTime time.Duration           // DwellTime in a zone emits Dwell, zero means DefaultDwellTime, negative disables it
+	Fix       analytics.FixClassifier // Fix classifies positions, positions without a fix are skipped
+
+	mu      sync.Mutex
+	devices map[string]*device
+	dropped int
+	enterAt []float64 // enterAt is the distance inside of each zone a device enters it
+}
+
+// device is the state of one device
+type device struct {
+	last       time.Time            // time of the last record
+	positioned bool                 // positioned is true after the first record with a fix
+	inside     map[string]*presence // inside holds the zones the device is in by the zone ID
+}
+
+// presence is a stay of a device in a zone
+type presence struct {
+	since time.Time // since is the time of the Enter event
+	dwelt bool      // dwelt is true after the Dwell event
+}
+
+// Add takes the next record of the device and returns its events, usually none
+func (e *Engine) Add(imei string, data teltonikaparser.AvlData) []Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	t := time.Unix(0, int64(data.UtimeMs)*int64(time.Millisecond)).UTC()
+	d, seen := e.devices[imei]
+	if seen && !t.After(d.last) {
+		e.dropped++
+		return nil
+	}
+	if !seen {
+		if e.devices == nil {
+			e.devices = make(map[string]*device)
+		}
+		d = &device{inside: make(map[string]*presence)}
+		e.devices[imei] = d
+	}
+	d.last = t
+
+	if e.Fix.Classify(&data) < analytics.FixPoor {
+		return nil
+	}
+
+	if len(e.enterAt) != len(e.Zones) {
+		e.enterAt = make([]float64, len(e.Zones))
+		for i := range e.Zones {
+			e.enterAt[i] = math.Min(e.margin(), e.Zones[i].Depth()/2)
+		}
+	}
+
+	var events []Event
+	position := analytics.Position(&data)
+	for i := range e.Zones {
+		zone := &e.Zones[i]
+		distance := zone.Distance(position)
+		stay, inside := d.inside[zone.ID]
+
+		switch {
+		case !inside && (distance <= -e.enterAt[i] || (!d.positioned && distance <= 0)):
+			d.inside[zone.ID] = &presence{since: t}
+			events = append(events, Event{Type: Enter, IMEI: imei, Zone: zone.ID, Time: t, Position: position})
+		case inside && distance >= e.margin():
+			delete(d.inside, zone.ID)
+			events = append(events, Event{Type: Exit, IMEI: imei, Zone: zone.ID, Time: t, Position: position})
+		case inside && !stay.dwelt && e.DwellTime >= 0 && t.Sub(stay.since) >= e.dwellTime():
+			stay.dwelt = true
+			events = append(events, Event{Type: Dwell, IMEI: imei, Zone: zone.ID, Time: t, Position: position})
+		}
+	}
+	d.positioned = true
+	return events
+}
+
+// Inside returns IDs of the zones the device is in
+func (e *Engine) Inside(imei string) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	d, ok := e.devices[imei]
+	if !ok {
+		return nil
+	}
+	var zones []string
+	for i := range e.Zones {
+		if _, inside := d.inside[e.Zones[i].ID]; inside {
+			zones = append(zones, e.Zones[i].ID)
+		}
+	}
+	return zones
+}
+
+// Dropped returns the number of records which were dropped because they were not newer than the last record of the device
+func (e *Engine) Dropped() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.dropped
+}
+
+func (e *Engine) margin() float64 {
+	if e.Margin == 0 {
+		return DefaultMargin
+	}
+	return e.Margin
+}
+
+func (e *Engine) dwellTime() time.Duration {
+	if e.DwellTime == 0 {
+		return DefaultDwellTime
+	}
+	return e.DwellTime
+}
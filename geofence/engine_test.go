@@ -0,0 +1,226 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geofence
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+	"github.com/filipkroca/teltonikaparser/analytics"
+)
+
+// origin is the time of the first record of synthetic tracks
+var origin = time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+
+// north returns records every 10 seconds on the meridian 16.6 at the distances in meters north of the latitude 49.2
+func north(distances ...float64) []teltonikaparser.AvlData {
+	records := make([]teltonikaparser.AvlData, 0, len(distances))
+	for i, distance := range distances {
+		t := origin.Add(time.Duration(i) * 10 * time.Second)
+		records = append(records, teltonikaparser.AvlData{
+			UtimeMs: uint64(t.UnixNano() / int64(time.Millisecond)),
+			Utime:   uint64(t.Unix()),
+			Lat:     int32(math.Round((49.2 + distance/metersPerDegree) * 1e7)),
+			Lng:     166000000,
+			VisSat:  9,
+			Speed:   40,
+		})
+	}
+	return records
+}
+
+// event is the type and the record of an event
+type event struct {
+	Type   EventType
+	Record int
+}
+
+func TestEngine(t *testing.T) {
+	zones, err := ParseGeoJSON([]byte(zonesJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the circle of 500 m around 49.2, 16.6
+	depot := zones[:1]
+
+	noFix := north(-100, -100, -100)
+	noFix[0].VisSat = 0
+
+	testCases := []struct {
+		Name     string
+		Engine   *Engine
+		Records  []teltonikaparser.AvlData
+		Expected []event
+	}{
+		{
+			Name:     "Through",
+			Engine:   &Engine{Zones: depot},
+			Records:  north(-800, -600, -400, -200, 0, 200, 400, 600, 800),
+			Expected: []event{{Enter, 2}, {Exit, 7}},
+		},
+		{
+			Name:   "Boundary",
+			Engine: &Engine{Zones: depot},
+			// the device drives along the boundary and enters and leaves by the margin only
+			Records:  north(600, 495, 505, 490, 510, 470, 510, 490, 515, 505, 530, 490),
+			Expected: []event{{Enter, 5}, {Exit, 10}},
+		},
+		{
+			Name:     "Margin",
+			Engine:   &Engine{Zones: depot, Margin: 5},
+			Records:  north(600, 495, 505, 490, 510, 470, 510, 490, 515, 505, 530, 490),
+			Expected: []event{{Enter, 1}, {Exit, 2}, {Enter, 3}, {Exit, 4}, {Enter, 5}, {Exit, 6}, {Enter, 7}, {Exit, 8}, {Enter, 11}},
+		},
+		{
+			Name:     "FirstInside",
+			Engine:   &Engine{Zones: depot},
+			Records:  north(495, 505, 530),
+			Expected: []event{{Enter, 0}, {Exit, 2}},
+		},
+		{
+			Name:     "NoFix",
+			Engine:   &Engine{Zones: depot},
+			Records:  noFix,
+			Expected: []event{{Enter, 1}},
+		},
+		{
+			Name:     "Dwell",
+			Engine:   &Engine{Zones: depot, DwellTime: 30 * time.Second},
+			Records:  north(-600, 0, 0, 0, 0, 0, 0, 600),
+			Expected: []event{{Enter, 1}, {Dwell, 4}, {Exit, 7}},
+		},
+		{
+			Name:     "DwellDisabled",
+			Engine:   &Engine{Zones: depot, DwellTime: -1},
+			Records:  north(-600, 0, 0, 0, 0, 0, 0, 600),
+			Expected: []event{{Enter, 1}, {Exit, 7}},
+		},
+		{
+			Name:     "DefaultDwell",
+			Engine:   &Engine{Zones: depot},
+			Records:  north(append(append([]float64{-600}, make([]float64, 32)...), 600)...),
+			Expected: []event{{Enter, 1}, {Dwell, 31}, {Exit, 33}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			var events []event
+			for i, data := range testCase.Records {
+				for _, e := range testCase.Engine.Add("352094081672179", data) {
+					if e.IMEI != "352094081672179" || e.Zone != "depot" || !e.Time.Equal(origin.Add(time.Duration(i)*10*time.Second)) {
+						test.Logf("Event of record %v is %+v", i, e)
+						test.Fail()
+					}
+					events = append(events, event{e.Type, i})
+				}
+			}
+			if len(events) != len(testCase.Expected) {
+				test.Fatalf("Expected events %v, got %v", testCase.Expected, events)
+			}
+			for i := range events {
+				if events[i] != testCase.Expected[i] {
+					test.Logf("Event %v is %v, want %v", i, events[i], testCase.Expected[i])
+					test.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestEngineDevices(t *testing.T) {
+	zones, err := ParseGeoJSON([]byte(zonesJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := &Engine{Zones: zones}
+
+	inside, outside := north(0, 100), north(-800, -800)
+	engine.Add("A", inside[0])
+	engine.Add("B", outside[0])
+	// the record of A is older than the last one
+	engine.Add("A", inside[1])
+	if events := engine.Add("A", inside[0]); events != nil {
+		t.Errorf("Late record returned %+v", events)
+	}
+	engine.Add("B", outside[1])
+
+	if zones := engine.Inside("A"); len(zones) != 1 || zones[0] != "depot" {
+		t.Errorf("A is inside %v", zones)
+	}
+	if zones := engine.Inside("B"); len(zones) != 0 {
+		t.Errorf("B is inside %v", zones)
+	}
+	if zones := engine.Inside("C"); zones != nil {
+		t.Errorf("Unknown device is inside %v", zones)
+	}
+	if engine.Dropped() != 1 {
+		t.Errorf("Dropped %v records, want 1", engine.Dropped())
+	}
+}
+
+func TestEngineSmallZones(t *testing.T) {
+	// 15 m from the meridian 16.6 on both sides
+	width := 15 / (metersPerDegree * math.Cos(49.2*math.Pi/180))
+	strip := Zone{ID: "strip", Polygons: [][][]analytics.Point{{{
+		{Lat: 49.19, Lng: 16.6 - width}, {Lat: 49.19, Lng: 16.6 + width}, {Lat: 49.21, Lng: 16.6 + width},
+		{Lat: 49.21, Lng: 16.6 - width}, {Lat: 49.19, Lng: 16.6 - width},
+	}}}}
+
+	testCases := []struct {
+		Name     string
+		Zone     Zone
+		Records  []teltonikaparser.AvlData
+		Expected []event
+	}{
+		// the circle is smaller than Margin, it is entered 7.5 m inside
+		{Name: "Circle", Zone: Zone{ID: "post", Center: analytics.Point{Lat: 49.2, Lng: 16.6}, Radius: 15}, Records: north(-100, -5, 100, 0), Expected: []event{{Enter, 1}, {Exit, 2}, {Enter, 3}}},
+		// the strip is 30 m wide, narrower than 2×Margin
+		{Name: "Polygon", Zone: strip, Records: north(-1500, -1000, 0, 1500), Expected: []event{{Enter, 1}, {Exit, 3}}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			engine := &Engine{Zones: []Zone{testCase.Zone}}
+			var events []event
+			for i, data := range testCase.Records {
+				for _, e := range engine.Add("352094081672179", data) {
+					events = append(events, event{e.Type, i})
+				}
+			}
+			if len(events) != len(testCase.Expected) {
+				test.Fatalf("Expected events %v, got %v", testCase.Expected, events)
+			}
+			for i := range events {
+				if events[i] != testCase.Expected[i] {
+					test.Logf("Event %v is %v, want %v", i, events[i], testCase.Expected[i])
+					test.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestEngineZonesWithoutID(t *testing.T) {
+	// two circles without id get IDs by their index, so presence in one does not hide the other
+	zones, err := ParseGeoJSON([]byte(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"radius": 500}, "geometry": {"type": "Point", "coordinates": [16.6, 49.2]}},
+		{"type": "Feature", "properties": {"radius": 500}, "geometry": {"type": "Point", "coordinates": [16.6, 49.3]}}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := &Engine{Zones: zones}
+
+	var events []Event
+	for _, data := range north(-800, 0, 100, 200) {
+		events = append(events, engine.Add("352094081672179", data)...)
+	}
+	if len(events) != 1 || events[0].Type != Enter || events[0].Zone != "0" {
+		t.Errorf("Events are %+v", events)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geofence
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/filipkroca/teltonikaparser/analytics"
+)
+
+// geoJSON is a GeoJSON object, a FeatureCollection, a Feature or a geometry
+type geoJSON struct {
+	Type        string                 `json:"type"`
+	Features    []geoJSON              `json:"features"`
+	ID          interface{}            `json:"id"`
+	Properties  map[string]interface{} `json:"properties"`
+	Geometry    *geoJSON               `json:"geometry"`
+	Coordinates json.RawMessage        `json:"coordinates"`
+}
+
+// ParseGeoJSON returns zones of a GeoJSON FeatureCollection or Feature. A Point feature is a circle with the radius property in meters,
+// Polygon and MultiPolygon features are polygons. The id of the feature is the ID of the zone, a feature without id
+// gets the index in the collection, duplicate IDs are rejected. The name property is the Name of the zone.
+func ParseGeoJSON(bs []byte) ([]Zone, error) {
+	var object geoJSON
+	if err := json.Unmarshal(bs, &object); err != nil {
+		return nil, fmt.Errorf("Unable to parse GeoJSON, %v", err)
+	}
+
+	switch object.Type {
+	case "FeatureCollection":
+		zones := make([]Zone, 0, len(object.Features))
+		ids := make(map[string]bool, len(object.Features))
+		for i := range object.Features {
+			zone, err := parseFeature(&object.Features[i])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid feature %v, %v", i, err)
+			}
+			if zone.ID == "" {
+				zone.ID = strconv.Itoa(i)
+			}
+			// Engine tells zones apart by the ID
+			if ids[zone.ID] {
+				return nil, fmt.Errorf("Invalid feature %v, duplicate id %q", i, zone.ID)
+			}
+			ids[zone.ID] = true
+			zones = append(zones, zone)
+		}
+		return zones, nil
+	case "Feature":
+		zone, err := parseFeature(&object)
+		if err != nil {
+			return nil, err
+		}
+		if zone.ID == "" {
+			zone.ID = "0"
+		}
+		return []Zone{zone}, nil
+	}
+	return nil, fmt.Errorf("Unsupported GeoJSON type %q, want FeatureCollection or Feature", object.Type)
+}
+
+// parseFeature returns the zone of the feature
+func parseFeature(feature *geoJSON) (Zone, error) {
+	if feature.Type != "Feature" {
+		return Zone{}, fmt.Errorf("Unsupported GeoJSON type %q, want Feature", feature.Type)
+	}
+	if feature.Geometry == nil {
+		return Zone{}, fmt.Errorf("Missing geometry")
+	}
+
+	zone := Zone{}
+	if feature.ID != nil {
+		zone.ID = fmt.Sprint(feature.ID)
+	}
+	if name, ok := feature.Properties["name"].(string); ok {
+		zone.Name = name
+	}
+
+	geometry := feature.Geometry
+	switch geometry.Type {
+	case "Point":
+		var position []float64
+		if err := json.Unmarshal(geometry.Coordinates, &position); err != nil || len(position) < 2 {
+			return Zone{}, fmt.Errorf("Invalid coordinates of Point %s", geometry.Coordinates)
+		}
+		radius, ok := feature.Properties["radius"].(float64)
+		if !ok || radius <= 0 {
+			return Zone{}, fmt.Errorf("Missing radius of the circle, want a positive number in meters")
+		}
+		zone.Center, zone.Radius = analytics.Point{Lat: position[1], Lng: position[0]}, radius
+	case "Polygon":
+		var polygon [][][]float64
+		if err := json.Unmarshal(geometry.Coordinates, &polygon); err != nil {
+			return Zone{}, fmt.Errorf("Invalid coordinates of Polygon, %v", err)
+		}
+		rings, err := parsePolygon(polygon)
+		if err != nil {
+			return Zone{}, err
+		}
+		zone.Polygons = [][][]analytics.Point{rings}
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if err := json.Unmarshal(geometry.Coordinates, &polygons); err != nil {
+			return Zone{}, fmt.Errorf("Invalid coordinates of MultiPolygon, %v", err)
+		}
+		for _, polygon := range polygons {
+			rings, err := parsePolygon(polygon)
+			if err != nil {
+				return Zone{}, err
+			}
+			zone.Polygons = append(zone.Polygons, rings)
+		}
+		if zone.Polygons == nil {
+			return Zone{}, fmt.Errorf("Invalid MultiPolygon without polygons")
+		}
+	default:
+		return Zone{}, fmt.Errorf("Unsupported geometry %q, want Point, Polygon or MultiPolygon", geometry.Type)
+	}
+	return zone, nil
+}
+
+// parsePolygon returns the rings of the polygon, positions of GeoJSON are longitude first
+func parsePolygon(polygon [][][]float64) ([][]analytics.Point, error) {
+	if len(polygon) == 0 {
+		return nil, fmt.Errorf("Invalid polygon without rings")
+	}
+	rings := make([][]analytics.Point, 0, len(polygon))
+	for _, ring := range polygon {
+		if len(ring) < 4 {
+			return nil, fmt.Errorf("Invalid ring with %v positions, want at least 4", len(ring))
+		}
+		points := make([]analytics.Point, 0, len(ring))
+		for _, position := range ring {
+			if len(position) < 2 {
+				return nil, fmt.Errorf("Invalid position %v", position)
+			}
+			points = append(points, analytics.Point{Lat: position[1], Lng: position[0]})
+		}
+		if points[0] != points[len(points)-1] {
+			return nil, fmt.Errorf("Invalid ring, the first and the last positions differ")
+		}
+		rings = append(rings, points)
+	}
+	return rings, nil
+}
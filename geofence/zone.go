@@ -0,0 +1,123 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package geofence evaluates decoded AVL records against circle and polygon zones and emits enter, exit and dwell events,
+// so zones are maintained on the server instead of in the geozones of every device.
+package geofence
+
+import (
+	"math"
+
+	"github.com/filipkroca/teltonikaparser/analytics"
+)
+
+// metersPerDegree is the length of one degree of latitude in meters on a sphere with the mean Earth radius
+const metersPerDegree = 6371000 * math.Pi / 180
+
+// Zone is a circle or a polygon. A circle has Center and Radius, a polygon has Polygons.
+type Zone struct {
+	ID       string                // ID of the zone, it is the ID of the GeoJSON feature
+	Name     string                // Name of the zone, it is the name property of the GeoJSON feature
+	Center   analytics.Point       // Center of a circle
+	Radius   float64               // Radius of a circle in meters
+	Polygons [][][]analytics.Point // Polygons of the zone, each one has the outer ring and optional holes, rings are closed
+}
+
+// Contains returns true if the point is inside the zone
+func (z *Zone) Contains(p analytics.Point) bool {
+	if z.Polygons == nil {
+		return analytics.Distance(z.Center, p) <= z.Radius
+	}
+	for _, polygon := range z.Polygons {
+		if len(polygon) == 0 || !inRing(polygon[0], p) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if inRing(hole, p) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// Distance returns the distance of the point from the boundary of the zone in meters, it is negative inside the zone
+func (z *Zone) Distance(p analytics.Point) float64 {
+	if z.Polygons == nil {
+		return analytics.Distance(z.Center, p) - z.Radius
+	}
+
+	distance := math.Inf(1)
+	for _, polygon := range z.Polygons {
+		for _, ring := range polygon {
+			for i := 0; i+1 < len(ring); i++ {
+				distance = math.Min(distance, segmentDistance(p, ring[i], ring[i+1]))
+			}
+		}
+	}
+	if z.Contains(p) {
+		return -distance
+	}
+	return distance
+}
+
+// Depth returns the distance from the boundary of the deepest point inside the zone in meters, it is Radius for a circle.
+// It is approximated on a grid over each polygon, so it is zero for a polygon too thin for the grid.
+func (z *Zone) Depth() float64 {
+	if z.Polygons == nil {
+		return z.Radius
+	}
+
+	const steps = 32
+	depth := 0.0
+	for _, polygon := range z.Polygons {
+		if len(polygon) == 0 || len(polygon[0]) == 0 {
+			continue
+		}
+		min, max := polygon[0][0], polygon[0][0]
+		for _, p := range polygon[0] {
+			min.Lat, min.Lng = math.Min(min.Lat, p.Lat), math.Min(min.Lng, p.Lng)
+			max.Lat, max.Lng = math.Max(max.Lat, p.Lat), math.Max(max.Lng, p.Lng)
+		}
+		for i := 1; i < steps; i++ {
+			for j := 1; j < steps; j++ {
+				p := analytics.Point{Lat: min.Lat + (max.Lat-min.Lat)*float64(i)/steps, Lng: min.Lng + (max.Lng-min.Lng)*float64(j)/steps}
+				depth = math.Max(depth, -z.Distance(p))
+			}
+		}
+	}
+	return depth
+}
+
+// inRing returns true if the point is inside the closed ring by the ray casting
+func inRing(ring []analytics.Point, p analytics.Point) bool {
+	inside := false
+	for i := 0; i+1 < len(ring); i++ {
+		a, b := ring[i], ring[i+1]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) && p.Lng < a.Lng+(p.Lat-a.Lat)*(b.Lng-a.Lng)/(b.Lat-a.Lat) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// segmentDistance returns the distance of the point from the segment in meters, the segment is projected to a plane around the point
+func segmentDistance(p, a, b analytics.Point) float64 {
+	scale := math.Cos(p.Lat * math.Pi / 180)
+	ax, ay := (a.Lng-p.Lng)*scale*metersPerDegree, (a.Lat-p.Lat)*metersPerDegree
+	bx, by := (b.Lng-p.Lng)*scale*metersPerDegree, (b.Lat-p.Lat)*metersPerDegree
+
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		// the point is at the origin
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/length))
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
@@ -0,0 +1,184 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package geofence
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/filipkroca/teltonikaparser/analytics"
+)
+
+// zonesJSON has a circle, a square with a square hole and two squares
+const zonesJSON = `{
+	"type": "FeatureCollection",
+	"features": [
+		{"type": "Feature", "id": "depot", "properties": {"name": "Depot", "radius": 500}, "geometry": {"type": "Point", "coordinates": [16.6, 49.2]}},
+		{"type": "Feature", "id": 7, "properties": {"name": "Yard"}, "geometry": {"type": "Polygon", "coordinates": [
+			[[16.6, 49.3], [16.62, 49.3], [16.62, 49.32], [16.6, 49.32], [16.6, 49.3]],
+			[[16.605, 49.305], [16.615, 49.305], [16.615, 49.315], [16.605, 49.315], [16.605, 49.305]]
+		]}},
+		{"type": "Feature", "id": "fields", "properties": {}, "geometry": {"type": "MultiPolygon", "coordinates": [
+			[[[16.7, 49.3], [16.71, 49.3], [16.71, 49.31], [16.7, 49.31], [16.7, 49.3]]],
+			[[[16.8, 49.3], [16.81, 49.3], [16.81, 49.31], [16.8, 49.31], [16.8, 49.3]]]
+		]}}
+	]
+}`
+
+func TestParseGeoJSON(t *testing.T) {
+	zones, err := ParseGeoJSON([]byte(zonesJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 3 {
+		t.Fatalf("Expected 3 zones, got %v", len(zones))
+	}
+	if zones[0].ID != "depot" || zones[0].Name != "Depot" || zones[0].Radius != 500 || zones[0].Center != (analytics.Point{Lat: 49.2, Lng: 16.6}) {
+		t.Errorf("Circle is %+v", zones[0])
+	}
+	if zones[1].ID != "7" || zones[1].Name != "Yard" || len(zones[1].Polygons) != 1 || len(zones[1].Polygons[0]) != 2 {
+		t.Errorf("Polygon is %+v", zones[1])
+	}
+	if zones[2].ID != "fields" || len(zones[2].Polygons) != 2 {
+		t.Errorf("MultiPolygon is %+v", zones[2])
+	}
+
+	feature := `{"type": "Feature", "id": "depot", "properties": {"radius": 50}, "geometry": {"type": "Point", "coordinates": [16.6, 49.2]}}`
+	if zones, err := ParseGeoJSON([]byte(feature)); err != nil || len(zones) != 1 || zones[0].Radius != 50 {
+		t.Errorf("Feature is %+v, %v", zones, err)
+	}
+}
+
+func TestParseGeoJSONIDs(t *testing.T) {
+	circle := `{"type": "Feature", %v "properties": {"radius": 50}, "geometry": {"type": "Point", "coordinates": [16.6, 49.2]}}`
+	collection := func(ids ...string) []byte {
+		features := make([]string, 0, len(ids))
+		for _, id := range ids {
+			features = append(features, fmt.Sprintf(circle, id))
+		}
+		return []byte(`{"type": "FeatureCollection", "features": [` + strings.Join(features, ",") + `]}`)
+	}
+
+	testCases := []struct {
+		Name      string
+		JSON      []byte
+		Expected  []string
+		ErrorCase bool
+	}{
+		{Name: "Missing", JSON: collection("", ""), Expected: []string{"0", "1"}},
+		{Name: "Mixed", JSON: collection(`"id": "depot",`, ""), Expected: []string{"depot", "1"}},
+		{Name: "Feature", JSON: []byte(fmt.Sprintf(circle, "")), Expected: []string{"0"}},
+		{Name: "Duplicate", JSON: collection(`"id": "depot",`, `"id": "depot",`), ErrorCase: true},
+		{Name: "DuplicateIndex", JSON: collection(`"id": 1,`, ""), ErrorCase: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			zones, err := ParseGeoJSON(testCase.JSON)
+			if testCase.ErrorCase {
+				if err == nil {
+					test.Logf("This is an error case but there is no error.")
+					test.Fail()
+				}
+				return
+			}
+			if err != nil {
+				test.Fatal(err)
+			}
+			var ids []string
+			for _, zone := range zones {
+				ids = append(ids, zone.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(testCase.Expected, ",") {
+				test.Logf("IDs are %v, want %v", ids, testCase.Expected)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestParseGeoJSONErrors(t *testing.T) {
+	testCases := []struct {
+		Name string
+		JSON string
+	}{
+		{Name: "NotJSON", JSON: `{"type":`},
+		{Name: "Geometry", JSON: `{"type": "Point", "coordinates": [16.6, 49.2]}`},
+		{Name: "MissingGeometry", JSON: `{"type": "Feature", "properties": {}}`},
+		{Name: "MissingRadius", JSON: `{"type": "Feature", "geometry": {"type": "Point", "coordinates": [16.6, 49.2]}}`},
+		{Name: "NegativeRadius", JSON: `{"type": "Feature", "properties": {"radius": -1}, "geometry": {"type": "Point", "coordinates": [16.6, 49.2]}}`},
+		{Name: "ShortPoint", JSON: `{"type": "Feature", "properties": {"radius": 1}, "geometry": {"type": "Point", "coordinates": [16.6]}}`},
+		{Name: "ShortRing", JSON: `{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[16.6, 49.3], [16.62, 49.3], [16.6, 49.3]]]}}`},
+		{Name: "OpenRing", JSON: `{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[16.6, 49.3], [16.62, 49.3], [16.62, 49.32], [16.6, 49.32]]]}}`},
+		{Name: "EmptyPolygon", JSON: `{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": []}}`},
+		{Name: "EmptyMultiPolygon", JSON: `{"type": "Feature", "geometry": {"type": "MultiPolygon", "coordinates": []}}`},
+		{Name: "LineString", JSON: `{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[16.6, 49.3], [16.62, 49.3]]}}`},
+		{Name: "InvalidFeature", JSON: `{"type": "FeatureCollection", "features": [{"type": "Point"}]}`},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			if zones, err := ParseGeoJSON([]byte(testCase.JSON)); err == nil {
+				test.Logf("This is an error case but there is no error, zones %+v", zones)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestZone(t *testing.T) {
+	zones, err := ParseGeoJSON([]byte(zonesJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	circle, square, fields := &zones[0], &zones[1], &zones[2]
+
+	testCases := []struct {
+		Name     string
+		Zone     *Zone
+		Point    analytics.Point
+		Contains bool
+		Distance float64
+	}{
+		{Name: "CircleCenter", Zone: circle, Point: analytics.Point{Lat: 49.2, Lng: 16.6}, Contains: true, Distance: -500},
+		{Name: "CircleInside", Zone: circle, Point: analytics.Point{Lat: 49.2 + 400/metersPerDegree, Lng: 16.6}, Contains: true, Distance: -100},
+		{Name: "CircleOutside", Zone: circle, Point: analytics.Point{Lat: 49.2 - 600/metersPerDegree, Lng: 16.6}, Distance: 100},
+		{Name: "SquareInside", Zone: square, Point: analytics.Point{Lat: 49.301, Lng: 16.61}, Contains: true, Distance: -111.2},
+		{Name: "SquareOutside", Zone: square, Point: analytics.Point{Lat: 49.299, Lng: 16.61}, Distance: 111.2},
+		{Name: "SquareCorner", Zone: square, Point: analytics.Point{Lat: 49.299, Lng: 16.599}, Distance: 132.7},
+		{Name: "Hole", Zone: square, Point: analytics.Point{Lat: 49.306, Lng: 16.61}, Distance: 111.2},
+		{Name: "SecondPolygon", Zone: fields, Point: analytics.Point{Lat: 49.305, Lng: 16.805}, Contains: true, Distance: -363.5},
+		{Name: "BetweenPolygons", Zone: fields, Point: analytics.Point{Lat: 49.305, Lng: 16.75}, Distance: 2900.1},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			if contains := testCase.Zone.Contains(testCase.Point); contains != testCase.Contains {
+				test.Logf("Contains is %v, want %v", contains, testCase.Contains)
+				test.Fail()
+			}
+			if distance := testCase.Zone.Distance(testCase.Point); math.Abs(distance-testCase.Distance) > 1 {
+				test.Logf("Distance is %v, want %v", distance, testCase.Distance)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestZoneDepth(t *testing.T) {
+	zones, err := ParseGeoJSON([]byte(zonesJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the square of 0.02° has the hole of 0.01° in the middle, the fields are squares of 0.01°
+	for i, expected := range []float64{500, 278, 363.5} {
+		if depth := zones[i].Depth(); math.Abs(depth-expected) > expected/20 {
+			t.Errorf("Depth of %v is %v, want %v", zones[i].ID, depth, expected)
+		}
+	}
+}
@@ -28,14 +28,14 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 	if codecID == 0x8e {
 		x, err := b2n.ParseBs2Uint16(bs, start)
 		if err != nil {
-			return []Element{}, 0, fmt.Errorf("DecodeElements error %v", err)
+			return []Element{}, 0, errorAt(start, fmt.Errorf("DecodeElements error %v", err))
 		}
 
 		totalElements = int(x)
 	} else if codecID == 0x08 {
 		x, err := b2n.ParseBs2Uint8(bs, start)
 		if err != nil {
-			return []Element{}, 0, fmt.Errorf("DecodeElements error %v", err)
+			return []Element{}, 0, errorAt(start, fmt.Errorf("DecodeElements error %v", err))
 		}
 
 		totalElements = int(x)
@@ -50,14 +50,14 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 	// parse 1Byte ios
 	x, err := b2n.ParseBs2Uint8(bs, nextByte)
 	if err != nil {
-		return []Element{}, 0, fmt.Errorf("DecodeElements error %v", err)
+		return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements error %v", err))
 	}
 	noOfElements := int(x)
 
 	if codecID == 0x8e {
 		z, err := b2n.ParseBs2Uint16(bs, nextByte)
 		if err != nil {
-			return []Element{}, 0, fmt.Errorf("DecodeElements error %v", err)
+			return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements error %v", err))
 		}
 		noOfElements = int(z)
 	}
@@ -67,7 +67,7 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 	for ioB := 0; ioB < noOfElements; ioB++ {
 		cutted, err := cutIO(bs, nextByte, codecLenDel, 1)
 		if err != nil {
-			return []Element{}, 0, fmt.Errorf("DecodeElements 1B error %v", err)
+			return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements 1B error %v", err))
 		}
 		//append element to the returned slice
 		ElementsBS = append(ElementsBS, cutted)
@@ -78,14 +78,14 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 	// parse 2Byte ios
 	noOfElementsX, err := b2n.ParseBs2Uint8(bs, nextByte)
 	if err != nil {
-		return []Element{}, 0, fmt.Errorf("DecodeElements noOfElements 2B error %v", err)
+		return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements noOfElements 2B error %v", err))
 	}
 	noOfElements = int(noOfElementsX)
 
 	if codecID == 0x8e {
 		noOfElementsX, err := b2n.ParseBs2Uint16(bs, nextByte)
 		if err != nil {
-			return []Element{}, 0, fmt.Errorf("DecodeElements noOfElements 2B Extended Codec error %v", err)
+			return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements noOfElements 2B Extended Codec error %v", err))
 		}
 		noOfElements = int(noOfElementsX)
 	}
@@ -95,7 +95,7 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 	for ioB := 0; ioB < noOfElements; ioB++ {
 		cutted, err := cutIO(bs, nextByte, codecLenDel, 2)
 		if err != nil {
-			return []Element{}, 0, fmt.Errorf("DecodeElements 2B error %v", err)
+			return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements 2B error %v", err))
 		}
 		// append element to the returned slice
 		ElementsBS = append(ElementsBS, cutted)
@@ -106,14 +106,14 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 	//parse 4Byte ios
 	noOfElementsX, err = b2n.ParseBs2Uint8(bs, nextByte)
 	if err != nil {
-		return []Element{}, 0, fmt.Errorf("DecodeElements noOfElements 4B error %v", err)
+		return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements noOfElements 4B error %v", err))
 	}
 	noOfElements = int(noOfElementsX)
 
 	if codecID == 0x8e {
 		noOfElementsX, err := b2n.ParseBs2Uint16(bs, nextByte)
 		if err != nil {
-			return []Element{}, 0, fmt.Errorf("DecodeElements noOfElements 4B Extended Codec error %v", err)
+			return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements noOfElements 4B Extended Codec error %v", err))
 		}
 		noOfElements = int(noOfElementsX)
 	}
@@ -123,7 +123,7 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 	for ioB := 0; ioB < noOfElements; ioB++ {
 		cutted, err := cutIO(bs, nextByte, codecLenDel, 4)
 		if err != nil {
-			return []Element{}, 0, fmt.Errorf("DecodeElements 4B error %v", err)
+			return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements 4B error %v", err))
 		}
 		// append element to the returned slice
 		ElementsBS = append(ElementsBS, cutted)
@@ -134,14 +134,14 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 	//parse 8Byte ios
 	noOfElementsX, err = b2n.ParseBs2Uint8(bs, nextByte)
 	if err != nil {
-		return []Element{}, 0, fmt.Errorf("DecodeElements noOfElements 8B error %v", err)
+		return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements noOfElements 8B error %v", err))
 	}
 	noOfElements = int(noOfElementsX)
 
 	if codecID == 0x8e {
 		noOfElementsX, err := b2n.ParseBs2Uint16(bs, nextByte)
 		if err != nil {
-			return []Element{}, 0, fmt.Errorf("DecodeElements noOfElements 8B Extended Codec error %v", err)
+			return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements noOfElements 8B Extended Codec error %v", err))
 		}
 		noOfElements = int(noOfElementsX)
 	}
@@ -151,7 +151,7 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 	for ioB := 0; ioB < noOfElements; ioB++ {
 		cutted, err := cutIO(bs, nextByte, codecLenDel, 8)
 		if err != nil {
-			return []Element{}, 0, fmt.Errorf("DecodeElements 8B error %v", err)
+			return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements 8B error %v", err))
 		}
 		// append element to the returned slice
 		ElementsBS = append(ElementsBS, cutted)
@@ -164,7 +164,7 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 
 		noOfElementsX, err := b2n.ParseBs2Uint16(bs, nextByte)
 		if err != nil {
-			return []Element{}, 0, fmt.Errorf("DecodeElements noOfElements variableB Extended Codec error %v", err)
+			return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements noOfElements variableB Extended Codec error %v", err))
 		}
 		noOfElements = int(noOfElementsX)
 
@@ -173,7 +173,7 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 		for ioB := 0; ioB < noOfElements; ioB++ {
 			cutted, err := cutIOxLen(bs, nextByte)
 			if err != nil {
-				return []Element{}, 0, fmt.Errorf("DecodeElements 2B error %v", err)
+				return []Element{}, 0, errorAt(nextByte, fmt.Errorf("DecodeElements 2B error %v", err))
 			}
 			// append element to the returned slice
 			ElementsBS = append(ElementsBS, cutted)
@@ -185,7 +185,7 @@ func DecodeElements(bs *[]byte, start int, codecID byte) ([]Element, int, error)
 
 	if totalElementsChecksum != totalElements {
 		//log.Fatalf("Error when counting parsed IO Elements, want %v, got %v", totalElements, totalElementsChecksum)
-		return []Element{}, 0, fmt.Errorf("Error when counting parsed IO Elements, want %v, got %v", totalElements, totalElementsChecksum)
+		return []Element{}, 0, errorAt(nextByte, fmt.Errorf("Error when counting parsed IO Elements, want %v, got %v", totalElements, totalElementsChecksum))
 	}
 
 	return ElementsBS, nextByte, nil
@@ -208,11 +208,11 @@ func cutIO(bs *[]byte, start int, idLen int, length int) (Element, error) {
 		curIO.IOID, err = b2n.ParseBs2Uint16(bs, start)
 	}
 	if err != nil {
-		return Element{}, fmt.Errorf("cutIO error ParseBs2Uint8 or ParseBs2Uint16, %v", err)
+		return Element{}, errorAt(start, fmt.Errorf("cutIO error ParseBs2Uint8 or ParseBs2Uint16, %v", err))
 	}
 
 	if (start + idLen + length) > len(*bs) {
-		return Element{}, fmt.Errorf("cutIO error, want minimum length of bs %v, got %v, packet %x", start+idLen+length, len(*bs), *bs)
+		return Element{}, errorAt(start, fmt.Errorf("cutIO error, want minimum length of bs %v, got %v, packet %x", start+idLen+length, len(*bs), *bs))
 	}
 
 	curIO.Value = (*bs)[start+idLen : start+idLen+length]
@@ -228,13 +228,17 @@ func cutIOxLen(bs *[]byte, start int) (Element, error) {
 	//parse element ID according to the length of ID [1, 2] Byte
 	curIO.IOID, err = b2n.ParseBs2Uint16(bs, start)
 	if err != nil {
-		return Element{}, fmt.Errorf("cutIOxLen error, %v", err)
+		return Element{}, errorAt(start, fmt.Errorf("cutIOxLen error, %v", err))
 	}
 
 	//determine length of this variable element
 	curIO.Length, err = b2n.ParseBs2Uint16(bs, start+2)
 	if err != nil {
-		return Element{}, fmt.Errorf("cutIOxLen error, %v", err)
+		return Element{}, errorAt(start, fmt.Errorf("cutIOxLen error, %v", err))
+	}
+
+	if (start + 4 + int(curIO.Length)) > len(*bs) {
+		return Element{}, errorAt(start, fmt.Errorf("cutIOxLen error, want minimum length of bs %v, got %v", start+4+int(curIO.Length), len(*bs)))
 	}
 
 	curIO.Value = (*bs)[start+4 : start+4+int(curIO.Length)]
@@ -0,0 +1,262 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package teltonikaparser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/filipkroca/teltonikaparser/teltonikajson"
+)
+
+// Types of configuration parameters
+const (
+	ParamUnsigned = "Unsigned" // ParamUnsigned values are whole numbers from Min to Max
+	ParamString   = "String"   // ParamString values are texts of Min to Max characters
+)
+
+// ParamKey represent a configuration parameter of a device family parsed from JSON in ./teltonikajson/
+type ParamKey struct {
+	ID            uint16            `json:"-"`
+	PropertyName  string            `json:"PropertyName"`
+	Type          string            `json:"Type"` // Type is ParamUnsigned or ParamString
+	Min           int64             `json:"Min,string"`
+	Max           int64             `json:"Max,string"`
+	Units         string            `json:"Units"`
+	Description   string            `json:"Description"`
+	ParametrGroup string            `json:"Parametr Group"`
+	Values        map[string]string `json:"Values"` // labels of enumerated values, keyed by the decimal value, other values are invalid
+}
+
+// ParamValue is a typed value of a configuration parameter, Value is uint64 for ParamUnsigned and string for ParamString
+type ParamValue struct {
+	ID    uint16
+	Value interface{}
+}
+
+// ErrNoParamCatalogue is returned for a device family which has an IO dictionary but no parameter catalogue, e.g. FM36,
+// its parameters can still be sent by SetParam and GetParam
+var ErrNoParamCatalogue = errors.New("no parameter catalogue")
+
+var (
+	// paramCatalogue is parsed once by loadParams and never modified after that
+	paramCatalogue     map[string]map[uint16]ParamKey
+	paramCatalogueErr  error
+	paramCatalogueOnce sync.Once
+)
+
+// loadParams parses the parameter catalogues of ./teltonikajson/.. into maps, it is done only once and the result is shared
+func loadParams() (map[string]map[uint16]ParamKey, error) {
+	paramCatalogueOnce.Do(func() {
+		families := []struct {
+			device string
+			json   string
+		}{
+			{"FMBXY", teltonikajson.FMBXYParams},
+			{"FM64", teltonikajson.FM64Params},
+			{"FM11XY", teltonikajson.FM11XYParams},
+		}
+
+		catalogue := make(map[string]map[uint16]ParamKey, len(families))
+		for _, family := range families {
+			keys := make(map[uint16]ParamKey)
+			if err := json.Unmarshal([]byte(family.json), &keys); err != nil {
+				paramCatalogueErr = fmt.Errorf("Unable to parse %v parameters, %v", family.device, err)
+				return
+			}
+			for id, key := range keys {
+				if key.Type != ParamUnsigned && key.Type != ParamString {
+					paramCatalogueErr = fmt.Errorf("Unknown type %q of %v parameter %v", key.Type, family.device, id)
+					return
+				}
+				key.ID = id
+				keys[id] = key
+			}
+			catalogue[family.device] = keys
+		}
+		paramCatalogue = catalogue
+	})
+
+	return paramCatalogue, paramCatalogueErr
+}
+
+// deviceParams returns the catalogue of the device family, ErrNoParamCatalogue is wrapped for a family known only by its IO dictionary
+func deviceParams(device string) (map[uint16]ParamKey, error) {
+	catalogue, err := loadParams()
+	if err != nil {
+		return nil, err
+	}
+
+	keys, ok := catalogue[device]
+	if !ok {
+		if elements, err := loadElements(); err == nil && elements[device] != nil {
+			return nil, fmt.Errorf("Unable to check parameters of %v, %w", device, ErrNoParamCatalogue)
+		}
+		return nil, fmt.Errorf("Unknown device %v", device)
+	}
+	return keys, nil
+}
+
+// LookupParam takes device type ["FMBXY", "FM64", "FM11XY"] and a parameter ID and return its description
+func LookupParam(device string, id uint16) (ParamKey, error) {
+	keys, err := deviceParams(device)
+	if err != nil {
+		return ParamKey{}, err
+	}
+	key, ok := keys[id]
+	if !ok {
+		return ParamKey{}, fmt.Errorf("Unknown parameter %v of %v", id, device)
+	}
+	return key, nil
+}
+
+// DeviceParams takes device type ["FMBXY", "FM64", "FM11XY"] and return its parameters sorted by ID
+func DeviceParams(device string) ([]ParamKey, error) {
+	keys, err := deviceParams(device)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]ParamKey, 0, len(keys))
+	for _, key := range keys {
+		params = append(params, key)
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].ID < params[j].ID })
+	return params, nil
+}
+
+// Format checks the value and return it as sent by setparam, ParamUnsigned takes integers, bool or a decimal string,
+// ParamString takes a string
+func (k ParamKey) Format(value interface{}) (string, error) {
+	var text string
+
+	switch k.Type {
+	case ParamUnsigned:
+		var number uint64
+		switch v := value.(type) {
+		case bool:
+			if v {
+				number = 1
+			}
+		case int, int8, int16, int32, int64:
+			signed := reflect.ValueOf(v).Int()
+			if signed < 0 {
+				return "", fmt.Errorf("Invalid value %v of %v, it can not be negative", v, k.PropertyName)
+			}
+			number = uint64(signed)
+		case uint, uint8, uint16, uint32, uint64:
+			number = reflect.ValueOf(v).Uint()
+		case string:
+			parsed, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("Invalid value %q of %v, want a whole number", v, k.PropertyName)
+			}
+			number = parsed
+		default:
+			return "", fmt.Errorf("Invalid value %v of %v, want a whole number", value, k.PropertyName)
+		}
+		text = strconv.FormatUint(number, 10)
+	case ParamString:
+		v, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("Invalid value %v of %v, want a string", value, k.PropertyName)
+		}
+		text = v
+	default:
+		return "", fmt.Errorf("Unknown type %q of %v", k.Type, k.PropertyName)
+	}
+
+	if _, err := k.Parse(text); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// Parse takes the value as sent by the device and return it typed, uint64 for ParamUnsigned and string for ParamString
+func (k ParamKey) Parse(text string) (interface{}, error) {
+	switch k.Type {
+	case ParamUnsigned:
+		number, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value %q of %v, want a whole number", text, k.PropertyName)
+		}
+		if number < uint64(k.Min) || number > uint64(k.Max) {
+			return nil, fmt.Errorf("Invalid value %v of %v, want %v to %v", number, k.PropertyName, k.Min, k.Max)
+		}
+		if len(k.Values) > 0 {
+			if _, ok := k.Values[text]; !ok {
+				return nil, fmt.Errorf("Invalid value %v of %v, want one of %v", number, k.PropertyName, k.Values)
+			}
+		}
+		return number, nil
+	case ParamString:
+		if length := int64(utf8.RuneCountInString(text)); length < k.Min || length > k.Max {
+			return nil, fmt.Errorf("Invalid length %v of %v, want %v to %v characters", length, k.PropertyName, k.Min, k.Max)
+		}
+		return text, nil
+	}
+	return nil, fmt.Errorf("Unknown type %q of %v", k.Type, k.PropertyName)
+}
+
+// SetDeviceParams returns the setparam command for parameters of the device family, the values are checked by the catalogue
+func SetDeviceParams(device string, values ...ParamValue) (Command, error) {
+	if _, err := deviceParams(device); err != nil {
+		return Command{}, err
+	}
+	params := make([]Param, len(values))
+	for i, value := range values {
+		key, err := LookupParam(device, value.ID)
+		if err != nil {
+			return Command{}, err
+		}
+		text, err := key.Format(value.Value)
+		if err != nil {
+			return Command{}, err
+		}
+		params[i] = Param{ID: value.ID, Value: text}
+	}
+	return SetParam(params...)
+}
+
+// GetDeviceParams returns the getparam command for parameters of the device family which are in the catalogue
+func GetDeviceParams(device string, ids ...uint16) (Command, error) {
+	if _, err := deviceParams(device); err != nil {
+		return Command{}, err
+	}
+	for _, id := range ids {
+		if _, err := LookupParam(device, id); err != nil {
+			return Command{}, err
+		}
+	}
+	return GetParam(ids...)
+}
+
+// ParseDeviceParamResponse parses the response to getparam and return typed values of parameters of the device family,
+// a value which does not match the catalogue is reported by ResponseError
+func ParseDeviceParamResponse(device string, text string) ([]ParamValue, error) {
+	params, err := ParseParamResponse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]ParamValue, len(params))
+	for i, param := range params {
+		key, err := LookupParam(device, param.ID)
+		if err != nil {
+			return nil, &ResponseError{Command: "getparam", Response: text, Err: err}
+		}
+		value, err := key.Parse(param.Value)
+		if err != nil {
+			return nil, &ResponseError{Command: "getparam", Response: text, Err: err}
+		}
+		values[i] = ParamValue{ID: param.ID, Value: value}
+	}
+	return values, nil
+}
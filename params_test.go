@@ -0,0 +1,163 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package teltonikaparser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeviceParams(t *testing.T) {
+	for _, device := range []string{"FMBXY", "FM64", "FM11XY"} {
+		params, err := DeviceParams(device)
+		if err != nil {
+			t.Fatalf("%v: %v", device, err)
+		}
+		if len(params) == 0 {
+			t.Errorf("%v has no parameters", device)
+		}
+
+		for i, key := range params {
+			if i > 0 && params[i-1].ID >= key.ID {
+				t.Errorf("%v parameters are not sorted, %v before %v", device, params[i-1].ID, key.ID)
+			}
+			if key.PropertyName == "" || key.Min > key.Max {
+				t.Errorf("%v parameter %v is invalid, %+v", device, key.ID, key)
+			}
+			for value := range key.Values {
+				if _, err := key.Parse(value); err != nil {
+					t.Errorf("%v parameter %v has a label of invalid value, %v", device, key.ID, err)
+				}
+			}
+		}
+	}
+
+	if _, err := DeviceParams("FMX"); err == nil {
+		t.Error("This is an error case but there is no error.")
+	}
+}
+
+func TestNoParamCatalogue(t *testing.T) {
+	// FM36 has an IO dictionary but no parameter catalogue
+	_, err := DeviceParams("FM36")
+	if !errors.Is(err, ErrNoParamCatalogue) {
+		t.Errorf("DeviceParams error is %v, want ErrNoParamCatalogue", err)
+	}
+	if _, err := LookupParam("FM36", 2004); !errors.Is(err, ErrNoParamCatalogue) {
+		t.Errorf("LookupParam error is %v, want ErrNoParamCatalogue", err)
+	}
+	if _, err := GetDeviceParams("FM36"); !errors.Is(err, ErrNoParamCatalogue) {
+		t.Errorf("GetDeviceParams error is %v, want ErrNoParamCatalogue", err)
+	}
+	if _, err := SetDeviceParams("FM36"); !errors.Is(err, ErrNoParamCatalogue) {
+		t.Errorf("SetDeviceParams error is %v, want ErrNoParamCatalogue", err)
+	}
+	if _, err := DeviceParams("FMX"); err == nil || errors.Is(err, ErrNoParamCatalogue) {
+		t.Errorf("Unknown device error is %v", err)
+	}
+}
+
+func TestLookupParam(t *testing.T) {
+	key, err := LookupParam("FMBXY", 2004)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.ID != 2004 || key.PropertyName != "Domain" || key.Type != ParamString || key.Max != 55 {
+		t.Errorf("Parameter 2004 is %+v", key)
+	}
+
+	if _, err := LookupParam("FMBXY", 1); err == nil {
+		t.Error("This is an error case but there is no error.")
+	}
+}
+
+func TestSetDeviceParams(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Device    string
+		Values    []ParamValue
+		Expected  string
+		ErrorCase bool
+	}{
+		{
+			Name:     "Server",
+			Device:   "FMBXY",
+			Values:   []ParamValue{{ID: 2004, Value: "gps.example.com"}, {ID: 2005, Value: 5027}, {ID: 2006, Value: uint8(0)}},
+			Expected: "setparam 2004:gps.example.com;2005:5027;2006:0",
+		},
+		{Name: "Bool", Device: "FM11XY", Values: []ParamValue{{ID: 1240, Value: true}}, Expected: "setparam 1240:1"},
+		{Name: "DecimalString", Device: "FM64", Values: []ParamValue{{ID: 246, Value: "5027"}}, Expected: "setparam 246:5027"},
+		{Name: "EmptyString", Device: "FMBXY", Values: []ParamValue{{ID: 2002, Value: ""}}, Expected: "setparam 2002:"},
+		{Name: "Period", Device: "FMBXY", Values: []ParamValue{{ID: 11000, Value: uint32(60)}}, Expected: "setparam 11000:60"},
+		{Name: "OutOfRange", Device: "FMBXY", Values: []ParamValue{{ID: 2005, Value: 70000}}, ErrorCase: true},
+		{Name: "Negative", Device: "FMBXY", Values: []ParamValue{{ID: 10000, Value: -1}}, ErrorCase: true},
+		{Name: "NotEnumerated", Device: "FMBXY", Values: []ParamValue{{ID: 102, Value: 5}}, ErrorCase: true},
+		{Name: "TooLong", Device: "FMBXY", Values: []ParamValue{{ID: 2004, Value: strings.Repeat("a", 56)}}, ErrorCase: true},
+		{Name: "WrongType", Device: "FMBXY", Values: []ParamValue{{ID: 2004, Value: 1}}, ErrorCase: true},
+		{Name: "NotNumber", Device: "FMBXY", Values: []ParamValue{{ID: 2005, Value: "port"}}, ErrorCase: true},
+		{Name: "Separator", Device: "FMBXY", Values: []ParamValue{{ID: 2001, Value: "a;2004:b"}}, ErrorCase: true},
+		{Name: "UnknownParameter", Device: "FMBXY", Values: []ParamValue{{ID: 1245, Value: "a"}}, ErrorCase: true},
+		{Name: "UnknownDevice", Device: "FM36", Values: []ParamValue{{ID: 2004, Value: "a"}}, ErrorCase: true},
+		{Name: "None", Device: "FMBXY", ErrorCase: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			command, err := SetDeviceParams(testCase.Device, testCase.Values...)
+
+			if testCase.ErrorCase {
+				if err == nil {
+					test.Logf("This is an error case but there is no error, %q", command)
+					test.Fail()
+				}
+				return
+			}
+
+			if err != nil {
+				test.Logf("Error %v", err)
+				test.Fail()
+				return
+			}
+			if command.String() != testCase.Expected {
+				test.Logf("Command is %q, want %q", command, testCase.Expected)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestGetDeviceParams(t *testing.T) {
+	command, err := GetDeviceParams("FMBXY", 2004, 2005)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if command.String() != "getparam 2004;2005" {
+		t.Errorf("Command is %q", command)
+	}
+
+	if _, err := GetDeviceParams("FMBXY", 2004, 1245); err == nil {
+		t.Error("This is an error case but there is no error.")
+	}
+}
+
+func TestParseDeviceParamResponse(t *testing.T) {
+	values, err := ParseDeviceParamResponse("FMBXY", "Param ID:2004 Value:gps.example.com;Param ID:2005 Value:5027;Param ID:102 Value:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ParamValue{{ID: 2004, Value: "gps.example.com"}, {ID: 2005, Value: uint64(5027)}, {ID: 102, Value: uint64(2)}}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Values are %v, want %v", values, expected)
+	}
+
+	for _, response := range []string{"Param ID:2005 Value:70000", "Param ID:2006 Value:TCP", "Param ID:1245 Value:a", "Unknown command"} {
+		var responseErr *ResponseError
+		if _, err := ParseDeviceParamResponse("FMBXY", response); !errors.As(err, &responseErr) {
+			t.Errorf("This is an error case but the error of %q is %v, want ResponseError", response, err)
+		}
+	}
+}
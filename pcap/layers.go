@@ -0,0 +1,205 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pcap
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/netip"
+)
+
+// Link types of captures which are supported, see https://www.tcpdump.org/linktypes.html
+const (
+	LinkTypeNull      = 0   // BSD loopback
+	LinkTypeEthernet  = 1   // Ethernet with optional 802.1Q tags
+	LinkTypeRaw       = 101 // raw IPv4 or IPv6
+	LinkTypeLinuxSLL  = 113 // Linux cooked capture of tcpdump -i any
+	LinkTypeIPv4      = 228 // raw IPv4
+	LinkTypeIPv6      = 229 // raw IPv6
+	LinkTypeLinuxSLL2 = 276 // Linux cooked capture v2
+)
+
+var (
+	// errSkip is returned for packets which do not carry TCP or UDP over IPv4 or IPv6
+	errSkip = errors.New("not TCP or UDP over IP")
+	// errTruncated is returned for packets which are cut by the snap length, malformed or fragmented
+	errTruncated = errors.New("truncated packet")
+)
+
+// segment is a TCP segment or UDP datagram
+type segment struct {
+	tcp      bool
+	src, dst netip.AddrPort
+	seq      uint32
+	syn      bool
+	fin      bool
+	rst      bool
+	payload  []byte
+}
+
+// parseSegment returns TCP or UDP layer of the packet
+func parseSegment(linkType uint32, data []byte) (segment, error) {
+	var etherType uint16
+
+	switch linkType {
+	case LinkTypeEthernet:
+		if len(data) < 14 {
+			return segment{}, errTruncated
+		}
+		etherType, data = binary.BigEndian.Uint16(data[12:14]), data[14:]
+		// 802.1Q and 802.1ad tags
+		for etherType == 0x8100 || etherType == 0x88a8 {
+			if len(data) < 4 {
+				return segment{}, errTruncated
+			}
+			etherType, data = binary.BigEndian.Uint16(data[2:4]), data[4:]
+		}
+	case LinkTypeLinuxSLL:
+		if len(data) < 16 {
+			return segment{}, errTruncated
+		}
+		etherType, data = binary.BigEndian.Uint16(data[14:16]), data[16:]
+	case LinkTypeLinuxSLL2:
+		if len(data) < 20 {
+			return segment{}, errTruncated
+		}
+		etherType, data = binary.BigEndian.Uint16(data[0:2]), data[20:]
+	case LinkTypeNull:
+		if len(data) < 4 {
+			return segment{}, errTruncated
+		}
+		// the address family is in the byte order of the capturing host
+		family := binary.LittleEndian.Uint32(data[0:4])
+		if family > 0xffff {
+			family = binary.BigEndian.Uint32(data[0:4])
+		}
+		switch family {
+		case 2:
+			etherType = 0x0800
+		case 24, 28, 30:
+			etherType = 0x86dd
+		}
+		data = data[4:]
+	case LinkTypeRaw, LinkTypeIPv4, LinkTypeIPv6:
+		if len(data) == 0 {
+			return segment{}, errTruncated
+		}
+		switch data[0] >> 4 {
+		case 4:
+			etherType = 0x0800
+		case 6:
+			etherType = 0x86dd
+		}
+	default:
+		return segment{}, errSkip
+	}
+
+	switch etherType {
+	case 0x0800:
+		return parseIPv4(data)
+	case 0x86dd:
+		return parseIPv6(data)
+	}
+	return segment{}, errSkip
+}
+
+// parseIPv4 returns the TCP or UDP layer of an IPv4 packet, fragments are not reassembled
+func parseIPv4(data []byte) (segment, error) {
+	if len(data) < 20 || data[0]>>4 != 4 {
+		return segment{}, errTruncated
+	}
+
+	headerLen := int(data[0]&0x0f) * 4
+	total := int(binary.BigEndian.Uint16(data[2:4]))
+	if headerLen < 20 || total < headerLen || total > len(data) {
+		return segment{}, errTruncated
+	}
+
+	// more fragments flag or fragment offset
+	if binary.BigEndian.Uint16(data[6:8])&0x3fff != 0 {
+		return segment{}, errTruncated
+	}
+
+	src, _ := netip.AddrFromSlice(data[12:16])
+	dst, _ := netip.AddrFromSlice(data[16:20])
+
+	// the total length cuts the padding of short Ethernet frames
+	return parseTransport(data[9], src, dst, data[headerLen:total])
+}
+
+// parseIPv6 returns the TCP or UDP layer of an IPv6 packet
+func parseIPv6(data []byte) (segment, error) {
+	if len(data) < 40 || data[0]>>4 != 6 {
+		return segment{}, errTruncated
+	}
+
+	payloadLen := int(binary.BigEndian.Uint16(data[4:6]))
+	if 40+payloadLen > len(data) {
+		return segment{}, errTruncated
+	}
+
+	src, _ := netip.AddrFromSlice(data[8:24])
+	dst, _ := netip.AddrFromSlice(data[24:40])
+	next := data[6]
+	payload := data[40 : 40+payloadLen]
+
+	// hop-by-hop, routing and destination options extension headers
+	for next == 0 || next == 43 || next == 60 {
+		if len(payload) < 8 {
+			return segment{}, errTruncated
+		}
+		length := (int(payload[1]) + 1) * 8
+		if length > len(payload) {
+			return segment{}, errTruncated
+		}
+		next, payload = payload[0], payload[length:]
+	}
+	if next == 44 {
+		// fragment
+		return segment{}, errTruncated
+	}
+
+	return parseTransport(next, src, dst, payload)
+}
+
+// parseTransport parses TCP or UDP header
+func parseTransport(protocol byte, src netip.Addr, dst netip.Addr, data []byte) (segment, error) {
+	switch protocol {
+	case 6:
+		if len(data) < 20 {
+			return segment{}, errTruncated
+		}
+		headerLen := int(data[12]>>4) * 4
+		if headerLen < 20 || headerLen > len(data) {
+			return segment{}, errTruncated
+		}
+		flags := data[13]
+		return segment{
+			tcp:     true,
+			src:     netip.AddrPortFrom(src, binary.BigEndian.Uint16(data[0:2])),
+			dst:     netip.AddrPortFrom(dst, binary.BigEndian.Uint16(data[2:4])),
+			seq:     binary.BigEndian.Uint32(data[4:8]),
+			fin:     flags&0x01 != 0,
+			syn:     flags&0x02 != 0,
+			rst:     flags&0x04 != 0,
+			payload: data[headerLen:],
+		}, nil
+
+	case 17:
+		if len(data) < 8 {
+			return segment{}, errTruncated
+		}
+		length := int(binary.BigEndian.Uint16(data[4:6]))
+		if length < 8 || length > len(data) {
+			return segment{}, errTruncated
+		}
+		return segment{
+			src:     netip.AddrPortFrom(src, binary.BigEndian.Uint16(data[0:2])),
+			dst:     netip.AddrPortFrom(dst, binary.BigEndian.Uint16(data[2:4])),
+			payload: data[8:length],
+		}, nil
+	}
+	return segment{}, errSkip
+}
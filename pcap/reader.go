@@ -0,0 +1,280 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// maxPacketSize limits one captured packet, larger lengths mean a corrupted file
+const maxPacketSize = 256 * 1024
+
+// pcapng block types
+const (
+	blockInterface      = 0x00000001
+	blockPacket         = 0x00000002 // obsolete Packet Block
+	blockSimplePacket   = 0x00000003
+	blockEnhancedPacket = 0x00000006
+	blockSection        = 0x0a0d0d0a
+)
+
+// Packet is one packet of a capture
+type Packet struct {
+	Time     time.Time // Time of the capture, zero for pcapng Simple Packet Blocks which have no timestamp
+	LinkType uint32    // LinkType of Data, e.g. LinkTypeEthernet
+	Data     []byte    // Data captured from the wire, shorter than Length if the snap length cut the packet
+	Length   int       // Length of the packet on the wire
+}
+
+// Reader reads packets of a classic pcap or pcapng file, the format, the byte order and the timestamp resolution are detected from the file
+type Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	ng    bool
+
+	// classic pcap
+	linkType uint32
+	nano     bool
+
+	// pcapng interfaces of the current section
+	interfaces []iface
+}
+
+// iface is a pcapng interface
+type iface struct {
+	linkType uint32
+	snapLen  uint32
+	base2    bool  // resolution of timestamps is 2^-exp, otherwise 10^-exp seconds
+	exp      uint8 // exponent of the resolution
+}
+
+// NewReader reads the file header and returns a Reader of packets
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: bufio.NewReaderSize(r, 64*1024)}
+
+	header, err := reader.r.Peek(24)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read capture header, %v", err)
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(header) == blockSection:
+		// the byte order of pcapng is given by the magic of the Section Header Block
+		if _, err := sectionOrder(header[8:12]); err != nil {
+			return nil, err
+		}
+		reader.ng = true
+		return reader, nil
+	case binary.LittleEndian.Uint32(header) == 0xa1b2c3d4:
+		reader.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(header) == 0xa1b2c3d4:
+		reader.order = binary.BigEndian
+	case binary.LittleEndian.Uint32(header) == 0xa1b23c4d:
+		reader.order, reader.nano = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(header) == 0xa1b23c4d:
+		reader.order, reader.nano = binary.BigEndian, true
+	default:
+		return nil, fmt.Errorf("Unknown capture format, magic %x", header[:4])
+	}
+
+	reader.linkType = reader.order.Uint32(header[20:24]) & 0x0fffffff
+	reader.r.Discard(24)
+	return reader, nil
+}
+
+// Next returns the next packet, it returns io.EOF at the end of the capture
+func (r *Reader) Next() (Packet, error) {
+	if !r.ng {
+		return r.nextClassic()
+	}
+
+	for {
+		blockType, body, err := r.readBlock()
+		if err != nil {
+			return Packet{}, err
+		}
+
+		packet, ok, err := r.block(blockType, body)
+		if err != nil || ok {
+			return packet, err
+		}
+	}
+}
+
+// nextClassic reads a record of a classic pcap file
+func (r *Reader) nextClassic() (Packet, error) {
+	var header [16]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return Packet{}, fmt.Errorf("Truncated record header, %v", err)
+		}
+		return Packet{}, err
+	}
+
+	sec := r.order.Uint32(header[0:4])
+	frac := r.order.Uint32(header[4:8])
+	capLen := r.order.Uint32(header[8:12])
+	origLen := r.order.Uint32(header[12:16])
+	if capLen > maxPacketSize {
+		return Packet{}, fmt.Errorf("Invalid captured length %v", capLen)
+	}
+
+	data := make([]byte, capLen)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return Packet{}, fmt.Errorf("Truncated record, %v", err)
+	}
+
+	ns := int64(frac) * 1000
+	if r.nano {
+		ns = int64(frac)
+	}
+	return Packet{Time: time.Unix(int64(sec), ns).UTC(), LinkType: r.linkType, Data: data, Length: int(origLen)}, nil
+}
+
+// readBlock reads a pcapng block and returns its type and body without the trailing length
+func (r *Reader) readBlock() (uint32, []byte, error) {
+	header, err := r.r.Peek(12)
+	if len(header) == 0 && err == io.EOF {
+		return 0, nil, io.EOF
+	}
+	if len(header) < 8 {
+		return 0, nil, fmt.Errorf("Truncated block header, %v", err)
+	}
+
+	if binary.LittleEndian.Uint32(header) == blockSection {
+		// a new section can change the byte order, its interfaces are numbered from zero
+		if len(header) < 12 {
+			return 0, nil, fmt.Errorf("Truncated section header, %v", err)
+		}
+		if r.order, err = sectionOrder(header[8:12]); err != nil {
+			return 0, nil, err
+		}
+		r.interfaces = nil
+	}
+
+	blockType := r.order.Uint32(header[0:4])
+	length := r.order.Uint32(header[4:8])
+	if length < 12 || length%4 != 0 || length > maxPacketSize+64 {
+		return 0, nil, fmt.Errorf("Invalid length %v of block %#x", length, blockType)
+	}
+
+	block := make([]byte, length)
+	if _, err := io.ReadFull(r.r, block); err != nil {
+		return 0, nil, fmt.Errorf("Truncated block %#x, %v", blockType, err)
+	}
+	return blockType, block[8 : length-4], nil
+}
+
+// sectionOrder returns the byte order by the magic of the Section Header Block
+func sectionOrder(magic []byte) (binary.ByteOrder, error) {
+	switch {
+	case binary.LittleEndian.Uint32(magic) == 0x1a2b3c4d:
+		return binary.LittleEndian, nil
+	case binary.BigEndian.Uint32(magic) == 0x1a2b3c4d:
+		return binary.BigEndian, nil
+	}
+	return nil, fmt.Errorf("Invalid byte order magic %x of pcapng section", magic)
+}
+
+// block processes a pcapng block, it returns true if the block is a packet
+func (r *Reader) block(blockType uint32, body []byte) (Packet, bool, error) {
+	switch blockType {
+	case blockInterface:
+		if len(body) < 8 {
+			return Packet{}, false, errors.New("Truncated Interface Description Block")
+		}
+		i := iface{linkType: uint32(r.order.Uint16(body[0:2])), snapLen: r.order.Uint32(body[4:8]), exp: 6}
+		r.parseOptions(body[8:], &i)
+		r.interfaces = append(r.interfaces, i)
+
+	case blockEnhancedPacket, blockPacket:
+		if len(body) < 20 {
+			return Packet{}, false, fmt.Errorf("Truncated packet block %#x", blockType)
+		}
+		id := r.order.Uint32(body[0:4])
+		if blockType == blockPacket {
+			id = uint32(r.order.Uint16(body[0:2]))
+		}
+		if int(id) >= len(r.interfaces) {
+			return Packet{}, false, fmt.Errorf("Packet of unknown interface %v", id)
+		}
+		i := r.interfaces[id]
+
+		ts := uint64(r.order.Uint32(body[4:8]))<<32 | uint64(r.order.Uint32(body[8:12]))
+		capLen := r.order.Uint32(body[12:16])
+		if int(capLen) > len(body)-20 {
+			return Packet{}, false, fmt.Errorf("Invalid captured length %v", capLen)
+		}
+		data := body[20 : 20+capLen]
+		return Packet{Time: i.time(ts), LinkType: i.linkType, Data: data, Length: int(r.order.Uint32(body[16:20]))}, true, nil
+
+	case blockSimplePacket:
+		if len(r.interfaces) == 0 || len(body) < 4 {
+			return Packet{}, false, errors.New("Invalid Simple Packet Block")
+		}
+		i := r.interfaces[0]
+		length := int(r.order.Uint32(body[0:4]))
+		capLen := length
+		if i.snapLen > 0 && capLen > int(i.snapLen) {
+			capLen = int(i.snapLen)
+		}
+		if capLen > len(body)-4 {
+			capLen = len(body) - 4
+		}
+		return Packet{LinkType: i.linkType, Data: body[4 : 4+capLen], Length: length}, true, nil
+	}
+
+	// other blocks like statistics or name resolution are skipped
+	return Packet{}, false, nil
+}
+
+// parseOptions reads the timestamp resolution of an interface
+func (r *Reader) parseOptions(options []byte, i *iface) {
+	for len(options) >= 4 {
+		code := r.order.Uint16(options[0:2])
+		length := int(r.order.Uint16(options[2:4]))
+		if code == 0 || 4+length > len(options) {
+			return
+		}
+		if code == 9 && length == 1 {
+			// if_tsresol
+			i.base2 = options[4]&0x80 != 0
+			i.exp = options[4] & 0x7f
+		}
+		options = options[4+(length+3)/4*4:]
+	}
+}
+
+// time converts the timestamp in units of the interface resolution
+func (i iface) time(ts uint64) time.Time {
+	if i.base2 {
+		if i.exp >= 64 {
+			return time.Time{}
+		}
+		sec := ts >> i.exp
+		frac := ts & (1<<i.exp - 1)
+		return time.Unix(int64(sec), int64(float64(frac)/math.Exp2(float64(i.exp))*1e9)).UTC()
+	}
+
+	if i.exp > 19 {
+		return time.Time{}
+	}
+	unit := uint64(math.Pow10(int(i.exp)))
+	sec := ts / unit
+	frac := ts % unit
+	var ns uint64
+	if i.exp <= 9 {
+		ns = frac * uint64(math.Pow10(9-int(i.exp)))
+	} else {
+		ns = frac / uint64(math.Pow10(int(i.exp)-9))
+	}
+	return time.Unix(int64(sec), int64(ns)).UTC()
+}
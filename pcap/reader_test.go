@@ -0,0 +1,110 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pcap
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReader(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		File     string
+		Packets  int
+		LinkType uint32
+		Time     time.Time
+	}{
+		{Name: "ClassicMicroseconds", File: "testdata/udp.pcap", Packets: 6, LinkType: LinkTypeEthernet, Time: time.Date(2019, 6, 10, 10, 4, 46, 250000000, time.UTC)},
+		{Name: "ClassicNanosecondsBigEndian", File: "testdata/sll.pcap", Packets: 1, LinkType: LinkTypeLinuxSLL, Time: time.Date(2019, 6, 10, 10, 4, 46, 123456789, time.UTC)},
+		{Name: "PcapngNanoseconds", File: "testdata/tcp.pcapng", Packets: 12, LinkType: LinkTypeEthernet, Time: time.Date(2019, 6, 10, 10, 4, 46, 0, time.UTC)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			f, err := os.Open(testCase.File)
+			if err != nil {
+				test.Fatalf("Failed to open capture. %v", err)
+			}
+			defer f.Close()
+
+			reader, err := NewReader(f)
+			if err != nil {
+				test.Fatalf("Failed to read capture header. %v", err)
+			}
+
+			var packets []Packet
+			for {
+				packet, err := reader.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					test.Fatalf("Failed to read packet %v. %v", len(packets)+1, err)
+				}
+				packets = append(packets, packet)
+			}
+
+			if len(packets) != testCase.Packets {
+				test.Fatalf("Expected %v packets, got %v", testCase.Packets, len(packets))
+			}
+			if first := packets[0]; first.LinkType != testCase.LinkType || !first.Time.Equal(testCase.Time) || first.Length != len(first.Data) {
+				test.Errorf("Unexpected first packet at %v, link type %v, length %v of %v", first.Time, first.LinkType, len(first.Data), first.Length)
+			}
+		})
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	capture, err := os.ReadFile("testdata/udp.pcap")
+	if err != nil {
+		t.Fatalf("Failed to read capture. %v", err)
+	}
+
+	if _, err := NewReader(bytes.NewReader([]byte("not a capture file at all"))); err == nil {
+		t.Errorf("Expected an error of an unknown format")
+	}
+
+	// the last record is cut in the middle
+	reader, err := NewReader(bytes.NewReader(capture[:len(capture)-10]))
+	if err != nil {
+		t.Fatalf("Failed to read capture header. %v", err)
+	}
+	for {
+		_, err = reader.Next()
+		if err != nil {
+			break
+		}
+	}
+	if err == io.EOF {
+		t.Errorf("Expected an error of a truncated record, got EOF")
+	}
+}
+
+func TestInterfaceTime(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Iface    iface
+		Ts       uint64
+		Expected time.Time
+	}{
+		{Name: "Microseconds", Iface: iface{exp: 6}, Ts: 1560161086250000, Expected: time.Unix(1560161086, 250000000)},
+		{Name: "Milliseconds", Iface: iface{exp: 3}, Ts: 1560161086250, Expected: time.Unix(1560161086, 250000000)},
+		{Name: "Picoseconds", Iface: iface{exp: 12}, Ts: 1560161086250000000, Expected: time.Unix(1560161, 86250000)},
+		{Name: "Base2", Iface: iface{base2: true, exp: 10}, Ts: 1560161086<<10 | 256, Expected: time.Unix(1560161086, 250000000)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			if actual := testCase.Iface.time(testCase.Ts); !actual.Equal(testCase.Expected) {
+				test.Logf("Expected value: %v, Actual value: %v", testCase.Expected, actual)
+				test.Fail()
+			}
+		})
+	}
+}
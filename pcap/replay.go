@@ -0,0 +1,255 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pcap extracts Teltonika traffic from classic pcap and pcapng captures, e.g. made by tcpdump on a gateway.
+// It reassembles TCP streams of devices, takes UDP payloads sent to the server ports and decodes them by teltonikaparser.
+// Only the standard library is used, live capturing is not supported.
+package pcap
+
+import (
+	"io"
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// Message is one packet sent by a device to the server
+type Message struct {
+	Time    time.Time                        // Time when the packet was captured, for TCP the time of the segment which completed it
+	TCP     bool                             // TCP is true for packets of a TCP stream, false for UDP datagrams
+	Src     netip.AddrPort                   // Src is the address of the device
+	Dst     netip.AddrPort                   // Dst is the address of the server
+	IMEI    string                           // IMEI from the UDP packet or the login of the TCP connection, empty if the login was not captured
+	Packet  []byte                           // Packet is the raw UDP payload or the TCP packet
+	Decoded *teltonikaparser.Decoded         // Decoded AVL packet, nil for Codec 12 responses and undecodable packets
+	Command *teltonikaparser.CommandResponse // Command is a Codec 12 response to a command of the server
+	Err     error                            // Err is the decoding error of an undecodable packet
+}
+
+// Stats counts the traffic of a replayed capture
+type Stats struct {
+	Packets       int // Packets read from the capture
+	Skipped       int // Packets which are not TCP or UDP over IPv4 or IPv6 sent to the ports
+	Truncated     int // Packets cut by the snap length, malformed or IP fragments
+	Datagrams     int // UDP datagrams sent to the ports
+	Segments      int // TCP segments with data sent to the ports
+	Retransmitted int // TCP segments with data which were received already
+	Gaps          int // Missing parts of TCP streams, the packets in them are lost
+	SkippedBytes  int // Bytes of TCP streams which do not belong to any packet, e.g. after a gap
+	Logins        int // IMEI logins of TCP connections
+	Decoded       int // AVL packets decoded
+	Records       int // Records of decoded AVL packets
+	Commands      int // Codec 12 responses decoded
+	Undecodable   int // UDP datagrams and TCP packets which failed to decode
+}
+
+// Replayer decodes Teltonika traffic of captures
+type Replayer struct {
+	Ports []uint16 // Ports of the server, only traffic sent to them is decoded, empty means all ports
+}
+
+// flow identifies one direction of a TCP connection
+type flow struct {
+	src, dst netip.AddrPort
+}
+
+// Replay reads the capture and calls handle for every packet sent by a device in the order of the capture, undecodable packets
+// are passed with Err. Replay stops at the first error of handle or of reading the capture and returns it with the statistics.
+func (p *Replayer) Replay(r io.Reader, handle func(*Message) error) (Stats, error) {
+	var stats Stats
+
+	reader, err := NewReader(r)
+	if err != nil {
+		return stats, err
+	}
+
+	streams := make(map[flow]*stream)
+	var last time.Time
+
+	// emit decodes whole packets of the stream
+	emit := func(f flow, s *stream, t time.Time) error {
+		for {
+			c, ok := s.chunk()
+			if !ok {
+				return nil
+			}
+			if c.login != "" {
+				stats.Logins++
+				continue
+			}
+
+			m := &Message{Time: t, TCP: true, Src: f.src, Dst: f.dst, IMEI: s.imei, Packet: c.packet}
+			decodeTCP(m)
+			stats.count(m)
+			if err := handle(m); err != nil {
+				return err
+			}
+		}
+	}
+
+	// closeStream gives up missing data of the stream and counts its leftovers
+	closeStream := func(f flow, s *stream, t time.Time) error {
+		s.flush()
+		if err := emit(f, s, t); err != nil {
+			return err
+		}
+		stats.Retransmitted += s.retransmitted
+		stats.Gaps += s.gaps
+		stats.SkippedBytes += s.skipped + len(s.buf)
+		delete(streams, f)
+		return nil
+	}
+
+	for {
+		packet, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stats, err
+		}
+		stats.Packets++
+		last = packet.Time
+
+		seg, err := parseSegment(packet.LinkType, packet.Data)
+		if err == errTruncated || (err == nil && len(packet.Data) < packet.Length && seg.tcp) {
+			// a TCP segment cut by the snap length is a gap in the stream
+			stats.Truncated++
+			continue
+		}
+		if err != nil || !p.wanted(seg.dst.Port()) {
+			stats.Skipped++
+			continue
+		}
+
+		if !seg.tcp {
+			stats.Datagrams++
+			m := &Message{Time: packet.Time, Src: seg.src, Dst: seg.dst, Packet: seg.payload}
+			decodeUDP(m)
+			stats.count(m)
+			if err := handle(m); err != nil {
+				return stats, err
+			}
+			continue
+		}
+
+		f := flow{src: seg.src, dst: seg.dst}
+		s := streams[f]
+		if s == nil {
+			s = &stream{}
+			streams[f] = s
+		}
+		if len(seg.payload) > 0 {
+			stats.Segments++
+		}
+
+		if seg.syn && s.started {
+			// the same addresses are used by a new connection
+			if err := closeStream(f, s, packet.Time); err != nil {
+				return stats, err
+			}
+			s = &stream{}
+			streams[f] = s
+		}
+
+		s.add(seg)
+		if err := emit(f, s, packet.Time); err != nil {
+			return stats, err
+		}
+
+		if seg.fin || seg.rst {
+			if err := closeStream(f, s, packet.Time); err != nil {
+				return stats, err
+			}
+		}
+	}
+
+	// streams which were not closed in the capture, in a stable order
+	open := make([]flow, 0, len(streams))
+	for f := range streams {
+		open = append(open, f)
+	}
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].src.String()+open[i].dst.String() < open[j].src.String()+open[j].dst.String()
+	})
+	for _, f := range open {
+		if err := closeStream(f, streams[f], last); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// wanted returns true if the port is one of the server ports
+func (p *Replayer) wanted(port uint16) bool {
+	if len(p.Ports) == 0 {
+		return true
+	}
+	for _, wanted := range p.Ports {
+		if wanted == port {
+			return true
+		}
+	}
+	return false
+}
+
+// count adds the decoded message to the statistics
+func (s *Stats) count(m *Message) {
+	switch {
+	case m.Err != nil:
+		s.Undecodable++
+	case m.Command != nil:
+		s.Commands++
+	case m.Decoded != nil:
+		s.Decoded++
+		s.Records += len(m.Decoded.Data)
+	}
+}
+
+// decodeUDP decodes the UDP payload as an AVL packet or a Codec 12 response
+func decodeUDP(m *Message) {
+	if len(m.Packet) > 8 {
+		codecIndex := 8 + (int(m.Packet[6])<<8 | int(m.Packet[7]))
+		if codecIndex < len(m.Packet) && m.Packet[codecIndex] == teltonikaparser.CodecID {
+			imei, response, err := teltonikaparser.DecodeCommandResponseUDP(&m.Packet)
+			if err != nil {
+				m.Err = err
+				return
+			}
+			m.IMEI, m.Command = imei, &response
+			return
+		}
+	}
+
+	decoded, err := teltonikaparser.Decode(&m.Packet)
+	if err != nil {
+		m.Err = err
+		return
+	}
+	m.IMEI, m.Decoded = decoded.IMEI, &decoded
+}
+
+// decodeTCP decodes the TCP packet as AVL data or a Codec 12 response
+func decodeTCP(m *Message) {
+	if m.Packet[8] == teltonikaparser.CodecID {
+		response, err := teltonikaparser.DecodeCommandResponse(&m.Packet)
+		if err != nil {
+			m.Err = err
+			return
+		}
+		m.Command = &response
+		return
+	}
+
+	decoded, err := teltonikaparser.DecodeTCP(&m.Packet)
+	if err != nil {
+		m.Err = err
+		return
+	}
+	// the IMEI is known only from the login
+	decoded.IMEI = m.IMEI
+	m.Decoded = &decoded
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pcap
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// replay returns messages and statistics of the capture file
+func replay(t *testing.T, name string, ports ...uint16) ([]*Message, Stats) {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("Failed to open capture. %v", err)
+	}
+	defer f.Close()
+
+	var messages []*Message
+	replayer := &Replayer{Ports: ports}
+	stats, err := replayer.Replay(f, func(m *Message) error {
+		messages = append(messages, m)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to replay capture. %v", err)
+	}
+	return messages, stats
+}
+
+func TestReplayUDP(t *testing.T) {
+	messages, stats := replay(t, "testdata/udp.pcap", 5027)
+
+	expected := Stats{Packets: 6, Skipped: 3, Datagrams: 3, Decoded: 1, Records: 1, Commands: 1, Undecodable: 1}
+	if stats != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, stats)
+	}
+
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %v", len(messages))
+	}
+	if m := messages[0]; m.Decoded == nil || m.IMEI != "352093085698206" || m.Src.String() != "10.0.0.2:40000" || !m.Time.Equal(time.Date(2019, 6, 10, 10, 4, 46, 250000000, time.UTC)) {
+		t.Errorf("Unexpected AVL message %+v", m)
+	}
+	if m := messages[1]; m.Err == nil || string(m.Packet) != "garbage" {
+		t.Errorf("Unexpected undecodable message %+v", m)
+	}
+	if m := messages[2]; m.Command == nil || string(m.Command.Response) != "getinfo" {
+		t.Errorf("Unexpected command message %+v", m)
+	}
+}
+
+func TestReplayLinuxCookedIPv6(t *testing.T) {
+	messages, stats := replay(t, "testdata/sll.pcap")
+
+	if stats.Decoded != 1 || stats.Records != 1 || len(messages) != 1 {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+	if m := messages[0]; m.Dst.String() != "[2001:db8::1]:5027" || m.Time.Nanosecond() != 123456789 {
+		t.Errorf("Unexpected message %+v", m)
+	}
+}
+
+func TestReplayTCP(t *testing.T) {
+	messages, stats := replay(t, "testdata/tcp.pcapng", 5027)
+
+	// the first connection has an out of order segment and a retransmission, the second one starts without SYN and lost a segment
+	expected := Stats{Packets: 12, Skipped: 2, Segments: 8, Retransmitted: 1, Gaps: 1, SkippedBytes: 46, Logins: 2, Decoded: 2, Records: 2, Commands: 1}
+	if stats != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, stats)
+	}
+
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %v", len(messages))
+	}
+
+	first := messages[0]
+	if first.Decoded == nil || first.Decoded.IMEI != "352093085698206" || first.Decoded.Data[0].UtimeMs != 0x16b40d8ea30 || !first.TCP {
+		t.Errorf("Unexpected AVL message %+v", first)
+	}
+	// the packet was completed by the sixth segment
+	if expected := time.Unix(1560161086, 5*1000001).UTC(); !first.Time.Equal(expected) {
+		t.Errorf("Expected time %v, got %v", expected, first.Time)
+	}
+
+	if m := messages[1]; m.Command == nil || len(m.Command.Response) != 0x88 {
+		t.Errorf("Unexpected command message %+v", m)
+	}
+	if m := messages[2]; m.Decoded == nil || m.Src.Port() != 40002 || m.IMEI != "352093085698206" {
+		t.Errorf("Unexpected AVL message after the gap %+v", m)
+	}
+}
+
+func TestReplayHandlerError(t *testing.T) {
+	f, err := os.Open("testdata/tcp.pcapng")
+	if err != nil {
+		t.Fatalf("Failed to open capture. %v", err)
+	}
+	defer f.Close()
+
+	stop := errors.New("stop")
+	calls := 0
+	_, err = (&Replayer{}).Replay(f, func(m *Message) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("Expected the handler error after 1 call, got %v after %v calls", err, calls)
+	}
+}
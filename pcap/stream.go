@@ -0,0 +1,203 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pcap
+
+import (
+	"encoding/binary"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+const (
+	// maxPending limits out of order segments of a stream, the missing data are given up when it is reached
+	maxPending = 64
+	// maxFrameSize limits the data field length of a TCP packet, larger values mean the stream is out of sync
+	maxFrameSize = 64 * 1024
+)
+
+// stream reassembles the TCP stream of one device to the server and splits it into the IMEI login and TCP packets
+type stream struct {
+	started bool
+	next    uint32            // next expected sequence number
+	pending map[uint32][]byte // out of order segments by sequence number
+	buf     []byte            // reassembled data which do not form a whole packet yet
+	imei    string            // IMEI of the login
+
+	retransmitted int // segments which were received already
+	gaps          int // missing parts of the stream which were given up
+	skipped       int // Bytes which do not belong to any packet
+}
+
+// chunk is a message of the stream
+type chunk struct {
+	login  string // IMEI of a login
+	packet []byte // TCP packet, AVL data or Codec 12
+}
+
+// add puts the segment into the stream
+func (s *stream) add(seg segment) {
+	if seg.syn {
+		// a new connection, a SYN consumes one sequence number
+		*s = stream{started: true, next: seg.seq + 1, retransmitted: s.retransmitted, gaps: s.gaps, skipped: s.skipped}
+		return
+	}
+	if len(seg.payload) == 0 {
+		return
+	}
+	if !s.started {
+		// the capture started in the middle of the connection
+		s.started = true
+		s.next = seg.seq
+	}
+
+	seq, payload := seg.seq, seg.payload
+	if diff := int32(seq - s.next); diff < 0 {
+		if int(-diff) >= len(payload) {
+			s.retransmitted++
+			return
+		}
+		// overlapping retransmission
+		seq, payload = s.next, payload[-diff:]
+	}
+
+	if seq != s.next {
+		if s.pending == nil {
+			s.pending = make(map[uint32][]byte)
+		}
+		if _, ok := s.pending[seq]; ok {
+			s.retransmitted++
+			return
+		}
+		s.pending[seq] = append([]byte(nil), payload...)
+		if len(s.pending) > maxPending {
+			s.skipGap()
+		}
+		return
+	}
+
+	s.buf = append(s.buf, payload...)
+	s.next += uint32(len(payload))
+	s.drain()
+}
+
+// drain appends pending segments which follow the stream
+func (s *stream) drain() {
+	for found := true; found; {
+		found = false
+		for seq, payload := range s.pending {
+			diff := int32(seq - s.next)
+			if diff > 0 {
+				continue
+			}
+			delete(s.pending, seq)
+			if int(-diff) < len(payload) {
+				s.buf = append(s.buf, payload[-diff:]...)
+				s.next += uint32(len(payload) + int(diff))
+			}
+			found = true
+		}
+	}
+}
+
+// skipGap gives up the missing data before the first pending segment, the incomplete packet in the buffer is dropped
+func (s *stream) skipGap() {
+	first, min := uint32(0), int32(0)
+	for seq := range s.pending {
+		if diff := int32(seq - s.next); min == 0 || diff < min {
+			first, min = seq, diff
+		}
+	}
+
+	s.gaps++
+	s.skipped += len(s.buf)
+	s.buf = nil
+	s.next = first
+	s.drain()
+}
+
+// flush gives up all missing data, it is called when the connection is closed or the capture ends
+func (s *stream) flush() {
+	for len(s.pending) > 0 {
+		s.skipGap()
+	}
+}
+
+// chunk returns the next whole message of the stream
+func (s *stream) chunk() (chunk, bool) {
+	for {
+		if len(s.buf) < 2 {
+			return chunk{}, false
+		}
+
+		// IMEI login, 2 Bytes length and IMEI digits
+		if n := int(binary.BigEndian.Uint16(s.buf)); n == 15 || n == 16 {
+			if len(s.buf) < 2+n {
+				return chunk{}, false
+			}
+			if isDigits(s.buf[2 : 2+n]) {
+				s.imei = string(s.buf[2 : 2+n])
+				s.buf = s.buf[2+n:]
+				return chunk{login: s.imei}, true
+			}
+		}
+
+		if len(s.buf) < 9 {
+			return chunk{}, false
+		}
+		if !isPacketStart(s.buf) {
+			s.resync()
+			continue
+		}
+
+		size := 12 + int(binary.BigEndian.Uint32(s.buf[4:8]))
+		if len(s.buf) < size {
+			return chunk{}, false
+		}
+		packet := s.buf[:size:size]
+		s.buf = s.buf[size:]
+		return chunk{packet: packet}, true
+	}
+}
+
+// resync drops Bytes until the next plausible start of a TCP packet
+func (s *stream) resync() {
+	for i := 1; i+9 <= len(s.buf); i++ {
+		if isPacketStart(s.buf[i:]) {
+			s.skipped += i
+			s.buf = s.buf[i:]
+			return
+		}
+	}
+
+	// the start of the next packet may be in the last 8 Bytes
+	drop := len(s.buf) - 8
+	s.skipped += drop
+	s.buf = s.buf[drop:]
+}
+
+// isPacketStart returns true if b starts by a preamble, a sane data field length and a known codec
+func isPacketStart(b []byte) bool {
+	if b[0] != 0 || b[1] != 0 || b[2] != 0 || b[3] != 0 {
+		return false
+	}
+	length := binary.BigEndian.Uint32(b[4:8])
+	if length == 0 || length > maxFrameSize {
+		return false
+	}
+	switch b[8] {
+	case 0x08, 0x8e, 0x10, teltonikaparser.CodecID:
+		return true
+	}
+	return false
+}
+
+func isDigits(b []byte) bool {
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,293 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// ErrDeviceDisconnected is returned by SendCommand when the connection of the device is closed before the response arrives
+var ErrDeviceDisconnected = errors.New("server: device disconnected before responding")
+
+// DefaultAbandonGrace is used by Dispatcher when AbandonGrace is not set
+const DefaultAbandonGrace = 10 * time.Second
+
+// Dispatcher sends Codec 12 commands to devices served by TCPServer or UDPServer and matches the responses to them.
+// Codec 12 responses carry no reference to the command, so commands for one device are sent one by one and the next command
+// is sent after the response to the previous one arrives or its SendCommand gives up. Commands for offline devices are queued
+// until the device connects (TCP) or sends a packet (UDP), a command which can not be written waits for the next connection too.
+// A command which can not be encoded fails right away and the next command is sent.
+// When SendCommand gives up on a command in flight, the device may still respond to it, so the next command is sent after
+// the late response, which is discarded, or after AbandonGrace. The zero value is ready to use and it is safe for concurrent use.
+type Dispatcher struct {
+	AbandonGrace time.Duration // AbandonGrace waits for the response to an abandoned command, zero means DefaultAbandonGrace
+
+	mu      sync.Mutex
+	devices map[string]*device
+}
+
+// transport delivers a command to a device, it is a TCP session or a UDP peer address
+type transport interface {
+	encodeCommand(command string) ([]byte, error)
+	write(packet []byte) error
+}
+
+// device holds queued commands of one device and the command waiting for a response
+type device struct {
+	transport transport
+	queue     []*command
+	inflight  *command
+}
+
+type command struct {
+	text      string
+	result    chan commandResult // buffered, receives at most one result
+	abandoned bool               // abandoned is true after SendCommand gave up, its response is discarded
+}
+
+type commandResult struct {
+	response string
+	err      error
+}
+
+// SendCommand sends the command to the device and returns its response. If the device is offline or busy by another command,
+// the command waits in the queue. SendCommand returns ctx.Err() when ctx expires before the response arrives.
+func (d *Dispatcher) SendCommand(ctx context.Context, imei string, text string) (string, error) {
+	c := &command{text: text, result: make(chan commandResult, 1)}
+
+	d.mu.Lock()
+	dev := d.device(imei)
+	dev.queue = append(dev.queue, c)
+	d.mu.Unlock()
+
+	d.dispatch(imei)
+
+	select {
+	case r := <-c.result:
+		return r.response, r.err
+	case <-ctx.Done():
+		d.abandon(imei, c)
+
+		// the response may have arrived meanwhile
+		select {
+		case r := <-c.result:
+			return r.response, r.err
+		default:
+		}
+		return "", ctx.Err()
+	}
+}
+
+// Pending returns number of commands for the device which are queued or waiting for a response
+func (d *Dispatcher) Pending(imei string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	dev, ok := d.devices[imei]
+	if !ok {
+		return 0
+	}
+	if dev.inflight != nil {
+		return len(dev.queue) + 1
+	}
+	return len(dev.queue)
+}
+
+// device returns the device, it creates one if needed, d.mu must be held
+func (d *Dispatcher) device(imei string) *device {
+	if d.devices == nil {
+		d.devices = make(map[string]*device)
+	}
+	dev, ok := d.devices[imei]
+	if !ok {
+		dev = &device{}
+		d.devices[imei] = dev
+	}
+	return dev
+}
+
+// forget removes the device without any state, d.mu must be held
+func (d *Dispatcher) forget(imei string, dev *device) {
+	if dev.transport == nil && dev.inflight == nil && len(dev.queue) == 0 {
+		delete(d.devices, imei)
+	}
+}
+
+// dispatch sends the next queued command if the device is online and has no command in flight
+func (d *Dispatcher) dispatch(imei string) {
+	d.mu.Lock()
+	dev, ok := d.devices[imei]
+	if !ok || dev.transport == nil || dev.inflight != nil || len(dev.queue) == 0 {
+		d.mu.Unlock()
+		return
+	}
+	c, t := dev.queue[0], dev.transport
+
+	// the command itself is wrong, it fails and the connection is kept for the next one
+	packet, err := t.encodeCommand(c.text)
+	if err != nil {
+		dev.queue = dev.queue[1:]
+		c.result <- commandResult{err: err}
+		d.forget(imei, dev)
+		d.mu.Unlock()
+
+		d.dispatch(imei)
+		return
+	}
+	dev.queue = dev.queue[1:]
+	dev.inflight = c
+	d.mu.Unlock()
+
+	// write outside the lock, a slow connection must not block other devices
+	if err := t.write(packet); err == nil {
+		return
+	}
+
+	// the connection is broken, the command waits at the head of the queue for the next one
+	d.mu.Lock()
+	if dev.inflight == c {
+		dev.inflight = nil
+		if !c.abandoned {
+			dev.queue = append([]*command{c}, dev.queue...)
+		}
+	}
+	if dev.transport == t {
+		dev.transport = nil
+	}
+	d.forget(imei, dev)
+	d.mu.Unlock()
+}
+
+// abandon removes the command whose SendCommand gave up, a command in flight keeps the device until its response
+// arrives or AbandonGrace passes, so a late response is not passed to the next command
+func (d *Dispatcher) abandon(imei string, c *command) {
+	d.mu.Lock()
+	dev, ok := d.devices[imei]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+
+	if dev.inflight == c {
+		c.abandoned = true
+		d.mu.Unlock()
+		time.AfterFunc(d.abandonGrace(), func() { d.release(imei, c) })
+		return
+	}
+	for i, queued := range dev.queue {
+		if queued == c {
+			dev.queue = append(dev.queue[:i], dev.queue[i+1:]...)
+			break
+		}
+	}
+	d.forget(imei, dev)
+	d.mu.Unlock()
+
+	d.dispatch(imei)
+}
+
+// release frees the device from the abandoned command if its response did not arrive
+func (d *Dispatcher) release(imei string, c *command) {
+	d.mu.Lock()
+	dev, ok := d.devices[imei]
+	if !ok || dev.inflight != c {
+		d.mu.Unlock()
+		return
+	}
+	dev.inflight = nil
+	d.forget(imei, dev)
+	d.mu.Unlock()
+
+	d.dispatch(imei)
+}
+
+// deliver passes the response to the command in flight, the response to an abandoned command is discarded,
+// it returns false if no command waits for a response
+func (d *Dispatcher) deliver(imei string, response string) bool {
+	d.mu.Lock()
+	dev, ok := d.devices[imei]
+	if !ok || dev.inflight == nil {
+		d.mu.Unlock()
+		return false
+	}
+	c := dev.inflight
+	dev.inflight = nil
+	if !c.abandoned {
+		c.result <- commandResult{response: response}
+	}
+	d.forget(imei, dev)
+	d.mu.Unlock()
+
+	d.dispatch(imei)
+	return true
+}
+
+// attach marks the device online by the transport and sends queued commands
+func (d *Dispatcher) attach(imei string, t transport) {
+	d.mu.Lock()
+	d.device(imei).transport = t
+	d.mu.Unlock()
+
+	d.dispatch(imei)
+}
+
+// detach marks the device offline unless it is already attached by another transport, the command in flight fails by ErrDeviceDisconnected
+func (d *Dispatcher) detach(imei string, t transport) {
+	d.mu.Lock()
+	dev, ok := d.devices[imei]
+	if !ok || dev.transport != t {
+		d.mu.Unlock()
+		return
+	}
+
+	dev.transport = nil
+	if dev.inflight != nil {
+		if !dev.inflight.abandoned {
+			dev.inflight.result <- commandResult{err: ErrDeviceDisconnected}
+		}
+		dev.inflight = nil
+	}
+	d.forget(imei, dev)
+	d.mu.Unlock()
+}
+
+func (d *Dispatcher) abandonGrace() time.Duration {
+	if d.AbandonGrace > 0 {
+		return d.AbandonGrace
+	}
+	return DefaultAbandonGrace
+}
+
+// encodeCommand returns the Codec 12 command packet for the TCP connection
+func (s *Session) encodeCommand(command string) ([]byte, error) {
+	return teltonikaparser.EncodeCommandRequest(command)
+}
+
+// udpPeer is the address the device sent its last UDP packet from
+type udpPeer struct {
+	conn     net.PacketConn
+	addr     net.Addr
+	imei     string
+	packetID *uint32 // shared counter of the UDPServer
+}
+
+// encodeCommand returns the Codec 12 command packet for the peer address
+func (p *udpPeer) encodeCommand(command string) ([]byte, error) {
+	id := atomic.AddUint32(p.packetID, 1)
+	return teltonikaparser.EncodeCommandRequestUDP(p.imei, uint16(id), byte(id), command)
+}
+
+// write sends the packet to the peer address
+func (p *udpPeer) write(packet []byte) error {
+	_, err := p.conn.WriteTo(packet, p.addr)
+	return err
+}
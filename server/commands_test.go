@@ -0,0 +1,348 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// acceptAll is a Handler which accepts every packet
+var acceptAll = HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error { return nil })
+
+// sendResult is the outcome of SendCommand run in a goroutine
+type sendResult struct {
+	response string
+	err      error
+}
+
+// sendAsync runs SendCommand in a goroutine
+func sendAsync(d *Dispatcher, imei string, command string, timeout time.Duration) chan sendResult {
+	result := make(chan sendResult, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		response, err := d.SendCommand(ctx, imei, command)
+		result <- sendResult{response, err}
+	}()
+	return result
+}
+
+// waitPending waits until the dispatcher holds n commands of the device
+func waitPending(t *testing.T, d *Dispatcher, imei string, n int) {
+	for i := 0; i < 200; i++ {
+		if d.Pending(imei) == n {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Expected %v pending commands, got %v", n, d.Pending(imei))
+}
+
+// readCommand reads a Codec 12 TCP command sent to the device
+func readCommand(t *testing.T, conn net.Conn) string {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		t.Fatalf("Failed to read command. %v", err)
+	}
+	packet := make([]byte, 8+binary.BigEndian.Uint32(header[4:])+4)
+	copy(packet, header)
+	if _, err := io.ReadFull(conn, packet[8:]); err != nil {
+		t.Fatalf("Failed to read command. %v", err)
+	}
+
+	request, err := teltonikaparser.DecodeCommandRequest(&packet)
+	if err != nil {
+		t.Fatalf("Failed to decode command. %v", err)
+	}
+	return string(request.Command)
+}
+
+// writeResponse sends a Codec 12 TCP response from the device
+func writeResponse(t *testing.T, conn net.Conn, response string) {
+	packet, _ := teltonikaparser.EncodeCommandResponse(response)
+
+	if _, err := conn.Write(packet); err != nil {
+		t.Fatalf("Failed to write response. %v", err)
+	}
+}
+
+func TestDispatcherTCP(t *testing.T) {
+	commands := &Dispatcher{}
+	addr, _ := startTCPServer(t, &TCPServer{Handler: acceptAll, Commands: commands})
+	conn, _ := dialAndLogin(t, addr, login)
+
+	result := sendAsync(commands, "352093085698206", "getver", 2*time.Second)
+
+	if command := readCommand(t, conn); command != "getver" {
+		t.Fatalf("Expected value: getver, Actual value: %v", command)
+	}
+	writeResponse(t, conn, "Ver:03.27.07")
+
+	r := <-result
+	if r.err != nil || r.response != "Ver:03.27.07" {
+		t.Errorf("Expected value: Ver:03.27.07, Actual value: %q, %v", r.response, r.err)
+	}
+
+	// AVL packets are still acknowledged on the same connection
+	packet, _ := hex.DecodeString(tcpPacket)
+	conn.Write(packet)
+	if ack := readAck(t, conn); !bytes.Equal(ack, []byte{0x00, 0x00, 0x00, 0x01}) {
+		t.Errorf("Expected value: 00000001, Actual value: %x", ack)
+	}
+}
+
+func TestDispatcherOfflineQueue(t *testing.T) {
+	commands := &Dispatcher{}
+	addr, _ := startTCPServer(t, &TCPServer{Handler: acceptAll, Commands: commands})
+
+	// both commands wait until the device connects
+	first := sendAsync(commands, "352093085698206", "getver", 2*time.Second)
+	waitPending(t, commands, "352093085698206", 1)
+	second := sendAsync(commands, "352093085698206", "getinfo", 2*time.Second)
+	waitPending(t, commands, "352093085698206", 2)
+
+	conn, _ := dialAndLogin(t, addr, login)
+
+	if command := readCommand(t, conn); command != "getver" {
+		t.Fatalf("Expected value: getver, Actual value: %v", command)
+	}
+
+	// the second command is not sent until the first one is answered
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if n, err := conn.Read(make([]byte, 1)); n != 0 || err == nil {
+		t.Fatalf("Expected no data before the response, got %v Bytes, %v", n, err)
+	}
+
+	writeResponse(t, conn, "Ver:03.27.07")
+	if command := readCommand(t, conn); command != "getinfo" {
+		t.Fatalf("Expected value: getinfo, Actual value: %v", command)
+	}
+	writeResponse(t, conn, "RTC:2019/7/22 7:53")
+
+	if r := <-first; r.response != "Ver:03.27.07" {
+		t.Errorf("Expected value: Ver:03.27.07, Actual value: %q, %v", r.response, r.err)
+	}
+	if r := <-second; r.response != "RTC:2019/7/22 7:53" {
+		t.Errorf("Expected value: RTC:2019/7/22 7:53, Actual value: %q, %v", r.response, r.err)
+	}
+	if pending := commands.Pending("352093085698206"); pending != 0 {
+		t.Errorf("Expected no pending commands, got %v", pending)
+	}
+}
+
+func TestDispatcherTimeout(t *testing.T) {
+	commands := &Dispatcher{AbandonGrace: 200 * time.Millisecond}
+	addr, _ := startTCPServer(t, &TCPServer{Handler: acceptAll, Commands: commands})
+	conn, _ := dialAndLogin(t, addr, login)
+
+	result := sendAsync(commands, "352093085698206", "getver", 100*time.Millisecond)
+	readCommand(t, conn)
+
+	if r := <-result; r.err != context.DeadlineExceeded {
+		t.Fatalf("Expected error: %v, Actual error: %v", context.DeadlineExceeded, r.err)
+	}
+
+	// the device is released for the next command after AbandonGrace without a response
+	result = sendAsync(commands, "352093085698206", "getinfo", 2*time.Second)
+	if command := readCommand(t, conn); command != "getinfo" {
+		t.Fatalf("Expected value: getinfo, Actual value: %v", command)
+	}
+	writeResponse(t, conn, "RTC:2019/7/22 7:53")
+
+	if r := <-result; r.response != "RTC:2019/7/22 7:53" {
+		t.Errorf("Expected value: RTC:2019/7/22 7:53, Actual value: %q, %v", r.response, r.err)
+	}
+}
+
+func TestDispatcherLateResponse(t *testing.T) {
+	commands := &Dispatcher{AbandonGrace: time.Minute}
+	addr, _ := startTCPServer(t, &TCPServer{Handler: acceptAll, Commands: commands})
+	conn, _ := dialAndLogin(t, addr, login)
+
+	result := sendAsync(commands, "352093085698206", "getver", 100*time.Millisecond)
+	readCommand(t, conn)
+	if r := <-result; r.err != context.DeadlineExceeded {
+		t.Fatalf("Expected error: %v, Actual error: %v", context.DeadlineExceeded, r.err)
+	}
+
+	// the next command waits for the response to the abandoned one
+	result = sendAsync(commands, "352093085698206", "getgps", 2*time.Second)
+	waitPending(t, commands, "352093085698206", 2)
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if n, err := conn.Read(make([]byte, 1)); n != 0 || err == nil {
+		t.Fatalf("Expected no data before the late response, got %v Bytes, %v", n, err)
+	}
+
+	// the late response to getver is discarded, it is not the response to getgps
+	writeResponse(t, conn, "Ver:03.27.07")
+	if command := readCommand(t, conn); command != "getgps" {
+		t.Fatalf("Expected value: getgps, Actual value: %v", command)
+	}
+	writeResponse(t, conn, "GPS:1 Sat:9")
+
+	if r := <-result; r.err != nil || r.response != "GPS:1 Sat:9" {
+		t.Errorf("Expected value: GPS:1 Sat:9, Actual value: %q, %v", r.response, r.err)
+	}
+}
+
+// brokenTransport fails to write every command
+type brokenTransport struct{}
+
+func (brokenTransport) encodeCommand(command string) ([]byte, error) {
+	return []byte(command), nil
+}
+
+func (brokenTransport) write(packet []byte) error {
+	return io.ErrClosedPipe
+}
+
+// recordingTransport passes written commands to the channel, the command "bad" can not be encoded
+type recordingTransport chan string
+
+func (r recordingTransport) encodeCommand(command string) ([]byte, error) {
+	if command == "bad" {
+		return nil, errBadCommand
+	}
+	return []byte(command), nil
+}
+
+func (r recordingTransport) write(packet []byte) error {
+	r <- string(packet)
+	return nil
+}
+
+var errBadCommand = errors.New("bad command")
+
+func TestDispatcherSendError(t *testing.T) {
+	commands := &Dispatcher{}
+	// the connection died before it was detached
+	commands.attach("352093085698206", brokenTransport{})
+
+	first := sendAsync(commands, "352093085698206", "getver", 2*time.Second)
+	waitPending(t, commands, "352093085698206", 1)
+	second := sendAsync(commands, "352093085698206", "getinfo", 2*time.Second)
+	waitPending(t, commands, "352093085698206", 2)
+
+	// commands stay queued in order for the next connection
+	sent := make(recordingTransport, 2)
+	commands.attach("352093085698206", sent)
+	if command := <-sent; command != "getver" {
+		t.Fatalf("Expected value: getver, Actual value: %v", command)
+	}
+	commands.deliver("352093085698206", "Ver:03.27.07")
+	if command := <-sent; command != "getinfo" {
+		t.Fatalf("Expected value: getinfo, Actual value: %v", command)
+	}
+	commands.deliver("352093085698206", "RTC:2019/7/22 7:53")
+
+	if r := <-first; r.err != nil || r.response != "Ver:03.27.07" {
+		t.Errorf("Expected value: Ver:03.27.07, Actual value: %q, %v", r.response, r.err)
+	}
+	if r := <-second; r.err != nil || r.response != "RTC:2019/7/22 7:53" {
+		t.Errorf("Expected value: RTC:2019/7/22 7:53, Actual value: %q, %v", r.response, r.err)
+	}
+}
+
+func TestDispatcherEncodeError(t *testing.T) {
+	commands := &Dispatcher{}
+	sent := make(recordingTransport, 2)
+	commands.attach("352093085698206", sent)
+
+	// the bad command fails at once, the connection stays and the next command is sent
+	if _, err := commands.SendCommand(context.Background(), "352093085698206", "bad"); !errors.Is(err, errBadCommand) {
+		t.Fatalf("Expected error: %v, Actual error: %v", errBadCommand, err)
+	}
+	result := sendAsync(commands, "352093085698206", "getver", 2*time.Second)
+	if command := <-sent; command != "getver" {
+		t.Fatalf("Expected value: getver, Actual value: %v", command)
+	}
+	commands.deliver("352093085698206", "Ver:03.27.07")
+	if r := <-result; r.err != nil || r.response != "Ver:03.27.07" {
+		t.Errorf("Expected value: Ver:03.27.07, Actual value: %q, %v", r.response, r.err)
+	}
+	if n := commands.Pending("352093085698206"); n != 0 {
+		t.Errorf("Expected no pending commands, got %v", n)
+	}
+}
+
+func TestDispatcherDisconnect(t *testing.T) {
+	commands := &Dispatcher{}
+	addr, _ := startTCPServer(t, &TCPServer{Handler: acceptAll, Commands: commands})
+	conn, _ := dialAndLogin(t, addr, login)
+
+	result := sendAsync(commands, "352093085698206", "cpureset", 2*time.Second)
+	readCommand(t, conn)
+	conn.Close()
+
+	if r := <-result; !errors.Is(r.err, ErrDeviceDisconnected) {
+		t.Errorf("Expected error: %v, Actual error: %v", ErrDeviceDisconnected, r.err)
+	}
+}
+
+func TestDispatcherUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen. %v", err)
+	}
+
+	commands := &Dispatcher{}
+	s := &UDPServer{Handler: acceptAll, Commands: commands, ErrorLog: log.New(io.Discard, "", 0)}
+	go s.Serve(context.Background(), conn)
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial. %v", err)
+	}
+	defer client.Close()
+
+	// the command waits until the device sends a packet
+	result := sendAsync(commands, "352093085698206", "getver", 2*time.Second)
+	waitPending(t, commands, "352093085698206", 1)
+
+	packet, _ := hex.DecodeString(udpPacket)
+	client.Write(packet)
+
+	buf := make([]byte, 1024)
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := client.Read(buf)
+	if err != nil || n != 7 {
+		t.Fatalf("Expected acknowledgement, got %x, %v", buf[:n], err)
+	}
+
+	n, err = client.Read(buf)
+	if err != nil {
+		t.Fatalf("Failed to read command. %v", err)
+	}
+	imei := []byte("352093085698206")
+	if !bytes.Equal(buf[8:8+len(imei)], imei) || !bytes.HasSuffix(buf[:n], []byte("getver\x01")) {
+		t.Fatalf("Unexpected command %x", buf[:n])
+	}
+
+	// the response repeats the channel header of the command
+	text := []byte("Ver:03.27.07")
+	response := append([]byte{}, buf[:8+len(imei)]...)
+	response = append(response, 0x0c, 0x01, 0x06, 0x00, 0x00, 0x00, byte(len(text)))
+	response = append(response, text...)
+	response = append(response, 0x01)
+	binary.BigEndian.PutUint16(response, uint16(len(response)-2))
+	client.Write(response)
+
+	if r := <-result; r.err != nil || r.response != "Ver:03.27.07" {
+		t.Errorf("Expected value: Ver:03.27.07, Actual value: %q, %v", r.response, r.err)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// DefaultDedupSize is used by Deduplicator when Size is not set
+const DefaultDedupSize = 32
+
+// DefaultDedupTTL is used by Deduplicator when TTL is not set
+const DefaultDedupTTL = 10 * time.Minute
+
+// Deduplicator recognises UDP packets retransmitted by a device whose acknowledgement was lost. Every device has its own
+// least recently used list of packets identified by the packet ID, AVL packet ID and a hash of the content,
+// packets older than TTL are forgotten because the 1 Byte AVL packet ID wraps around. A packet is claimed before it is handled,
+// so a retransmission which comes meanwhile is not handled twice. The zero value is ready to use and it is safe for concurrent use.
+type Deduplicator struct {
+	Size int           // Size limits number of remembered packets per device, zero means DefaultDedupSize
+	TTL  time.Duration // TTL is how long a packet is remembered, zero means DefaultDedupTTL
+
+	mu        sync.Mutex
+	devices   map[string][]dedupEntry // ordered from the least recently used
+	inFlight  map[dedupKey]bool       // claimed packets which are not remembered or forgotten yet
+	lastSweep time.Time
+	now       func() time.Time // time source, replaced in tests
+}
+
+type dedupKey struct {
+	imei        string
+	packetID    uint16
+	avlPacketID byte
+	hash        uint64
+}
+
+type dedupEntry struct {
+	packetID    uint16
+	avlPacketID byte
+	hash        uint64
+	seen        time.Time
+}
+
+// Duplicate returns true if the device already sent the same packet and it was remembered in the last TTL
+func (d *Deduplicator) Duplicate(decoded *teltonikaparser.Decoded, packet []byte) bool {
+	hash := hashPacket(packet)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.duplicate(decoded, hash)
+}
+
+// Claim returns true if the packet is neither remembered nor claimed by another handler, then it is claimed until Remember or Forget
+func (d *Deduplicator) Claim(decoded *teltonikaparser.Decoded, packet []byte) bool {
+	key := dedupKey{imei: decoded.IMEI, packetID: decoded.PacketID, avlPacketID: decoded.AVLPacketID, hash: hashPacket(packet)}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.inFlight[key] || d.duplicate(decoded, key.hash) {
+		return false
+	}
+	if d.inFlight == nil {
+		d.inFlight = make(map[dedupKey]bool)
+	}
+	d.inFlight[key] = true
+	return true
+}
+
+// Forget releases the claimed packet without remembering it, call it when the delivery failed, so the retransmission is handled again
+func (d *Deduplicator) Forget(decoded *teltonikaparser.Decoded, packet []byte) {
+	key := dedupKey{imei: decoded.IMEI, packetID: decoded.PacketID, avlPacketID: decoded.AVLPacketID, hash: hashPacket(packet)}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.inFlight, key)
+}
+
+// duplicate returns true if the packet is remembered and moves it to the most recently used position, d.mu must be held
+func (d *Deduplicator) duplicate(decoded *teltonikaparser.Decoded, hash uint64) bool {
+	now := d.clock()
+	entries := d.devices[decoded.IMEI]
+	for i, entry := range entries {
+		if entry.packetID != decoded.PacketID || entry.avlPacketID != decoded.AVLPacketID {
+			continue
+		}
+		if entry.hash != hash || now.Sub(entry.seen) > d.ttl() {
+			return false
+		}
+
+		// move to the most recently used position
+		copy(entries[i:], entries[i+1:])
+		entries[len(entries)-1] = entry
+		return true
+	}
+	return false
+}
+
+// Remember stores the packet and releases its claim, call it after the packet was delivered,
+// so a failed delivery is retried when the device retransmits
+func (d *Deduplicator) Remember(decoded *teltonikaparser.Decoded, packet []byte) {
+	entry := dedupEntry{packetID: decoded.PacketID, avlPacketID: decoded.AVLPacketID, hash: hashPacket(packet)}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.inFlight, dedupKey{imei: decoded.IMEI, packetID: entry.packetID, avlPacketID: entry.avlPacketID, hash: entry.hash})
+
+	now := d.clock()
+	entry.seen = now
+	d.sweep(now)
+
+	if d.devices == nil {
+		d.devices = make(map[string][]dedupEntry)
+	}
+
+	// replace the older packet with the same IDs
+	entries := d.devices[decoded.IMEI]
+	for i, old := range entries {
+		if old.packetID == entry.packetID && old.avlPacketID == entry.avlPacketID {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+
+	entries = append(entries, entry)
+	if len(entries) > d.size() {
+		entries = entries[len(entries)-d.size():]
+	}
+	d.devices[decoded.IMEI] = entries
+}
+
+// sweep forgets devices without any packet in the last TTL, it runs at most once per TTL, d.mu must be held
+func (d *Deduplicator) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < d.ttl() {
+		return
+	}
+	d.lastSweep = now
+
+	for imei, entries := range d.devices {
+		newest := time.Time{}
+		for _, entry := range entries {
+			if entry.seen.After(newest) {
+				newest = entry.seen
+			}
+		}
+		if now.Sub(newest) > d.ttl() {
+			delete(d.devices, imei)
+		}
+	}
+}
+
+func (d *Deduplicator) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
+
+func (d *Deduplicator) size() int {
+	if d.Size > 0 {
+		return d.Size
+	}
+	return DefaultDedupSize
+}
+
+func (d *Deduplicator) ttl() time.Duration {
+	if d.TTL > 0 {
+		return d.TTL
+	}
+	return DefaultDedupTTL
+}
+
+// hashPacket returns FNV-1a hash of the packet
+func hashPacket(packet []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(packet)
+	return h.Sum64()
+}
@@ -0,0 +1,256 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+func TestDeduplicator(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	packet := []byte{0x01, 0x02, 0x03}
+	first := &teltonikaparser.Decoded{IMEI: "352093085698206", PacketID: 0xcafe, AVLPacketID: 0x01}
+
+	testCases := []struct {
+		Name     string
+		Decoded  *teltonikaparser.Decoded
+		Packet   []byte
+		Elapsed  time.Duration
+		Expected bool
+	}{
+		{Name: "Retransmission", Decoded: first, Packet: packet, Expected: true},
+		// the same content hash, only the key differs
+		{Name: "OtherPacketID", Decoded: &teltonikaparser.Decoded{IMEI: "352093085698206", PacketID: 0x0007, AVLPacketID: 0x01}, Packet: packet},
+		{Name: "OtherAVLPacketID", Decoded: &teltonikaparser.Decoded{IMEI: "352093085698206", PacketID: 0xcafe, AVLPacketID: 0x02}, Packet: packet},
+		{Name: "OtherDevice", Decoded: &teltonikaparser.Decoded{IMEI: "356307042441013", PacketID: 0xcafe, AVLPacketID: 0x01}, Packet: packet},
+		{Name: "OtherContent", Decoded: first, Packet: []byte{0x01, 0x02, 0x04}},
+		{Name: "Expired", Decoded: first, Packet: packet, Elapsed: 2 * time.Minute},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			clock := now
+			d := &Deduplicator{TTL: time.Minute, now: func() time.Time { return clock }}
+			d.Remember(first, packet)
+
+			clock = clock.Add(testCase.Elapsed)
+			if actual := d.Duplicate(testCase.Decoded, testCase.Packet); actual != testCase.Expected {
+				test.Logf("Expected value: %v, Actual value: %v", testCase.Expected, actual)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestDeduplicatorEviction(t *testing.T) {
+	d := &Deduplicator{Size: 2}
+	packet := []byte{0x01}
+	decoded := func(id byte) *teltonikaparser.Decoded {
+		return &teltonikaparser.Decoded{IMEI: "352093085698206", PacketID: 0xcafe, AVLPacketID: id}
+	}
+
+	d.Remember(decoded(1), packet)
+	d.Remember(decoded(2), packet)
+	// 1 is used, so 2 is the least recently used
+	d.Duplicate(decoded(1), packet)
+	d.Remember(decoded(3), packet)
+
+	for id, expected := range map[byte]bool{1: true, 2: false, 3: true} {
+		if actual := d.Duplicate(decoded(id), packet); actual != expected {
+			t.Errorf("AVL packet ID %v, expected value: %v, Actual value: %v", id, expected, actual)
+		}
+	}
+}
+
+func TestDeduplicatorClaim(t *testing.T) {
+	d := &Deduplicator{}
+	packet := []byte{0x01}
+	decoded := &teltonikaparser.Decoded{IMEI: "352093085698206", PacketID: 0xcafe, AVLPacketID: 1}
+
+	if !d.Claim(decoded, packet) {
+		t.Fatal("The first packet is not claimed")
+	}
+	// the retransmission comes while the first one is handled
+	if d.Claim(decoded, packet) || d.Duplicate(decoded, packet) {
+		t.Error("The packet in flight is claimed again or taken for a delivered one")
+	}
+
+	// the delivery failed, the retransmission is handled
+	d.Forget(decoded, packet)
+	if !d.Claim(decoded, packet) {
+		t.Fatal("The forgotten packet is not claimed")
+	}
+
+	// the delivery succeeded, the retransmission is only acknowledged
+	d.Remember(decoded, packet)
+	if d.Claim(decoded, packet) || !d.Duplicate(decoded, packet) {
+		t.Error("The remembered packet is claimed again or it is not a duplicate")
+	}
+}
+
+func TestDeduplicatorPacketID(t *testing.T) {
+	// two datagrams which differ only in the packet ID
+	first, _ := hex.DecodeString(udpPacket)
+	second, _ := hex.DecodeString(udpPacket[:4] + "0007" + udpPacket[8:])
+
+	decodedFirst, err := teltonikaparser.Decode(&first)
+	if err != nil {
+		t.Fatalf("Failed to decode packet. %v", err)
+	}
+	decodedSecond, err := teltonikaparser.Decode(&second)
+	if err != nil {
+		t.Fatalf("Failed to decode packet. %v", err)
+	}
+	if decodedFirst.PacketID == decodedSecond.PacketID || decodedFirst.AVLPacketID != decodedSecond.AVLPacketID {
+		t.Fatalf("Expected different packet IDs and the same AVL packet ID, got %+v and %+v", decodedFirst, decodedSecond)
+	}
+
+	d := &Deduplicator{}
+	d.Remember(&decodedFirst, first)
+	// the hash is taken from the first packet, so only the packet ID of the key tells them apart
+	if d.Duplicate(&decodedSecond, first) {
+		t.Errorf("Packet with packet ID %#x is a duplicate of %#x", decodedSecond.PacketID, decodedFirst.PacketID)
+	}
+	if d.Duplicate(&decodedSecond, second) {
+		t.Errorf("Packet with packet ID %#x is a duplicate", decodedSecond.PacketID)
+	}
+	if !d.Duplicate(&decodedFirst, first) {
+		t.Errorf("Retransmission with packet ID %#x is not a duplicate", decodedFirst.PacketID)
+	}
+}
+
+func TestUDPServerDedup(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen. %v", err)
+	}
+
+	var handled int32
+	var fail int32 = 1
+	s := &UDPServer{
+		Handler: HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+			if atomic.CompareAndSwapInt32(&fail, 1, 0) {
+				return io.ErrUnexpectedEOF
+			}
+			atomic.AddInt32(&handled, 1)
+			return nil
+		}),
+		Dedup:    &Deduplicator{},
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go s.Serve(context.Background(), conn)
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial. %v", err)
+	}
+	defer client.Close()
+
+	packet, _ := hex.DecodeString(udpPacket)
+	expectedAck := []byte{0x00, 0x05, 0xca, 0xfe, 0x01, 0x01, 0x01}
+	buf := make([]byte, 64)
+
+	// the failed packet is not acknowledged and not remembered
+	client.Write(packet)
+	client.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if n, err := client.Read(buf); err == nil {
+		t.Fatalf("Expected no acknowledgement, got %x", buf[:n])
+	}
+
+	// the retransmission is handled and acknowledged, then every next one is only acknowledged
+	for i := 0; i < 3; i++ {
+		client.Write(packet)
+		client.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, err := client.Read(buf)
+		if err != nil || !bytes.Equal(buf[:n], expectedAck) {
+			t.Fatalf("Expected value: %x, Actual value: %x, %v", expectedAck, buf[:n], err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&handled); n != 1 {
+		t.Errorf("Expected the packet to be handled once, got %v", n)
+	}
+}
+
+func TestUDPServerConcurrentRetransmission(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen. %v", err)
+	}
+
+	var handled int32
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	s := &UDPServer{
+		Handler: HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+			atomic.AddInt32(&handled, 1)
+			select {
+			case entered <- struct{}{}:
+			default:
+			}
+			<-release
+			return nil
+		}),
+		Dedup:    &Deduplicator{},
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go s.Serve(context.Background(), conn)
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	// handlers are released before the shutdown waits for them, also when the test fails
+	var releaseOnce sync.Once
+	t.Cleanup(func() { releaseOnce.Do(func() { close(release) }) })
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial. %v", err)
+	}
+	defer client.Close()
+
+	packet, _ := hex.DecodeString(udpPacket)
+	expectedAck := []byte{0x00, 0x05, 0xca, 0xfe, 0x01, 0x01, 0x01}
+	buf := make([]byte, 64)
+
+	client.Write(packet)
+	select {
+	case <-entered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the handler")
+	}
+
+	// retransmissions while the handler is slow are neither handled nor acknowledged
+	client.Write(packet)
+	client.Write(packet)
+	client.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if n, err := client.Read(buf); err == nil {
+		t.Fatalf("Expected no acknowledgement, got %x", buf[:n])
+	}
+
+	releaseOnce.Do(func() { close(release) })
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if n, err := client.Read(buf); err != nil || !bytes.Equal(buf[:n], expectedAck) {
+		t.Fatalf("Expected value: %x, Actual value: %x, %v", expectedAck, buf[:n], err)
+	}
+
+	// a later retransmission is only acknowledged
+	client.Write(packet)
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if n, err := client.Read(buf); err != nil || !bytes.Equal(buf[:n], expectedAck) {
+		t.Fatalf("Expected value: %x, Actual value: %x, %v", expectedAck, buf[:n], err)
+	}
+	if n := atomic.LoadInt32(&handled); n != 1 {
+		t.Errorf("Expected the packet to be handled once, got %v", n)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package server implements UDP and TCP ingestion servers for Teltonika devices, it decodes incoming AVL packets by teltonikaparser.Decode
+// or teltonikaparser.DecodeTCP, passes them to a Handler and acknowledges them to the device only if the Handler succeeds
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// ErrServerClosed is returned by Serve after a call to Shutdown
+var ErrServerClosed = errors.New("server: Server closed")
+
+// Handler processes decoded AVL packets, a device gets an acknowledgement only if Handle returns nil, otherwise the device keeps the records and sends them again.
+// A Handler which stored only a part of the records returns *AcceptError.
+type Handler interface {
+	Handle(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error
+}
+
+// AcceptError is returned by a Handler which stored only the first Accepted records, the device gets an acknowledgement
+// of Accepted records and sends the rest again
+type AcceptError struct {
+	Accepted uint8 // Number of records from the beginning of the packet which were stored
+	Err      error // Reason why the rest was not stored
+}
+
+func (e *AcceptError) Error() string {
+	return fmt.Sprintf("server: accepted %v records, %v", e.Accepted, e.Err)
+}
+
+// Unwrap returns the reason why the rest was not stored
+func (e *AcceptError) Unwrap() error {
+	return e.Err
+}
+
+// acceptedRecords returns number of records to acknowledge after the Handler returned err
+func acceptedRecords(decoded *teltonikaparser.Decoded, err error) uint8 {
+	var acceptErr *AcceptError
+	if err == nil {
+		return decoded.NoOfData
+	}
+	if errors.As(err, &acceptErr) && acceptErr.Accepted < decoded.NoOfData {
+		return acceptErr.Accepted
+	}
+	return 0
+}
+
+// HandlerFunc type is an adapter to allow the use of ordinary functions as a Handler
+type HandlerFunc func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error
+
+// Handle calls f(ctx, addr, decoded)
+func (f HandlerFunc) Handle(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+	return f(ctx, addr, decoded)
+}
+
+// logf logs by the logger or by the standard logger of the log package if the logger is nil
+func logf(logger *log.Logger, format string, args ...interface{}) {
+	if logger != nil {
+		logger.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
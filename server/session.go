@@ -0,0 +1,39 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// Session represent one TCP connection of a device, it lives from the IMEI login until the connection is closed
+type Session struct {
+	IMEI       string    // IMEI sent by the device when it connected
+	RemoteAddr net.Addr  // Remote address of the device
+	Started    time.Time // Time of the IMEI login
+
+	conn    net.Conn
+	writeMu sync.Mutex // serialises acknowledgements and commands written to conn
+}
+
+// write sends b to the device, it is safe to call from multiple goroutines
+func (s *Session) write(b []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_, err := s.conn.Write(b)
+	return err
+}
+
+type sessionKey struct{}
+
+// SessionFromContext returns the TCP session of the device, it is available in the context passed to Handler by TCPServer
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(sessionKey{}).(*Session)
+	return s, ok
+}
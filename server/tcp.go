@@ -0,0 +1,405 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/filipkroca/b2n"
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// DefaultMaxPacketSize is used by TCPServer when MaxPacketSize is not set
+const DefaultMaxPacketSize = 64 * 1024
+
+// TCPServer receives Codec 8 and Codec 8 Extended TCP packets. Every connection starts by the IMEI login,
+// then AVL packets are framed, checked by CRC, passed to the Handler and acknowledged by the number of accepted records.
+type TCPServer struct {
+	Handler        Handler                // Handler to invoke for every decoded packet, the context carries the Session
+	Authenticate   func(imei string) bool // Authenticate decides if the device is accepted, nil accepts every valid IMEI
+	IdleTimeout    time.Duration          // IdleTimeout closes a connection without any data for this duration, zero means no timeout
+	MaxConnections int                    // MaxConnections limits concurrent connections, extra ones are closed immediately, zero means no limit
+	MaxPacketSize  int                    // MaxPacketSize limits the data field length of a packet, zero means DefaultMaxPacketSize
+	Commands       *Dispatcher            // Commands sends Codec 12 commands over live sessions, nil means commands are not supported
+	ErrorLog       *log.Logger            // ErrorLog for rejected connections, undecodable packets and failed handlers, nil means the standard logger of the log package
+
+	mu       sync.Mutex
+	listener net.Listener
+	closing  bool
+	conns    map[net.Conn]struct{}
+	sessions map[string]*Session
+	wg       sync.WaitGroup
+	cancel   context.CancelFunc // cancels contexts of running handlers
+	done     chan struct{}      // closed when Serve returned
+}
+
+// ListenAndServe listens on the TCP network address addr and then calls Serve
+func (s *TCPServer) ListenAndServe(ctx context.Context, addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, l)
+}
+
+// Serve accepts connections on l until ctx is canceled or Shutdown is called, the listener is closed on return.
+// Running handlers are waited for, so their acknowledgements are still sent. Serve returns ctx.Err() or ErrServerClosed.
+func (s *TCPServer) Serve(ctx context.Context, l net.Listener) error {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	handlerCtx, cancel := context.WithCancel(context.Background())
+	s.listener = l
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	done := s.done
+	s.mu.Unlock()
+
+	defer close(done)
+	defer cancel()
+
+	// stop accepting when ctx is canceled
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.stop()
+		case <-done:
+		}
+	}()
+
+	var err error
+	for {
+		var conn net.Conn
+		conn, err = l.Accept()
+		if err != nil {
+			break
+		}
+
+		if !s.trackConn(conn) {
+			logf(s.ErrorLog, "server: connection limit %v reached, closing connection from %v", s.MaxConnections, conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.untrackConn(conn)
+			s.serveConn(handlerCtx, conn)
+		}()
+	}
+
+	if !s.isClosing() {
+		// the listener failed, close connections and report the error
+		s.stop()
+		s.closeConns()
+		s.wg.Wait()
+		return err
+	}
+
+	s.wg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return ErrServerClosed
+}
+
+// Shutdown stops accepting of new connections, unblocks idle connections and waits until running handlers send their acknowledgements and Serve returns.
+// If ctx expires first, connections are closed, contexts of running handlers are canceled and Shutdown returns ctx.Err().
+func (s *TCPServer) Shutdown(ctx context.Context) error {
+	s.stop()
+
+	s.mu.Lock()
+	done, cancel := s.done, s.cancel
+	s.mu.Unlock()
+
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		s.closeConns()
+		return ctx.Err()
+	}
+}
+
+// Session returns the live session of the device, the latest one if the device connected more times
+func (s *TCPServer) Session(imei string) (*Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[imei]
+	return session, ok
+}
+
+// Sessions returns all live sessions
+func (s *TCPServer) Sessions() []*Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := make([]*Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// serveConn runs the IMEI login and then reads packets until the connection is closed
+func (s *TCPServer) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			logf(s.ErrorLog, "server: panic when serving connection from %v, %v", conn.RemoteAddr(), r)
+		}
+	}()
+
+	reader := bufio.NewReader(conn)
+
+	if !s.setReadDeadline(conn) {
+		return
+	}
+	imei, err := readIMEI(reader)
+	if err != nil {
+		if s.isClosing() {
+			return
+		}
+		logf(s.ErrorLog, "server: login from %v failed, %v", conn.RemoteAddr(), err)
+		conn.Write([]byte{0x00})
+		return
+	}
+	if s.Authenticate != nil && !s.Authenticate(imei) {
+		logf(s.ErrorLog, "server: device %v from %v is not authenticated", imei, conn.RemoteAddr())
+		conn.Write([]byte{0x00})
+		return
+	}
+
+	session := &Session{IMEI: imei, RemoteAddr: conn.RemoteAddr(), Started: time.Now(), conn: conn}
+	if err := session.write([]byte{0x01}); err != nil {
+		return
+	}
+
+	s.addSession(session)
+	defer s.removeSession(session)
+
+	if s.Commands != nil {
+		s.Commands.attach(imei, session)
+		defer s.Commands.detach(imei, session)
+	}
+
+	ctx = context.WithValue(ctx, sessionKey{}, session)
+
+	for {
+		if !s.setReadDeadline(conn) {
+			return
+		}
+
+		packet, err := readPacket(reader, s.maxPacketSize())
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !s.isClosing() {
+				logf(s.ErrorLog, "server: connection of %v from %v closed, %v", imei, conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		if packet[8] == teltonikaparser.CodecID {
+			s.handleCommandResponse(session, packet)
+			continue
+		}
+
+		if err := s.handlePacket(ctx, session, packet); err != nil {
+			logf(s.ErrorLog, "server: connection of %v from %v closed, %v", imei, conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
+
+// handlePacket decodes one AVL packet, passes it to the Handler and writes the acknowledgement
+func (s *TCPServer) handlePacket(ctx context.Context, session *Session, packet []byte) error {
+	decoded, err := teltonikaparser.DecodeTCP(&packet)
+	if err != nil {
+		// the stream is still framed correctly, the device sends the packet again when it gets no acknowledgement
+		logf(s.ErrorLog, "server: unable to decode packet from %v, %v", session.IMEI, err)
+		return nil
+	}
+	decoded.IMEI = session.IMEI
+
+	err = s.Handler.Handle(ctx, session.RemoteAddr, &decoded)
+	accepted := acceptedRecords(&decoded, err)
+	if err != nil {
+		logf(s.ErrorLog, "server: handler failed for %v from %v, %v of %v records are acknowledged, %v", session.IMEI, session.RemoteAddr, accepted, decoded.NoOfData, err)
+	}
+
+	response, err := teltonikaparser.EncodeTCPResponse(&decoded, accepted)
+	if err != nil {
+		return err
+	}
+	return session.write(response)
+}
+
+// handleCommandResponse decodes a Codec 12 response and passes it to the command waiting in Commands
+func (s *TCPServer) handleCommandResponse(session *Session, packet []byte) {
+	response, err := teltonikaparser.DecodeCommandResponse(&packet)
+	if err != nil {
+		logf(s.ErrorLog, "server: unable to decode command response from %v, %v", session.IMEI, err)
+		return
+	}
+
+	if s.Commands == nil || !s.Commands.deliver(session.IMEI, string(response.Response)) {
+		logf(s.ErrorLog, "server: unexpected command response from %v, %q", session.IMEI, response.Response)
+	}
+}
+
+// readIMEI reads the login packet, 2 Bytes of IMEI length followed by IMEI digits
+func readIMEI(r io.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+	if length != 15 && length != 16 {
+		return "", fmt.Errorf("want IMEI length 15 or 16, got %v", length)
+	}
+
+	imei := make([]byte, length)
+	if _, err := io.ReadFull(r, imei); err != nil {
+		return "", err
+	}
+
+	return b2n.ParseIMEI(&imei, 0, int(length))
+}
+
+// readPacket reads one packet framed by 4 zero Bytes preamble, 4 Bytes data field length and 4 Bytes CRC
+func readPacket(r io.Reader, maxPacketSize int) ([]byte, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	if preamble := binary.BigEndian.Uint32(header[:4]); preamble != 0 {
+		return nil, fmt.Errorf("invalid preamble %#08x", preamble)
+	}
+
+	dataLen := binary.BigEndian.Uint32(header[4:])
+	if dataLen == 0 || int64(dataLen) > int64(maxPacketSize) {
+		return nil, fmt.Errorf("invalid data field length %v, maximum is %v", dataLen, maxPacketSize)
+	}
+
+	packet := make([]byte, 8+int(dataLen)+4)
+	copy(packet, header)
+	if _, err := io.ReadFull(r, packet[8:]); err != nil {
+		return nil, err
+	}
+
+	return packet, nil
+}
+
+func (s *TCPServer) maxPacketSize() int {
+	if s.MaxPacketSize > 0 {
+		return s.MaxPacketSize
+	}
+	return DefaultMaxPacketSize
+}
+
+// setReadDeadline arms the idle timeout, it returns false if the server is shutting down
+func (s *TCPServer) setReadDeadline(conn net.Conn) bool {
+	deadline := time.Time{}
+	if s.IdleTimeout > 0 {
+		deadline = time.Now().Add(s.IdleTimeout)
+	}
+	conn.SetReadDeadline(deadline)
+
+	// stop may have set the deadline to the past just before, check after arming
+	return !s.isClosing()
+}
+
+// stop closes the listener and unblocks reads of all connections, writes still work so running handlers can acknowledge
+func (s *TCPServer) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closing {
+		return
+	}
+	s.closing = true
+
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.SetReadDeadline(time.Now())
+	}
+}
+
+func (s *TCPServer) closeConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *TCPServer) isClosing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closing
+}
+
+// trackConn registers the connection, it returns false if the connection limit is reached
+func (s *TCPServer) trackConn(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]struct{})
+	}
+	if s.MaxConnections > 0 && len(s.conns) >= s.MaxConnections {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *TCPServer) untrackConn(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.conns, conn)
+}
+
+func (s *TCPServer) addSession(session *Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sessions == nil {
+		s.sessions = make(map[string]*Session)
+	}
+	s.sessions[session.IMEI] = session
+}
+
+// removeSession forgets the session unless the device already has a newer one
+func (s *TCPServer) removeSession(session *Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sessions[session.IMEI] == session {
+		delete(s.sessions, session.IMEI)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// tcpPacket is Codec8 TCP packet with one record
+const tcpPacket = `000000000000003608010000016B40D8EA30010000000000000000000000000000000105021503010101425E0F01F10000601A014E0000000000000000010000C7CF`
+
+// login is the IMEI login packet of 352093085698206
+var login = append([]byte{0x00, 0x0f}, []byte("352093085698206")...)
+
+// startTCPServer runs the TCPServer on a loopback socket and returns its address
+func startTCPServer(t *testing.T, s *TCPServer) (string, chan error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen. %v", err)
+	}
+
+	if s.ErrorLog == nil {
+		s.ErrorLog = log.New(io.Discard, "", 0)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(context.Background(), l)
+	}()
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+
+	return l.Addr().String(), served
+}
+
+// dialAndLogin connects to the server and sends the IMEI login, it returns the login reply
+func dialAndLogin(t *testing.T, addr string, login []byte) (net.Conn, byte) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial. %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if _, err := conn.Write(login); err != nil {
+		t.Fatalf("Failed to send login. %v", err)
+	}
+
+	reply := make([]byte, 1)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		t.Fatalf("Failed to read login reply. %v", err)
+	}
+	return conn, reply[0]
+}
+
+// readAck reads the 4 Bytes acknowledgement
+func readAck(t *testing.T, conn net.Conn) []byte {
+	ack := make([]byte, 4)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		t.Fatalf("Failed to read acknowledgement. %v", err)
+	}
+	return ack
+}
+
+func TestTCPServerAcknowledge(t *testing.T) {
+	received := make(chan teltonikaparser.Decoded, 1)
+	sessions := make(chan *Session, 1)
+
+	s := &TCPServer{Handler: HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+		session, _ := SessionFromContext(ctx)
+		sessions <- session
+		received <- *decoded
+		return nil
+	})}
+	addr, _ := startTCPServer(t, s)
+
+	conn, reply := dialAndLogin(t, addr, login)
+	if reply != 0x01 {
+		t.Fatalf("Expected login accepted, got %#x", reply)
+	}
+
+	packet, _ := hex.DecodeString(tcpPacket)
+	// send the packet in two parts to check framing
+	conn.Write(packet[:20])
+	time.Sleep(10 * time.Millisecond)
+	conn.Write(packet[20:])
+
+	if ack := readAck(t, conn); !bytes.Equal(ack, []byte{0x00, 0x00, 0x00, 0x01}) {
+		t.Errorf("Expected value: 00000001, Actual value: %x", ack)
+	}
+
+	decoded := <-received
+	if decoded.IMEI != "352093085698206" || decoded.NoOfData != 1 {
+		t.Errorf("Unexpected decoded packet %+v", decoded)
+	}
+
+	session := <-sessions
+	if session == nil || session.IMEI != "352093085698206" {
+		t.Fatalf("Expected session of 352093085698206 in the handler context, got %+v", session)
+	}
+	if live, ok := s.Session("352093085698206"); !ok || live != session {
+		t.Errorf("Expected live session of 352093085698206")
+	}
+
+	conn.Close()
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := s.Session("352093085698206"); ok {
+		t.Errorf("Expected session to be removed after the connection was closed")
+	}
+}
+
+func TestTCPServerLogin(t *testing.T) {
+	s := &TCPServer{
+		Handler:      HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error { return nil }),
+		Authenticate: func(imei string) bool { return imei != "356307042441013" },
+	}
+	addr, _ := startTCPServer(t, s)
+
+	testCases := []struct {
+		Name          string
+		Login         []byte
+		ExpectedReply byte
+	}{
+		{Name: "Valid", Login: login, ExpectedReply: 0x01},
+		{Name: "InvalidChecksum", Login: append([]byte{0x00, 0x0f}, []byte("352093085698207")...), ExpectedReply: 0x00},
+		{Name: "InvalidLength", Login: append([]byte{0x00, 0x05}, []byte("35209")...), ExpectedReply: 0x00},
+		{Name: "NotAuthenticated", Login: append([]byte{0x00, 0x0f}, []byte("356307042441013")...), ExpectedReply: 0x00},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			_, reply := dialAndLogin(test, addr, testCase.Login)
+			if reply != testCase.ExpectedReply {
+				test.Errorf("Expected value: %#x, Actual value: %#x", testCase.ExpectedReply, reply)
+			}
+		})
+	}
+}
+
+func TestTCPServerHandlerFailure(t *testing.T) {
+	s := &TCPServer{Handler: HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+		return errors.New("storage is down")
+	})}
+	addr, _ := startTCPServer(t, s)
+
+	conn, _ := dialAndLogin(t, addr, login)
+
+	packet, _ := hex.DecodeString(tcpPacket)
+	conn.Write(packet)
+
+	if ack := readAck(t, conn); !bytes.Equal(ack, []byte{0x00, 0x00, 0x00, 0x00}) {
+		t.Errorf("Expected value: 00000000, Actual value: %x", ack)
+	}
+}
+
+func TestTCPServerIdleTimeout(t *testing.T) {
+	s := &TCPServer{
+		Handler:     HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error { return nil }),
+		IdleTimeout: 100 * time.Millisecond,
+	}
+	addr, _ := startTCPServer(t, s)
+
+	conn, _ := dialAndLogin(t, addr, login)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected the server to close an idle connection, got %v", err)
+	}
+}
+
+func TestTCPServerMaxConnections(t *testing.T) {
+	s := &TCPServer{
+		Handler:        HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error { return nil }),
+		MaxConnections: 1,
+	}
+	addr, _ := startTCPServer(t, s)
+
+	dialAndLogin(t, addr, login)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial. %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected the server to close a connection over the limit, got %v", err)
+	}
+}
+
+func TestTCPServerShutdown(t *testing.T) {
+	handling := make(chan struct{})
+	release := make(chan struct{})
+
+	s := &TCPServer{Handler: HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+		close(handling)
+		<-release
+		return nil
+	})}
+	addr, served := startTCPServer(t, s)
+
+	conn, _ := dialAndLogin(t, addr, login)
+	idle, _ := dialAndLogin(t, addr, login)
+
+	packet, _ := hex.DecodeString(tcpPacket)
+	conn.Write(packet)
+	<-handling
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- s.Shutdown(context.Background())
+	}()
+
+	// idle connection is closed, running handler is waited for
+	idle.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := idle.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected the idle connection to be closed, got %v", err)
+	}
+
+	close(release)
+
+	if ack := readAck(t, conn); !bytes.Equal(ack, []byte{0x00, 0x00, 0x00, 0x01}) {
+		t.Errorf("Expected value: 00000001, Actual value: %x", ack)
+	}
+
+	if err := <-shutdown; err != nil {
+		t.Errorf("Failed to shutdown. %v", err)
+	}
+	if err := <-served; err != ErrServerClosed {
+		t.Errorf("Expected error: %v, Actual error: %v", ErrServerClosed, err)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// maxDatagramSize is the biggest UDP payload
+const maxDatagramSize = 65535
+
+// UDPServer receives Codec 8 and Codec 8 Extended UDP packets, every datagram is decoded and handled in its own goroutine
+type UDPServer struct {
+	Handler  Handler       // Handler to invoke for every decoded packet
+	ErrorLog *log.Logger   // ErrorLog for undecodable packets and failed handlers, nil means the standard logger of the log package
+	Dedup    *Deduplicator // Dedup acknowledges retransmitted packets again without passing them to the Handler, nil means every packet is handled
+	Commands *Dispatcher   // Commands sends Codec 12 commands to the address of the last packet of the device, nil means commands are not supported
+
+	mu       sync.Mutex
+	conn     net.PacketConn
+	closing  bool
+	handlers sync.WaitGroup
+	cancel   context.CancelFunc // cancels contexts of running handlers
+	done     chan struct{}      // closed when Serve returned
+	packetID uint32             // packet ID of the last command
+}
+
+// ListenAndServe listens on the UDP network address addr and then calls Serve
+func (s *UDPServer) ListenAndServe(ctx context.Context, addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, conn)
+}
+
+// Serve reads datagrams from conn until ctx is canceled or Shutdown is called, the connection is closed on return.
+// Running handlers are waited for, so their acknowledgements are still sent. Serve returns ctx.Err() or ErrServerClosed.
+func (s *UDPServer) Serve(ctx context.Context, conn net.PacketConn) error {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		conn.Close()
+		return ErrServerClosed
+	}
+	handlerCtx, cancel := context.WithCancel(context.Background())
+	s.conn = conn
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	done := s.done
+	s.mu.Unlock()
+
+	defer close(done)
+	defer cancel()
+	defer conn.Close()
+
+	// stop reading when ctx is canceled
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.stopReading()
+		case <-done:
+		}
+	}()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if s.isClosing() {
+				break
+			}
+			s.handlers.Wait()
+			return err
+		}
+
+		packet := make([]byte, n)
+		copy(packet, buf[:n])
+
+		s.handlers.Add(1)
+		go func() {
+			defer s.handlers.Done()
+			s.handle(handlerCtx, conn, addr, packet)
+		}()
+	}
+
+	s.handlers.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return ErrServerClosed
+}
+
+// Shutdown stops reading of new datagrams and waits until running handlers send their acknowledgements and Serve returns.
+// If ctx expires first, contexts of running handlers are canceled and Shutdown returns ctx.Err().
+func (s *UDPServer) Shutdown(ctx context.Context) error {
+	s.stopReading()
+
+	s.mu.Lock()
+	done, cancel := s.done, s.cancel
+	s.mu.Unlock()
+
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	}
+}
+
+// stopReading unblocks the read loop, the connection stays open so handlers can still write acknowledgements
+func (s *UDPServer) stopReading() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closing = true
+	if s.conn != nil {
+		s.conn.SetReadDeadline(time.Now())
+	}
+}
+
+func (s *UDPServer) isClosing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closing
+}
+
+// handle decodes one datagram, passes it to the Handler and writes the acknowledgement
+func (s *UDPServer) handle(ctx context.Context, conn net.PacketConn, addr net.Addr, packet []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			logf(s.ErrorLog, "server: panic when handling packet from %v, %v", addr, r)
+		}
+	}()
+
+	// devices send a single 0xFF byte to keep NAT open
+	if len(packet) == 1 && packet[0] == 0xff {
+		return
+	}
+
+	if codecID, ok := udpCodecID(packet); ok && codecID == teltonikaparser.CodecID {
+		s.handleCommandResponse(addr, packet)
+		return
+	}
+
+	decoded, err := teltonikaparser.Decode(&packet)
+	if err != nil {
+		logf(s.ErrorLog, "server: unable to decode packet from %v, %v", addr, err)
+		return
+	}
+
+	claimed := false
+	if s.Dedup != nil {
+		if !s.Dedup.Claim(&decoded, packet) {
+			// the device did not get the previous acknowledgement, acknowledge again without handling,
+			// a packet which is still being handled is acknowledged by its handler
+			if s.Dedup.Duplicate(&decoded, packet) {
+				if _, err := conn.WriteTo(decoded.Response, addr); err != nil {
+					logf(s.ErrorLog, "server: unable to acknowledge packet to %v, %v", addr, err)
+				}
+			}
+			return
+		}
+
+		// a failed or panicking handler releases the packet, so the retransmission is handled again
+		claimed = true
+		defer func() {
+			if claimed {
+				s.Dedup.Forget(&decoded, packet)
+			}
+		}()
+	}
+
+	response := decoded.Response
+	if err := s.Handler.Handle(ctx, addr, &decoded); err != nil {
+		accepted := acceptedRecords(&decoded, err)
+		if accepted == 0 {
+			logf(s.ErrorLog, "server: handler failed for %v from %v, packet is not acknowledged, %v", decoded.IMEI, addr, err)
+			return
+		}
+
+		logf(s.ErrorLog, "server: handler failed for %v from %v, %v of %v records are acknowledged, %v", decoded.IMEI, addr, accepted, decoded.NoOfData, err)
+		if response, err = teltonikaparser.EncodeUDPResponse(&decoded, accepted); err != nil {
+			logf(s.ErrorLog, "server: unable to acknowledge packet to %v, %v", addr, err)
+			return
+		}
+	} else if claimed {
+		s.Dedup.Remember(&decoded, packet)
+		claimed = false
+	}
+
+	if _, err := conn.WriteTo(response, addr); err != nil {
+		logf(s.ErrorLog, "server: unable to acknowledge packet to %v, %v", addr, err)
+		return
+	}
+
+	// the device listens for commands shortly after it gets the acknowledgement
+	if s.Commands != nil {
+		s.Commands.attach(decoded.IMEI, &udpPeer{conn: conn, addr: addr, imei: decoded.IMEI, packetID: &s.packetID})
+	}
+}
+
+// handleCommandResponse decodes a Codec 12 response and passes it to the command waiting in Commands
+func (s *UDPServer) handleCommandResponse(addr net.Addr, packet []byte) {
+	imei, response, err := teltonikaparser.DecodeCommandResponseUDP(&packet)
+	if err != nil {
+		logf(s.ErrorLog, "server: unable to decode command response from %v, %v", addr, err)
+		return
+	}
+
+	if s.Commands == nil || !s.Commands.deliver(imei, string(response.Response)) {
+		logf(s.ErrorLog, "server: unexpected command response from %v at %v, %q", imei, addr, response.Response)
+	}
+}
+
+// udpCodecID returns Codec ID of the UDP packet, it follows the channel header and IMEI
+func udpCodecID(packet []byte) (byte, bool) {
+	if len(packet) < 8 {
+		return 0, false
+	}
+	codecIndex := 8 + int(binary.BigEndian.Uint16(packet[6:8]))
+	if codecIndex >= len(packet) {
+		return 0, false
+	}
+	return packet[codecIndex], true
+}
@@ -0,0 +1,174 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// udpPacket is Codec8 Extended UDP packet with one record
+const udpPacket = `0086cafe0101000f3335323039333038353639383230368e0100000167efa919800200000000000000000000000000000000fc0013000800ef0000f00000150500c80000450200010000710000fc00000900b5000000b600000042305600cd432a00ce6064001100090012ff22001303d1000f0000000200f1000059d90010000000000000000001`
+
+// startUDPServer runs a UDPServer on a loopback socket and returns a client connected to it
+func startUDPServer(t *testing.T, handler Handler) (*UDPServer, net.Conn, chan error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen. %v", err)
+	}
+
+	s := &UDPServer{Handler: handler, ErrorLog: log.New(io.Discard, "", 0)}
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(context.Background(), conn)
+	}()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial. %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return s, client, served
+}
+
+func TestUDPServerAcknowledge(t *testing.T) {
+	received := make(chan teltonikaparser.Decoded, 1)
+	s, client, served := startUDPServer(t, HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+		received <- *decoded
+		return nil
+	}))
+
+	packet, _ := hex.DecodeString(udpPacket)
+	if _, err := client.Write(packet); err != nil {
+		t.Fatalf("Failed to send packet. %v", err)
+	}
+
+	ack := make([]byte, 64)
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := client.Read(ack)
+	if err != nil {
+		t.Fatalf("Failed to read acknowledgement. %v", err)
+	}
+
+	expected := []byte{0x00, 0x05, 0xca, 0xfe, 0x01, 0x01, 0x01}
+	if !bytes.Equal(ack[:n], expected) {
+		t.Errorf("Expected value: %x, Actual value: %x", expected, ack[:n])
+	}
+
+	decoded := <-received
+	if decoded.IMEI != "352093085698206" || decoded.NoOfData != 1 {
+		t.Errorf("Unexpected decoded packet %+v", decoded)
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("Failed to shutdown. %v", err)
+	}
+	if err := <-served; err != ErrServerClosed {
+		t.Errorf("Expected error: %v, Actual error: %v", ErrServerClosed, err)
+	}
+}
+
+func TestUDPServerHandlerFailure(t *testing.T) {
+	handled := make(chan struct{}, 1)
+	_, client, _ := startUDPServer(t, HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+		handled <- struct{}{}
+		return errors.New("storage is down")
+	}))
+
+	packet, _ := hex.DecodeString(udpPacket)
+	if _, err := client.Write(packet); err != nil {
+		t.Fatalf("Failed to send packet. %v", err)
+	}
+
+	<-handled
+
+	ack := make([]byte, 64)
+	client.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if n, err := client.Read(ack); err == nil {
+		t.Errorf("Expected no acknowledgement, got %x", ack[:n])
+	}
+}
+
+func TestUDPServerPartialAccept(t *testing.T) {
+	// Codec8 UDP packet with four records, AVL packet ID 0x28
+	const packet4 = `01e4cafe0128000f333532303934303839333937343634080400000163c803eb02010a2524c01d4a377d00d3012f130032421b0a4503f00150051503ef01510052005900be00c1000ab50008b60006426fd8cd3d1ece605a5400005500007300005a0000c0000007c70000000df1000059d910002d33c65300000000570000000064000000f7bf000000000000000163c803e6e8010a2530781d4a316f00d40131130031421b0a4503f00150051503ef01510052005900be00c1000ab50008b60005426fcbcd3d1ece605a5400005500007300005a0000c0000007c70000000ef1000059d910002d33b95300000000570000000064000000f7bf000000000000000163c803df18010a2536961d4a2e4f00d50134130033421b0a4503f00150051503ef01510052005900be00c1000ab50008b6000542702bcd3d1ece605a5400005500007300005a0000c0000007c70000001ef1000059d910002d33aa5300000000570000000064000000f7bf000000000000000163c8039ce2010a25d8d41d49f42c00dc0123120058421b0a4503f00150051503ef01510052005900be00c1000ab50009b60005427031cd79d8ce605a5400005500007300005a0000c0000007c700000019f1000059d910002d32505300000000570000000064000000f7bf000000000004`
+
+	_, client, _ := startUDPServer(t, HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+		return &AcceptError{Accepted: 2, Err: errors.New("storage is full")}
+	}))
+
+	packet, _ := hex.DecodeString(packet4)
+	if _, err := client.Write(packet); err != nil {
+		t.Fatalf("Failed to send packet. %v", err)
+	}
+
+	ack := make([]byte, 64)
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := client.Read(ack)
+	if err != nil {
+		t.Fatalf("Failed to read acknowledgement. %v", err)
+	}
+
+	if expected := []byte{0x00, 0x05, 0xca, 0xfe, 0x01, 0x28, 0x02}; !bytes.Equal(ack[:n], expected) {
+		t.Errorf("Expected value: %x, Actual value: %x", expected, ack[:n])
+	}
+}
+
+func TestUDPServerInvalidPacket(t *testing.T) {
+	_, client, _ := startUDPServer(t, HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+		t.Errorf("Handler must not be called for an invalid packet")
+		return nil
+	}))
+
+	for _, packet := range [][]byte{{0xff}, []byte("garbage"), make([]byte, 50)} {
+		if _, err := client.Write(packet); err != nil {
+			t.Fatalf("Failed to send packet. %v", err)
+		}
+	}
+
+	ack := make([]byte, 64)
+	client.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+	if n, err := client.Read(ack); err == nil {
+		t.Errorf("Expected no acknowledgement, got %x", ack[:n])
+	}
+}
+
+func TestUDPServerContextCancel(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen. %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &UDPServer{Handler: HandlerFunc(func(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+		return nil
+	})}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(ctx, conn)
+	}()
+
+	cancel()
+
+	select {
+	case err := <-served:
+		if err != context.Canceled {
+			t.Errorf("Expected error: %v, Actual error: %v", context.Canceled, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Serve did not return after context cancellation")
+	}
+}
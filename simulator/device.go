@@ -0,0 +1,392 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package simulator emulates Teltonika devices for load and integration testing of servers, simulated devices drive
+// along routes, send Codec 8, Codec 8 Extended or Codec 16 packets over UDP or TCP and answer Codec 12 commands
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// maxPending limits records kept by a device which can not deliver them, the oldest ones are dropped like by a full memory
+const maxPending = 10000
+
+// maxRecordsPerPacket is the most records one packet can carry
+const maxRecordsPerPacket = 255
+
+// Device simulates one device driving along a route. It sends records to the server over UDP or TCP, retransmits packets
+// which are not acknowledged in AckTimeout, keeps records which were not accepted and answers Codec 12 commands.
+type Device struct {
+	IMEI       string        // IMEI of the device, see IMEI for generating valid ones
+	Network    string        // Network is "udp" or "tcp"
+	Addr       string        // Addr of the server
+	Codec      byte          // Codec8, Codec8Extended or Codec16, zero means Codec8
+	Route      Route         // Route to drive, nil means DefaultRoute
+	Start      float64       // Start is the distance from the first point of the route in meters where the device starts
+	Speed      float64       // Speed in km/h, zero means 50
+	Period     time.Duration // Period of records, zero means 1 second
+	Records    int           // Records per packet, zero means 1
+	IO         []IO          // IO elements of every record, nil means DefaultIO
+	AckTimeout time.Duration // AckTimeout before a packet is retransmitted, zero means 5 seconds
+	Retries    int           // Retries of one packet before the device reconnects, zero means 3, negative means none
+	Model      string        // Model reported by getver, empty means FMB920
+	ErrorLog   *log.Logger   // ErrorLog for connection failures, nil means the standard logger of the log package
+
+	started time.Time
+	stats   Stats
+}
+
+// Stats counts traffic of a Device
+type Stats struct {
+	Packets       int64 // Packets sent, retransmissions are not counted
+	Retransmitted int64 // Retransmitted packets
+	Acknowledged  int64 // Records accepted by the server
+	Commands      int64 // Answered Codec 12 commands
+	Reconnects    int64 // Reconnects after a failed connection
+}
+
+// Add returns the sum of both stats
+func (s Stats) Add(o Stats) Stats {
+	return Stats{
+		Packets:       s.Packets + o.Packets,
+		Retransmitted: s.Retransmitted + o.Retransmitted,
+		Acknowledged:  s.Acknowledged + o.Acknowledged,
+		Commands:      s.Commands + o.Commands,
+		Reconnects:    s.Reconnects + o.Reconnects,
+	}
+}
+
+// Stats returns the current traffic counters, it is safe to call while the device runs
+func (d *Device) Stats() Stats {
+	return Stats{
+		Packets:       atomic.LoadInt64(&d.stats.Packets),
+		Retransmitted: atomic.LoadInt64(&d.stats.Retransmitted),
+		Acknowledged:  atomic.LoadInt64(&d.stats.Acknowledged),
+		Commands:      atomic.LoadInt64(&d.stats.Commands),
+		Reconnects:    atomic.LoadInt64(&d.stats.Reconnects),
+	}
+}
+
+// Run simulates the device until ctx is canceled. Connection failures are logged and the device reconnects like a real one,
+// Run returns an error only if the device is not configured correctly, otherwise it returns ctx.Err().
+func (d *Device) Run(ctx context.Context) error {
+	if d.Network != "udp" && d.Network != "tcp" {
+		return fmt.Errorf("Invalid network %q, want udp or tcp", d.Network)
+	}
+
+	d.started = time.Now()
+
+	// check that the IO set fits the codec before connecting
+	if _, err := EncodeAvlData(d.codec(), []teltonikaparser.AvlData{d.record(d.started)}); err != nil {
+		return err
+	}
+
+	var pending []teltonikaparser.AvlData
+	for {
+		l, err := d.dial(ctx)
+		if err == nil {
+			pending, err = d.session(ctx, l, pending)
+			l.close()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		logf(d.ErrorLog, "simulator: device %v, %v, reconnecting", d.IMEI, err)
+		atomic.AddInt64(&d.stats.Reconnects, 1)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d.period()):
+		}
+	}
+}
+
+// session generates and sends records over one connection until it fails, it returns records which were not accepted
+func (d *Device) session(ctx context.Context, l link, pending []teltonikaparser.AvlData) ([]teltonikaparser.AvlData, error) {
+	// unblock reads when ctx is canceled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			l.close()
+		case <-done:
+		}
+	}()
+
+	next := time.Now()
+	for {
+		// answer commands until the next record
+		if err := d.listen(l, next); err != nil {
+			return pending, err
+		}
+
+		pending = append(pending, d.record(next))
+		if len(pending) > maxPending {
+			pending = pending[len(pending)-maxPending:]
+		}
+		next = next.Add(d.period())
+
+		if len(pending) < d.records() {
+			continue
+		}
+
+		batch := pending
+		if len(batch) > maxRecordsPerPacket {
+			batch = batch[:maxRecordsPerPacket]
+		}
+
+		accepted, err := d.deliver(l, batch)
+		pending = pending[accepted:]
+		if err != nil {
+			return pending, err
+		}
+	}
+}
+
+// deliver sends the records and retransmits them until they are acknowledged, it returns the number of accepted records
+func (d *Device) deliver(l link, batch []teltonikaparser.AvlData) (int, error) {
+	packet, err := l.encode(d.codec(), batch)
+	if err != nil {
+		return 0, err
+	}
+	atomic.AddInt64(&d.stats.Packets, 1)
+
+	for attempt := 0; attempt <= d.retries(); attempt++ {
+		if attempt > 0 {
+			atomic.AddInt64(&d.stats.Retransmitted, 1)
+		}
+
+		if err := l.send(packet); err != nil {
+			return 0, err
+		}
+
+		accepted, acked, err := d.awaitAck(l, time.Now().Add(d.ackTimeout()))
+		if err != nil {
+			return 0, err
+		}
+		if acked {
+			if accepted > len(batch) {
+				accepted = len(batch)
+			}
+			atomic.AddInt64(&d.stats.Acknowledged, int64(accepted))
+			return accepted, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no acknowledgement after %v retransmissions", d.retries())
+}
+
+// awaitAck reads from the server until the acknowledgement or deadline, commands are answered meanwhile
+func (d *Device) awaitAck(l link, deadline time.Time) (int, bool, error) {
+	for {
+		f, err := l.read(deadline)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, err
+		}
+
+		if f.ack {
+			return f.accepted, true, nil
+		}
+		if err := d.answer(l, f); err != nil {
+			return 0, false, err
+		}
+	}
+}
+
+// listen answers commands until deadline
+func (d *Device) listen(l link, deadline time.Time) error {
+	for time.Now().Before(deadline) {
+		f, err := l.read(deadline)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// a late acknowledgement of a retransmitted packet is ignored
+		if err := d.answer(l, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// answer replies to the command in the frame
+func (d *Device) answer(l link, f frame) error {
+	if !f.command {
+		return nil
+	}
+
+	atomic.AddInt64(&d.stats.Commands, 1)
+	return l.reply(d.respond(f.text, d.state(time.Now())))
+}
+
+// state returns the simulated state at the time t
+func (d *Device) state(t time.Time) State {
+	speed := d.speed()
+	driven := speed / 3.6 * t.Sub(d.started).Seconds()
+
+	route := d.Route
+	if route == nil {
+		route = DefaultRoute
+	}
+	position, angle := route.Position(d.Start + driven)
+
+	return State{Time: t, Position: position, Angle: angle, Speed: speed, Odometer: driven}
+}
+
+// record returns the record generated at the time t
+func (d *Device) record(t time.Time) teltonikaparser.AvlData {
+	state := d.state(t)
+
+	io := d.IO
+	if io == nil {
+		io = DefaultIO
+	}
+	elements := make([]teltonikaparser.Element, 0, len(io))
+	for _, e := range io {
+		elements = append(elements, e.Element(state))
+	}
+
+	return teltonikaparser.AvlData{
+		UtimeMs:  uint64(t.UnixNano() / int64(time.Millisecond)),
+		Utime:    uint64(t.Unix()),
+		Lat:      int32(math.Round(state.Position.Lat * 1e7)),
+		Lng:      int32(math.Round(state.Position.Lng * 1e7)),
+		Altitude: 230,
+		Angle:    uint16(math.Round(state.Angle)) % 360,
+		VisSat:   11,
+		Speed:    uint16(math.Round(state.Speed)),
+		Elements: elements,
+	}
+}
+
+func (d *Device) codec() byte {
+	if d.Codec == 0 {
+		return Codec8
+	}
+	return d.Codec
+}
+
+func (d *Device) speed() float64 {
+	if d.Speed == 0 {
+		return 50
+	}
+	return d.Speed
+}
+
+func (d *Device) period() time.Duration {
+	if d.Period == 0 {
+		return time.Second
+	}
+	return d.Period
+}
+
+func (d *Device) records() int {
+	if d.Records <= 0 {
+		return 1
+	}
+	return d.Records
+}
+
+func (d *Device) ackTimeout() time.Duration {
+	if d.AckTimeout == 0 {
+		return 5 * time.Second
+	}
+	return d.AckTimeout
+}
+
+func (d *Device) retries() int {
+	switch {
+	case d.Retries == 0:
+		return 3
+	case d.Retries < 0:
+		return 0
+	}
+	return d.Retries
+}
+
+// Fleet returns n copies of the template with consecutive IMEIs starting by the serial number first,
+// the devices start at evenly spread places of the route
+func Fleet(template *Device, first int, n int) []*Device {
+	route := template.Route
+	if route == nil {
+		route = DefaultRoute
+	}
+
+	devices := make([]*Device, n)
+	for i := range devices {
+		devices[i] = &Device{
+			IMEI:       IMEI(first + i),
+			Network:    template.Network,
+			Addr:       template.Addr,
+			Codec:      template.Codec,
+			Route:      template.Route,
+			Start:      template.Start + route.Length()*float64(i)/float64(n),
+			Speed:      template.Speed,
+			Period:     template.Period,
+			Records:    template.Records,
+			IO:         template.IO,
+			AckTimeout: template.AckTimeout,
+			Retries:    template.Retries,
+			Model:      template.Model,
+			ErrorLog:   template.ErrorLog,
+		}
+	}
+	return devices
+}
+
+// RunFleet runs all devices until ctx is canceled, it returns the first configuration error or ctx.Err()
+func RunFleet(ctx context.Context, devices []*Device) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(devices))
+	for _, device := range devices {
+		wg.Add(1)
+		go func(device *Device) {
+			defer wg.Done()
+			if err := device.Run(ctx); err != nil && ctx.Err() == nil {
+				errs <- fmt.Errorf("device %v, %v", device.IMEI, err)
+				cancel()
+			}
+		}(device)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return ctx.Err()
+	}
+}
+
+// logf logs by the logger or by the standard logger of the log package if the logger is nil
+func logf(logger *log.Logger, format string, args ...interface{}) {
+	if logger != nil {
+		logger.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
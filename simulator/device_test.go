@@ -0,0 +1,205 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simulator
+
+import (
+	"context"
+	"io"
+	"log"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filipkroca/b2n"
+	"github.com/filipkroca/teltonikaparser"
+	"github.com/filipkroca/teltonikaparser/server"
+)
+
+var discard = log.New(io.Discard, "", 0)
+
+// collector is a Handler which stores received records per IMEI
+type collector struct {
+	mu      sync.Mutex
+	records map[string][]teltonikaparser.AvlData
+}
+
+func (c *collector) Handle(ctx context.Context, addr net.Addr, decoded *teltonikaparser.Decoded) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.records == nil {
+		c.records = make(map[string][]teltonikaparser.AvlData)
+	}
+	c.records[decoded.IMEI] = append(c.records[decoded.IMEI], decoded.Data...)
+	return nil
+}
+
+func (c *collector) count(imei string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.records[imei])
+}
+
+// waitFor polls the condition for 2 seconds
+func waitFor(t *testing.T, what string, condition func() bool) {
+	for i := 0; i < 400; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %v", what)
+}
+
+func TestIMEI(t *testing.T) {
+	for _, n := range []int{0, 1, 42, 999999} {
+		imei := IMEI(n)
+		bs := []byte(imei)
+		if _, err := b2n.ParseIMEI(&bs, 0, 15); err != nil {
+			t.Errorf("IMEI %v is not valid, %v", imei, err)
+		}
+	}
+}
+
+func TestRoutePosition(t *testing.T) {
+	route := Route{{Lat: 0, Lng: 0}, {Lat: 0, Lng: 1}, {Lat: 1, Lng: 1}}
+	segment := Distance(route[0], route[1])
+
+	position, angle := route.Position(segment / 2)
+	if math.Abs(position.Lng-0.5) > 1e-9 || position.Lat != 0 || math.Abs(angle-90) > 1e-6 {
+		t.Errorf("Expected 0, 0.5 heading east, got %+v heading %v", position, angle)
+	}
+
+	// the route is driven repeatedly
+	again, _ := route.Position(route.Length() + segment/2)
+	if math.Abs(again.Lng-position.Lng) > 1e-9 || math.Abs(again.Lat-position.Lat) > 1e-9 {
+		t.Errorf("Expected %+v, got %+v", position, again)
+	}
+}
+
+func TestDeviceUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen. %v", err)
+	}
+
+	handler := &collector{}
+	commands := &server.Dispatcher{}
+	srv := &server.UDPServer{Handler: handler, Commands: commands, ErrorLog: discard}
+	go srv.Serve(context.Background(), conn)
+	defer srv.Shutdown(context.Background())
+
+	device := &Device{IMEI: IMEI(1), Network: "udp", Addr: conn.LocalAddr().String(), Codec: Codec8Extended, Period: 20 * time.Millisecond, Records: 2, ErrorLog: discard}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go device.Run(ctx)
+
+	waitFor(t, "records", func() bool { return handler.count(device.IMEI) >= 4 })
+
+	cmdCtx, cmdCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cmdCancel()
+	response, err := commands.SendCommand(cmdCtx, device.IMEI, "getver")
+	if err != nil || !strings.Contains(response, "IMEI:"+device.IMEI) {
+		t.Errorf("Unexpected getver response %q, %v", response, err)
+	}
+
+	if stats := device.Stats(); stats.Acknowledged < 4 || stats.Commands != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestDeviceTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen. %v", err)
+	}
+
+	handler := &collector{}
+	commands := &server.Dispatcher{}
+	srv := &server.TCPServer{Handler: handler, Commands: commands, ErrorLog: discard}
+	go srv.Serve(context.Background(), l)
+	defer srv.Shutdown(context.Background())
+
+	devices := Fleet(&Device{Network: "tcp", Addr: l.Addr().String(), Codec: Codec8, Period: 20 * time.Millisecond, ErrorLog: discard}, 10, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go RunFleet(ctx, devices)
+
+	for _, device := range devices {
+		imei := device.IMEI
+		waitFor(t, "records of "+imei, func() bool { return handler.count(imei) >= 2 })
+	}
+
+	cmdCtx, cmdCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cmdCancel()
+	response, err := commands.SendCommand(cmdCtx, devices[1].IMEI, "getgps")
+	if err != nil || !strings.HasPrefix(response, "GPS:1 Sat:11 Lat:49.") {
+		t.Errorf("Unexpected getgps response %q, %v", response, err)
+	}
+}
+
+func TestDeviceRetransmit(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen. %v", err)
+	}
+	defer conn.Close()
+
+	device := &Device{IMEI: IMEI(2), Network: "udp", Addr: conn.LocalAddr().String(), Period: time.Hour, AckTimeout: 50 * time.Millisecond, ErrorLog: discard}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go device.Run(ctx)
+
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Failed to read packet. %v", err)
+	}
+	first := string(buf[:n])
+
+	// no acknowledgement, the same packet comes again
+	n, addr, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Failed to read retransmission. %v", err)
+	}
+	if string(buf[:n]) != first {
+		t.Fatalf("Expected the same packet, got %x", buf[:n])
+	}
+
+	packet := buf[:n]
+	decoded, err := teltonikaparser.Decode(&packet)
+	if err != nil {
+		t.Fatalf("Failed to decode packet. %v", err)
+	}
+	conn.WriteTo(decoded.Response, addr)
+
+	waitFor(t, "acknowledgement", func() bool { return device.Stats().Acknowledged == 1 })
+	if stats := device.Stats(); stats.Packets != 1 || stats.Retransmitted < 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestDeviceNoRetries(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen. %v", err)
+	}
+	defer conn.Close()
+
+	device := &Device{IMEI: IMEI(3), Network: "udp", Addr: conn.LocalAddr().String(), Period: time.Hour, AckTimeout: 50 * time.Millisecond,
+		Retries: -1, ErrorLog: discard}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go device.Run(ctx)
+
+	// no acknowledgement, the device reconnects without retransmitting the packet
+	waitFor(t, "reconnect", func() bool { return device.Stats().Reconnects >= 1 })
+	if stats := device.Stats(); stats.Retransmitted != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simulator
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/basvdlei/gotsmart/crc16"
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// Codec IDs produced by the simulator
+const (
+	Codec8         = 0x08
+	Codec8Extended = 0x8e
+	Codec16        = 0x10
+)
+
+// Codec 16 generation types used for records
+const (
+	generationOnChange   = 0x05
+	generationPeriodical = 0x07
+)
+
+// EncodeUDP takes IMEI, packet IDs, Codec ID and records and return UDP packet as accepted by teltonikaparser.Decode
+func EncodeUDP(imei string, packetID uint16, avlPacketID byte, codecID byte, data []teltonikaparser.AvlData) ([]byte, error) {
+	body, err := EncodeAvlData(codecID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// packet ID, not usable byte, AVL packet ID, IMEI length, IMEI and data
+	length := 2 + 1 + 1 + 2 + len(imei) + len(body)
+	if length > 0xffff {
+		return nil, fmt.Errorf("Unable to encode UDP packet, length %v exceeds 65535 Bytes", length)
+	}
+
+	packet := make([]byte, 0, 2+length)
+	packet = binary.BigEndian.AppendUint16(packet, uint16(length))
+	packet = binary.BigEndian.AppendUint16(packet, packetID)
+	packet = append(packet, 0x01, avlPacketID)
+	packet = binary.BigEndian.AppendUint16(packet, uint16(len(imei)))
+	packet = append(packet, imei...)
+	packet = append(packet, body...)
+
+	return packet, nil
+}
+
+// EncodeTCP takes Codec ID and records and return TCP packet with preamble, data field length and CRC as accepted by teltonikaparser.DecodeTCP
+func EncodeTCP(codecID byte, data []teltonikaparser.AvlData) ([]byte, error) {
+	body, err := EncodeAvlData(codecID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	packet := make([]byte, 0, 8+len(body)+4)
+	packet = binary.BigEndian.AppendUint32(packet, 0)
+	packet = binary.BigEndian.AppendUint32(packet, uint32(len(body)))
+	packet = append(packet, body...)
+	packet = binary.BigEndian.AppendUint32(packet, uint32(crc16.Checksum(body)))
+
+	return packet, nil
+}
+
+// EncodeAvlData takes Codec ID and records and return the data shared by UDP and TCP packets,
+// Codec ID, number of data, records and number of data again
+func EncodeAvlData(codecID byte, data []teltonikaparser.AvlData) ([]byte, error) {
+	if codecID != Codec8 && codecID != Codec8Extended && codecID != Codec16 {
+		return nil, fmt.Errorf("Invalid Codec ID, want 0x08, 0x8E or 0x10, got %#x", codecID)
+	}
+	if len(data) == 0 || len(data) > 255 {
+		return nil, fmt.Errorf("Unable to encode %v records, want 1 to 255", len(data))
+	}
+
+	bs := []byte{codecID, byte(len(data))}
+	for i := range data {
+		var err error
+		bs, err = appendRecord(bs, codecID, &data[i])
+		if err != nil {
+			return nil, fmt.Errorf("Unable to encode record %v, %v", i, err)
+		}
+	}
+
+	return append(bs, byte(len(data))), nil
+}
+
+// appendRecord appends timestamp, priority, GPS element, event ID and IO elements of one record
+func appendRecord(bs []byte, codecID byte, record *teltonikaparser.AvlData) ([]byte, error) {
+	bs = binary.BigEndian.AppendUint64(bs, record.UtimeMs)
+	bs = append(bs, record.Priority)
+	bs = binary.BigEndian.AppendUint32(bs, uint32(record.Lng))
+	bs = binary.BigEndian.AppendUint32(bs, uint32(record.Lat))
+	bs = binary.BigEndian.AppendUint16(bs, uint16(record.Altitude))
+	bs = binary.BigEndian.AppendUint16(bs, record.Angle)
+	bs = append(bs, record.VisSat)
+	bs = binary.BigEndian.AppendUint16(bs, record.Speed)
+
+	switch codecID {
+	case Codec8:
+		if record.EventID > 0xff {
+			return nil, fmt.Errorf("Event ID %v does not fit 1 Byte of Codec 8", record.EventID)
+		}
+		bs = append(bs, byte(record.EventID))
+	case Codec8Extended:
+		bs = binary.BigEndian.AppendUint16(bs, record.EventID)
+	case Codec16:
+		bs = binary.BigEndian.AppendUint16(bs, record.EventID)
+		if record.EventID == 0 {
+			bs = append(bs, generationPeriodical)
+		} else {
+			bs = append(bs, generationOnChange)
+		}
+	}
+
+	return appendElements(bs, codecID, record.Elements)
+}
+
+// appendElements appends IO elements grouped by their length, variable length elements are supported only by Codec 8 Extended
+func appendElements(bs []byte, codecID byte, elements []teltonikaparser.Element) ([]byte, error) {
+	sizes := []int{1, 2, 4, 8}
+	groups := make(map[int][]teltonikaparser.Element)
+	var variable []teltonikaparser.Element
+
+	for _, element := range elements {
+		switch len(element.Value) {
+		case 1, 2, 4, 8:
+			groups[len(element.Value)] = append(groups[len(element.Value)], element)
+		default:
+			if codecID != Codec8Extended {
+				return nil, fmt.Errorf("IO %v has %v Bytes, Codec %#x supports only 1, 2, 4 and 8 Bytes", element.IOID, len(element.Value), codecID)
+			}
+			variable = append(variable, element)
+		}
+	}
+
+	var err error
+	if bs, err = appendCount(bs, codecID, len(elements)); err != nil {
+		return nil, err
+	}
+
+	for _, size := range sizes {
+		if bs, err = appendCount(bs, codecID, len(groups[size])); err != nil {
+			return nil, err
+		}
+		for _, element := range groups[size] {
+			if bs, err = appendID(bs, codecID, element.IOID); err != nil {
+				return nil, err
+			}
+			bs = append(bs, element.Value...)
+		}
+	}
+
+	if codecID == Codec8Extended {
+		bs = binary.BigEndian.AppendUint16(bs, uint16(len(variable)))
+		for _, element := range variable {
+			if len(element.Value) > 0xffff {
+				return nil, fmt.Errorf("IO %v has %v Bytes, maximum is 65535", element.IOID, len(element.Value))
+			}
+			bs = binary.BigEndian.AppendUint16(bs, element.IOID)
+			bs = binary.BigEndian.AppendUint16(bs, uint16(len(element.Value)))
+			bs = append(bs, element.Value...)
+		}
+	}
+
+	return bs, nil
+}
+
+// appendCount appends number of IO elements, 2 Bytes for Codec 8 Extended, 1 Byte otherwise
+func appendCount(bs []byte, codecID byte, count int) ([]byte, error) {
+	if codecID == Codec8Extended {
+		if count > 0xffff {
+			return nil, fmt.Errorf("Unable to encode %v IO elements, maximum is 65535", count)
+		}
+		return binary.BigEndian.AppendUint16(bs, uint16(count)), nil
+	}
+
+	if count > 0xff {
+		return nil, fmt.Errorf("Unable to encode %v IO elements, maximum of Codec %#x is 255", count, codecID)
+	}
+	return append(bs, byte(count)), nil
+}
+
+// appendID appends IO ID, 1 Byte for Codec 8, 2 Bytes otherwise
+func appendID(bs []byte, codecID byte, id uint16) ([]byte, error) {
+	if codecID == Codec8 {
+		if id > 0xff {
+			return nil, fmt.Errorf("IO ID %v does not fit 1 Byte of Codec 8", id)
+		}
+		return append(bs, byte(id)), nil
+	}
+	return binary.BigEndian.AppendUint16(bs, id), nil
+}
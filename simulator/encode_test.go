@@ -0,0 +1,149 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simulator
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// records are sorted by element length, the order in which the decoder returns them
+var records = []teltonikaparser.AvlData{
+	{
+		UtimeMs: 1560161086000, Utime: 1560161086, Priority: 1, Lat: 491951234, Lng: 166068370, Altitude: 230, Angle: 45, VisSat: 11, Speed: 50, EventID: 0,
+		Elements: []teltonikaparser.Element{
+			{Length: 1, IOID: 239, Value: []byte{0x01}},
+			{Length: 2, IOID: 66, Value: []byte{0x35, 0xe8}},
+			{Length: 4, IOID: 16, Value: []byte{0x00, 0x00, 0x12, 0x34}},
+			{Length: 8, IOID: 78, Value: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}},
+		},
+	},
+	{
+		UtimeMs: 1560161087000, Utime: 1560161087, Priority: 0, Lat: -335000000, Lng: -700000000, Altitude: -20, Angle: 360, VisSat: 0, Speed: 0, EventID: 240,
+		Elements: []teltonikaparser.Element{
+			{Length: 1, IOID: 240, Value: []byte{0x00}},
+		},
+	},
+}
+
+func TestEncodeUDP(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		CodecID byte
+	}{
+		{Name: "Codec8", CodecID: Codec8},
+		{Name: "Codec8Extended", CodecID: Codec8Extended},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			packet, err := EncodeUDP("352093085698206", 0xcafe, 0x07, testCase.CodecID, records)
+			if err != nil {
+				test.Fatalf("Failed to encode packet. %v", err)
+			}
+
+			decoded, err := teltonikaparser.Decode(&packet)
+			if err != nil {
+				test.Fatalf("Failed to decode packet %x. %v", packet, err)
+			}
+
+			if decoded.IMEI != "352093085698206" || decoded.CodecID != testCase.CodecID || decoded.AVLPacketID != 0x07 {
+				test.Errorf("Unexpected header %+v", decoded)
+			}
+			if !reflect.DeepEqual(decoded.Data, records) {
+				test.Errorf("Expected value: %+v, Actual value: %+v", records, decoded.Data)
+			}
+		})
+	}
+}
+
+func TestEncodeTCP(t *testing.T) {
+	packet, err := EncodeTCP(Codec8Extended, records)
+	if err != nil {
+		t.Fatalf("Failed to encode packet. %v", err)
+	}
+
+	decoded, err := teltonikaparser.DecodeTCP(&packet)
+	if err != nil {
+		t.Fatalf("Failed to decode packet %x. %v", packet, err)
+	}
+
+	if !reflect.DeepEqual(decoded.Data, records) {
+		t.Errorf("Expected value: %+v, Actual value: %+v", records, decoded.Data)
+	}
+}
+
+func TestEncodeTCPWiki(t *testing.T) {
+	// the first Codec 8 example of the Teltonika wiki
+	expected := "000000000000003608010000016b40d8ea30010000000000000000000000000000000105021503010101425e0f01f10000601a014e0000000000000000010000c7cf"
+
+	data := []teltonikaparser.AvlData{{
+		UtimeMs:  0x16b40d8ea30,
+		Priority: 1,
+		EventID:  1,
+		Elements: []teltonikaparser.Element{
+			{IOID: 21, Value: []byte{0x03}},
+			{IOID: 1, Value: []byte{0x01}},
+			{IOID: 66, Value: []byte{0x5e, 0x0f}},
+			{IOID: 241, Value: []byte{0x00, 0x00, 0x60, 0x1a}},
+			{IOID: 78, Value: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		},
+	}}
+
+	packet, err := EncodeTCP(Codec8, data)
+	if err != nil {
+		t.Fatalf("Failed to encode packet. %v", err)
+	}
+	if actual := hex.EncodeToString(packet); actual != expected {
+		t.Errorf("Expected value: %v, Actual value: %v", expected, actual)
+	}
+}
+
+func TestEncodeCodec16(t *testing.T) {
+	data := []teltonikaparser.AvlData{{
+		UtimeMs:  0x16b40d8ea30,
+		EventID:  385,
+		Elements: []teltonikaparser.Element{{IOID: 385, Value: []byte{0x01}}},
+	}}
+
+	bs, err := EncodeAvlData(Codec16, data)
+	if err != nil {
+		t.Fatalf("Failed to encode data. %v", err)
+	}
+
+	// Codec ID, number of data, timestamp, priority, GPS element, event ID, generation type,
+	// total IO count, 1 element of 1 Byte with 2 Bytes ID, no elements of 2, 4 and 8 Bytes, number of data
+	expected := "1001" + "0000016b40d8ea30" + "00" + "000000000000000000000000000000" + "0181" + "05" + "01" + "01" + "018101" + "00" + "00" + "00" + "01"
+	if actual := hex.EncodeToString(bs); actual != expected {
+		t.Errorf("Expected value: %v, Actual value: %v", expected, actual)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		CodecID byte
+		Data    []teltonikaparser.AvlData
+	}{
+		{Name: "UnknownCodec", CodecID: 0x0c, Data: records},
+		{Name: "NoRecords", CodecID: Codec8},
+		{Name: "EventIDOverCodec8", CodecID: Codec8, Data: []teltonikaparser.AvlData{{EventID: 256}}},
+		{Name: "IOIDOverCodec8", CodecID: Codec8, Data: []teltonikaparser.AvlData{{Elements: []teltonikaparser.Element{{IOID: 256, Value: []byte{0x01}}}}}},
+		{Name: "VariableLengthCodec8", CodecID: Codec8, Data: []teltonikaparser.AvlData{{Elements: []teltonikaparser.Element{{IOID: 1, Value: []byte{0x01, 0x02, 0x03}}}}}},
+		{Name: "VariableLengthCodec16", CodecID: Codec16, Data: []teltonikaparser.AvlData{{Elements: []teltonikaparser.Element{{IOID: 1, Value: []byte{0x01, 0x02, 0x03}}}}}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			if _, err := EncodeAvlData(testCase.CodecID, testCase.Data); err == nil {
+				test.Logf("This is an error case but there is no error.")
+				test.Fail()
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simulator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// firmwareVersion is reported by getver
+const firmwareVersion = "03.27.07_00"
+
+// respond returns the response of the firmware to the command, the format follows FMB devices
+func (d *Device) respond(command string, state State) string {
+	model := d.Model
+	if model == "" {
+		model = "FMB920"
+	}
+
+	switch strings.TrimSpace(command) {
+	case "getinfo":
+		return fmt.Sprintf("INI:%v RTC:%v RST:1 ERR:0 SR:0 BR:0 CF:0 FG:0 FL:0 TU:0/0 UT:0 SMS:0 NOGPS:0:0 GPS:3 SAT:11 RS:3 RF:65 SF:1 MD:%v",
+			formatTime(d.started), formatTime(state.Time), boolValue(state.Speed > 0))
+	case "getver":
+		return fmt.Sprintf("Ver:%v GPS:AXN_5.10_3333 Hw:%v Mod:15 IMEI:%v Init:%v Uptime:%v BOOT:1.6",
+			firmwareVersion, model, d.IMEI, formatTime(d.started), int(state.Time.Sub(d.started).Seconds()))
+	case "getgps":
+		utc := state.Time.UTC()
+		return fmt.Sprintf("GPS:1 Sat:11 Lat:%.6f Long:%.6f Alt:230 Speed:%.0f Dir:%.0f Date: %d/%d/%d Time: %d:%d:%d",
+			state.Position.Lat, state.Position.Lng, state.Speed, state.Angle, utc.Year(), utc.Month(), utc.Day(), utc.Hour(), utc.Minute(), utc.Second())
+	default:
+		return fmt.Sprintf("Unknown command %q", command)
+	}
+}
+
+// formatTime formats t like the firmware, 2019/7/22 7:22
+func formatTime(t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf("%d/%d/%d %d:%02d", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute())
+}
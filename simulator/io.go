@@ -0,0 +1,106 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simulator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// State is the simulated state of a device at the time of a record
+type State struct {
+	Time     time.Time
+	Position Point
+	Angle    float64 // Heading in degrees
+	Speed    float64 // Speed in km/h
+	Odometer float64 // Driven distance in meters since the start of the simulation
+}
+
+// IO generates one IO element of every record
+type IO struct {
+	ID    uint16                   // IO element ID
+	Size  int                      // Length of the value in Bytes, 1, 2, 4 and 8 are supported by all codecs, other lengths only by Codec 8 Extended
+	Value func(state State) uint64 // Value of the element, nil means zero, values of other lengths than 1, 2, 4 or 8 Bytes are filled by zeros
+}
+
+// Element returns the IO element for the state
+func (io IO) Element(state State) teltonikaparser.Element {
+	var value uint64
+	if io.Value != nil {
+		value = io.Value(state)
+	}
+
+	bs := make([]byte, io.Size)
+	switch io.Size {
+	case 1:
+		bs[0] = byte(value)
+	case 2:
+		binary.BigEndian.PutUint16(bs, uint16(value))
+	case 4:
+		binary.BigEndian.PutUint32(bs, uint32(value))
+	case 8:
+		binary.BigEndian.PutUint64(bs, value)
+	}
+
+	return teltonikaparser.Element{Length: uint16(io.Size), IOID: io.ID, Value: bs}
+}
+
+// IO elements of FMB devices derived from the state
+var (
+	Ignition        = IO{ID: 239, Size: 1, Value: func(s State) uint64 { return 1 }}
+	Movement        = IO{ID: 240, Size: 1, Value: func(s State) uint64 { return boolValue(s.Speed > 0) }}
+	GSMSignal       = IO{ID: 21, Size: 1, Value: func(s State) uint64 { return 4 }}
+	Speed           = IO{ID: 24, Size: 2, Value: func(s State) uint64 { return uint64(s.Speed) }}
+	ExternalVoltage = IO{ID: 66, Size: 2, Value: func(s State) uint64 { return 13800 + uint64(s.Time.Second()%10)*10 }}
+	TotalOdometer   = IO{ID: 16, Size: 4, Value: func(s State) uint64 { return uint64(s.Odometer) }}
+)
+
+// DefaultIO is the IO set used when a Device has no IO set
+var DefaultIO = []IO{Ignition, Movement, GSMSignal, Speed, ExternalVoltage, TotalOdometer}
+
+// KnownIO returns the known IO by its ID
+func KnownIO(id uint16) (IO, bool) {
+	for _, io := range DefaultIO {
+		if io.ID == id {
+			return io, true
+		}
+	}
+	return IO{}, false
+}
+
+// IMEI returns a valid IMEI with the serial number n, the last digit is the Luhn check digit
+func IMEI(n int) string {
+	body := fmt.Sprintf("35209308%06d", n%1000000)
+	return body + strconv.Itoa(luhn(body))
+}
+
+// luhn returns the check digit of digits
+func luhn(digits string) int {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return (10 - sum%10) % 10
+}
+
+func boolValue(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,240 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simulator
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/filipkroca/teltonikaparser"
+)
+
+// udpPacketID is the packet ID sent by devices in the UDP channel header
+const udpPacketID = 0xcafe
+
+// zeroAckWait is how long a TCP device waits for a Codec 12 header after 4 zero Bytes, if nothing follows they are the acknowledgement of 0 records
+const zeroAckWait = 100 * time.Millisecond
+
+// frame is one message received from the server
+type frame struct {
+	ack      bool   // frame is an acknowledgement
+	accepted int    // number of accepted records
+	command  bool   // frame is a Codec 12 command
+	text     string // command
+}
+
+// link is one connection to the server
+type link interface {
+	encode(codecID byte, data []teltonikaparser.AvlData) ([]byte, error)
+	send(packet []byte) error
+	read(deadline time.Time) (frame, error) // read returns os.ErrDeadlineExceeded when nothing arrived until deadline
+	reply(response string) error
+	close() error
+}
+
+// dial connects to the server, a TCP device also logs in by its IMEI
+func (d *Device) dial(ctx context.Context) (link, error) {
+	dialer := net.Dialer{Timeout: d.ackTimeout()}
+	conn, err := dialer.DialContext(ctx, d.Network, d.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.Network == "udp" {
+		return &udpLink{conn: conn, imei: d.IMEI, buf: make([]byte, 65535)}, nil
+	}
+
+	l := &tcpLink{conn: conn, reader: bufio.NewReader(conn)}
+	if err := l.login(d.IMEI, time.Now().Add(d.ackTimeout())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// udpLink sends packets from one UDP socket and receives acknowledgements and commands on it
+type udpLink struct {
+	conn        net.Conn
+	imei        string
+	avlPacketID byte   // AVL packet ID of the last packet
+	buf         []byte // buffer for received datagrams
+	header      []byte // UDP channel header and IMEI of the last command, the response repeats them
+}
+
+func (l *udpLink) encode(codecID byte, data []teltonikaparser.AvlData) ([]byte, error) {
+	l.avlPacketID++
+	return EncodeUDP(l.imei, udpPacketID, l.avlPacketID, codecID, data)
+}
+
+func (l *udpLink) send(packet []byte) error {
+	_, err := l.conn.Write(packet)
+	return err
+}
+
+func (l *udpLink) read(deadline time.Time) (frame, error) {
+	l.conn.SetReadDeadline(deadline)
+	n, err := l.conn.Read(l.buf)
+	if err != nil {
+		return frame{}, err
+	}
+	datagram := l.buf[:n]
+
+	// acknowledgement: length 5, packet ID, not usable byte, AVL packet ID and number of accepted records
+	if n == 7 && datagram[0] == 0x00 && datagram[1] == 0x05 {
+		if datagram[5] != l.avlPacketID {
+			// acknowledgement of an older packet
+			return frame{}, nil
+		}
+		return frame{ack: true, accepted: int(datagram[6])}, nil
+	}
+
+	header, command, err := decodeUDPCommand(datagram)
+	if err != nil {
+		// unknown datagrams are ignored like by a real device
+		return frame{}, nil
+	}
+	l.header = header
+	return frame{command: true, text: command}, nil
+}
+
+func (l *udpLink) reply(response string) error {
+	if l.header == nil {
+		return errors.New("no command to respond to")
+	}
+
+	packet := append([]byte{}, l.header...)
+	packet = append(packet, teltonikaparser.CodecID, 0x01, teltonikaparser.CommandTypeResponse)
+	packet = binary.BigEndian.AppendUint32(packet, uint32(len(response)))
+	packet = append(packet, response...)
+	packet = append(packet, 0x01)
+	binary.BigEndian.PutUint16(packet, uint16(len(packet)-2))
+
+	_, err := l.conn.Write(packet)
+	return err
+}
+
+func (l *udpLink) close() error {
+	return l.conn.Close()
+}
+
+// decodeUDPCommand returns the UDP channel header with IMEI and the command of a Codec 12 UDP packet
+func decodeUDPCommand(datagram []byte) ([]byte, string, error) {
+	if len(datagram) < 8 {
+		return nil, "", fmt.Errorf("datagram has only %v Bytes", len(datagram))
+	}
+
+	codecIndex := 8 + int(binary.BigEndian.Uint16(datagram[6:8]))
+	// Codec ID, quantity, type, size and quantity
+	if len(datagram) < codecIndex+8 {
+		return nil, "", fmt.Errorf("datagram has only %v Bytes", len(datagram))
+	}
+	if datagram[codecIndex] != teltonikaparser.CodecID || datagram[codecIndex+2] != teltonikaparser.CommandTypeRequest {
+		return nil, "", errors.New("not a Codec 12 command")
+	}
+
+	size := int(binary.BigEndian.Uint32(datagram[codecIndex+3:]))
+	if len(datagram) != codecIndex+7+size+1 {
+		return nil, "", fmt.Errorf("command size %v does not match datagram of %v Bytes", size, len(datagram))
+	}
+
+	header := append([]byte{}, datagram[:codecIndex]...)
+	return header, string(datagram[codecIndex+7 : codecIndex+7+size]), nil
+}
+
+// tcpLink sends packets over one TCP connection and receives acknowledgements and commands on it
+type tcpLink struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// login sends IMEI and waits for 0x01
+func (l *tcpLink) login(imei string, deadline time.Time) error {
+	packet := binary.BigEndian.AppendUint16(nil, uint16(len(imei)))
+	packet = append(packet, imei...)
+	if _, err := l.conn.Write(packet); err != nil {
+		return err
+	}
+
+	l.conn.SetReadDeadline(deadline)
+	reply, err := l.reader.ReadByte()
+	if err != nil {
+		return fmt.Errorf("login failed, %v", err)
+	}
+	if reply != 0x01 {
+		return fmt.Errorf("login rejected by %#02x", reply)
+	}
+	return nil
+}
+
+func (l *tcpLink) encode(codecID byte, data []teltonikaparser.AvlData) ([]byte, error) {
+	return EncodeTCP(codecID, data)
+}
+
+func (l *tcpLink) send(packet []byte) error {
+	_, err := l.conn.Write(packet)
+	return err
+}
+
+// read distinguishes the 4 Bytes acknowledgement from the Codec 12 command which starts by 4 zero Bytes of preamble,
+// 4 zero Bytes followed by nothing are the acknowledgement of 0 records
+func (l *tcpLink) read(deadline time.Time) (frame, error) {
+	l.conn.SetReadDeadline(deadline)
+	// peek does not consume a partially received frame when the deadline expires
+	head, err := l.reader.Peek(4)
+	if err != nil {
+		return frame{}, err
+	}
+	if value := binary.BigEndian.Uint32(head); value != 0 {
+		l.reader.Discard(4)
+		return frame{ack: true, accepted: int(value)}, nil
+	}
+
+	l.conn.SetReadDeadline(time.Now().Add(zeroAckWait))
+	header, err := l.reader.Peek(8)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		l.reader.Discard(4)
+		return frame{ack: true, accepted: 0}, nil
+	}
+	if err != nil {
+		return frame{}, err
+	}
+
+	dataLen := binary.BigEndian.Uint32(header[4:])
+	if dataLen > 64*1024 {
+		return frame{}, fmt.Errorf("command of %v Bytes is too long", dataLen)
+	}
+
+	l.conn.SetReadDeadline(time.Now().Add(zeroAckWait + time.Second))
+	packet := make([]byte, 8+int(dataLen)+4)
+	if _, err := io.ReadFull(l.reader, packet); err != nil {
+		return frame{}, err
+	}
+
+	request, err := teltonikaparser.DecodeCommandRequest(&packet)
+	if err != nil {
+		return frame{}, err
+	}
+	return frame{command: true, text: string(request.Command)}, nil
+}
+
+func (l *tcpLink) reply(response string) error {
+	packet, err := teltonikaparser.EncodeCommandResponse(response)
+	if err != nil {
+		return err
+	}
+	_, err = l.conn.Write(packet)
+	return err
+}
+
+func (l *tcpLink) close() error {
+	return l.conn.Close()
+}
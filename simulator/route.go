@@ -0,0 +1,95 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package simulator
+
+import (
+	"math"
+)
+
+// earthRadius is the mean Earth radius in meters
+const earthRadius = 6371000
+
+// Point is a position in degrees
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Route is a closed path, a device drives from the first point to the last one and then back to the first one
+type Route []Point
+
+// DefaultRoute is a loop of about 5 km around the centre of Brno
+var DefaultRoute = Route{
+	{Lat: 49.195060, Lng: 16.606837},
+	{Lat: 49.201140, Lng: 16.602640},
+	{Lat: 49.210262, Lng: 16.598590},
+	{Lat: 49.211960, Lng: 16.612790},
+	{Lat: 49.204530, Lng: 16.626330},
+	{Lat: 49.193850, Lng: 16.622480},
+	{Lat: 49.188900, Lng: 16.613270},
+}
+
+// Length returns length of the route in meters including the way from the last point back to the first one
+func (r Route) Length() float64 {
+	length := 0.0
+	for i := range r {
+		length += Distance(r[i], r[(i+1)%len(r)])
+	}
+	return length
+}
+
+// Position returns the position and heading in degrees after driving distance meters from the first point, the route is driven repeatedly
+func (r Route) Position(distance float64) (Point, float64) {
+	if len(r) == 0 {
+		return Point{}, 0
+	}
+	length := r.Length()
+	if len(r) == 1 || length == 0 {
+		return r[0], 0
+	}
+
+	distance = math.Mod(distance, length)
+	if distance < 0 {
+		distance += length
+	}
+
+	for i := range r {
+		from, to := r[i], r[(i+1)%len(r)]
+		segment := Distance(from, to)
+		if distance <= segment && segment > 0 {
+			// segments are short, a linear interpolation is accurate enough
+			f := distance / segment
+			position := Point{Lat: from.Lat + (to.Lat-from.Lat)*f, Lng: from.Lng + (to.Lng-from.Lng)*f}
+			return position, Bearing(from, to)
+		}
+		distance -= segment
+	}
+
+	return r[0], Bearing(r[0], r[1])
+}
+
+// Distance returns the great-circle distance of two points in meters
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Bearing returns the initial heading from a to b in degrees, 0 is north, increasing clock-wise
+func Bearing(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLng := radians(b.Lng - a.Lng)
+
+	y := math.Sin(dLng) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLng)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
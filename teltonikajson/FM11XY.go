@@ -74,14 +74,21 @@ const FM11XY string = `{
 	   "Description":"States: 0 – GPS module is turned off, 2 – working, but no fix, 3 – working with GPS fix, 4 – GPS module is in sleep state, 5 – antenna is short circuit",
 	   "Parametr Group":"M",
 	   "Type":"Unsigned",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"GPS Off",
+	      "2":"Working, No Fix",
+	      "3":"Working, With Fix",
+	      "4":"GPS Sleep",
+	      "5":"Antenna Short Circuit"
+	   }
 	},
 	"71":{
 	   "PropertyName":"Dallas Temperature ID 4",
 	   "Bytes":"8",
 	   "Description":"Dallas sensor ID number",
 	   "Parametr Group":"M",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"72":{
 	   "PropertyName":"Dallas Temperature 1",
@@ -116,28 +123,28 @@ const FM11XY string = `{
 	   "Bytes":"8",
 	   "Description":"Dallas sensor ID number",
 	   "Parametr Group":"M",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"77":{
 	   "PropertyName":"Dallas Temperature ID 2",
 	   "Bytes":"8",
 	   "Description":"Dallas sensor ID number",
 	   "Parametr Group":"M",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"78":{
 	   "PropertyName":"iButton ID",
 	   "Bytes":"8",
 	   "Description":"iButton ID number",
 	   "Parametr Group":"M",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toIButton"
 	},
 	"79":{
 	   "PropertyName":"Dallas Temperature ID 3",
 	   "Bytes":"8",
 	   "Description":"Dallas sensor ID number",
 	   "Parametr Group":"M",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"80":{
 	   "PropertyName":"Data Mode",
@@ -145,7 +152,15 @@ const FM11XY string = `{
 	   "Description":"0 – home on stop, 1 – home on move, 2 – roaming on stop, 3 – roaming on move, 4 – unknown on stop, 5 – unknown on move",
 	   "Parametr Group":"M",
 	   "Type":"Unsigned",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Home On Stop",
+	      "1":"Home On Moving",
+	      "2":"Roaming On Stop",
+	      "3":"Roaming On Moving",
+	      "4":"Unknown On Stop",
+	      "5":"Unknown On Moving"
+	   }
 	},
 	"179":{
 	   "PropertyName":"Digital Output 1 state",
@@ -193,7 +208,11 @@ const FM11XY string = `{
 	   "Description":"0 – not deep sleep mode, 1 – deep sleep mode",
 	   "Parametr Group":"M",
 	   "Type":"Unsigned",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Not Deep Sleep",
+	      "1":"Deep Sleep"
+	   }
 	},
 	"205":{
 	   "PropertyName":"Cell ID",
@@ -217,7 +236,11 @@ const FM11XY string = `{
 	   "Description":"0 – ignition off, 1 – ignition on",
 	   "Parametr Group":"M",
 	   "Type":"Unsigned",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Ignition Off",
+	      "1":"Ignition On"
+	   }
 	},
 	"240":{
 	   "PropertyName":"Movement Sensor",
@@ -225,7 +248,11 @@ const FM11XY string = `{
 	   "Description":"0 – not moving, 1 – moving",
 	   "Parametr Group":"M",
 	   "Type":"Unsigned",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Movement Off",
+	      "1":"Movement On"
+	   }
 	},
 	"241":{
 	   "PropertyName":"GSM Operator Code",
@@ -297,7 +324,15 @@ const FM11XY string = `{
 	   "Description":"Door status value: Min – 0, Max – 16128 Door status is represented as bitmask converted to decimal value. Possible values: 0 – all doors closed, 0x100 (256) – front left door is opened, 0x200 (512) – front right door is opened, 0x400 (1024) – rear left door is opened, 0x800 (2048) – rear right door is opened, 0x1000 (4096) – hood is opened, 0x2000 (8192) – trunk is opened, 0x3F00 (16128) – all doors are opened, or combinations of values",
 	   "Parametr Group":"A2",
 	   "Type":"Unsigned",
-	   "FinalConversion":"toUint16"
+	   "FinalConversion":"toUint16",
+	   "Bits":{
+	      "Front Left Door":"8",
+	      "Front Right Door":"9",
+	      "Rear Left Door":"10",
+	      "Rear Right Door":"11",
+	      "Hood":"12",
+	      "Trunk":"13"
+	   }
 	},
 	"100":{
 	   "PropertyName":"LVCAN Program Number",
@@ -312,7 +347,7 @@ const FM11XY string = `{
 	   "Bytes":"8",
 	   "Description":"Module ID",
 	   "Parametr Group":"A2",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"102":{
 	   "PropertyName":"LVC Engine Work Time",
@@ -431,14 +466,38 @@ const FM11XY string = `{
 	   "Bytes":"4",
 	   "Description":"Control state flags Byte0 (LSB): 0x01 – STOP 0x02 – Oil pressure / level 0x04 – Coolant liquid temperature / level 0x08 – Handbrake system 0x10 – Battery charging 0x20 – AIRBAG Byte1:0x01 – CHECK ENGINE 0x02 – Lights failure 0x04 – Low tire pressure 0x08 – Wear of brake pads 0x10 – Warning 0x20 – ABS 0x40 – Low Fuel Byte2:0x01 – ESP 0x02 – Glow plug indicator 0x04 – FAP 0x08 – Electronics pressure control 0x10 – Parking lights 0x20 – Dipped headlights 0x40 – Full beam headlights Byte3: 0x40 – Passenger's seat belt 0x80 – Driver's seat belt",
 	   "Parametr Group":"A2",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex",
+	   "Bits":{
+	      "STOP":"0",
+	      "Oil Pressure Or Level":"1",
+	      "Coolant Temperature Or Level":"2",
+	      "Handbrake System":"3",
+	      "Battery Charging":"4",
+	      "Airbag":"5",
+	      "Check Engine":"8",
+	      "Lights Failure":"9",
+	      "Low Tire Pressure":"10",
+	      "Brake Pads Wear":"11",
+	      "Warning":"12",
+	      "ABS":"13",
+	      "Low Fuel":"14",
+	      "ESP":"16",
+	      "Glow Plug Indicator":"17",
+	      "FAP":"18",
+	      "Electronics Pressure Control":"19",
+	      "Parking Lights":"20",
+	      "Dipped Headlights":"21",
+	      "Full Beam Headlights":"22",
+	      "Passenger Seat Belt":"30",
+	      "Driver Seat Belt":"31"
+	   }
 	},
 	"124":{
 	   "PropertyName":"LVC Agricultural Machinery Flags",
 	   "Bytes":"8",
 	   "Description":"Agricultural machinery flags Byte0 (LSB): 0x01 – Mowing 0x02 – Grain release from hopper 0x04 – First front hydraulic turned on 0x08 – Rear Power Take-Off turned on Byte1: 0x01 – Excessive play under the threshing drum 0x02 – Grain tank is open 0x04 – 100% of Grain tank 0x08 – 70% of Grain tank 0x10 – Drain filter in hydraulic system of drive cylinders is plugged 0x20 – Pressure filter of drive cylinders hydraulic system is plugged 0x40 – Alarm oil level in oil tank 0x80 – Pressure filter of brakes hydraulic system is plugged Byte2: 0x01 – Oil filter of engine is plugged 0x02 – Fuel filter is plugged 0x04 – Air filter is plugged 0x08 – Alarm oil temperature in hydraulic system of chasis 0x10 – Alarm oil temperature in hydraulic system of drive cylinders 0x20 – Alarm oil pressure in engine 0x40 – Alarm coolant level 0x80 – Overflow chamber of hydraulic unit Byte3: 0x01 – Unloader drive is ON. Unloading tube pivot is in idle position 0x02 – No operator! 0x04 – Straw walker is plugged 0x08 – Water in fuel 0x10 – Cleaning fan RPM 0x20 – Trashing drum RPM Byte4:0x02 – Low water level in the tank 0x04 – First rear hydraulic turned on 0x08 – Standalone engine working 0x10 – Right joystick moved right 0x20 – Right joystick moved left 0x40 – Right joystick moved front 0x80 – Right joystick moved back Byte5: 0x01 – Brushes turned on 0x02 – Water supply turned on 0x04 – Vacuum cleaner  0x08 – Unloading from the hopper 0x10 – High Pressure washer (Karcher) 0x20 – Salt (sand) disperser ON 0x40 – Low salt (sand) level Byte6: 0x01 – Second front hydraulic turned on 0x02 – Third front hydraulic turned on 0x04 – Fourth front hydraulic turned on 0x08 – Second rear hydraulic turned on 0x10 – Third rear hydraulic turned on 0x20 – Fourth rear hydraulic turned on 0x40 – Front three-point Hitch turned on 0x80 – Rear three-point Hitch turned on Byte7:0x01 – Left joystick moved right 0x02 – Left joystick moved left 0x04 – Left joystick moved front 0x08 – Left joystick moved back 0x10 – Front Power Take-Off turned on",
 	   "Parametr Group":"A2",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"125":{
 	   "PropertyName":"LVC Harvesting Time",
@@ -501,7 +560,36 @@ const FM11XY string = `{
 	   "Bytes":"8",
 	   "Description":"Security State Flag Byte0 (LSB): Every two bits in this byte correspond to a different CAN bus number. 00 – CAN not connected, connection not required 01 – CAN connected, but currently module not received data 10 – CAN not connected, require connection 11 – CAN connectedExample: Byte0 - 0F hex – 00001111 binary CAN4, CAN3, CAN2, CAN1 Byte1: Not used Byte2: 0x20 – bit appears when any operate button in car was put 0x40 – bit appears when immobilizer is in service mode 0x80 – immobiliser, bit appears during introduction of a programmed sequence of keys in the car. Byte3: 0x01 – the key is in ignition lock 0x02 – ignition on 0x04 – dynamic ignition on 0x08 – webasto 0x20 – car closed by factory's remote control 0x40 – factory-installed alarm system is actuated (is in panic mode) 0x80 – factory-installed alarm system is emulated by module Byte4: 0x01 – parking activated (automatic gearbox) 0x10 – handbrake is actuated (information available only with ignition on) 0x20 – footbrake is actuated (information available only with ignition on) 0x40 – engine is working (information available only when the ignition on) 0x80 – revers is on Byte5: 0x01 – Front left door opened 0x02 – Front right door opened 0x04 – Rear left door opened 0x08 – Rear right door opened 0x10 – engine cover opened 0x20 – trunk door opened Byte6: 0x01 – car was closed by the factory's remote control 0x02 – car was opened by the factory's remote control 0x03 – trunk cover was opened by the factory's remote control 0x04 – module has sent a rearming signal 0x05 – car was closed three times by the factory's remote control - High nibble (mask 0xF0 bit) 0x80 – CAN module goes to sleep mode Byte7: Not used",
 	   "Parametr Group":"A2",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex",
+	   "Bits":{
+	      "CAN1 State":"0-1",
+	      "CAN2 State":"2-3",
+	      "CAN3 State":"4-5",
+	      "CAN4 State":"6-7",
+	      "Operate Button Pressed":"21",
+	      "Immobilizer Service Mode":"22",
+	      "Immobilizer Key Sequence":"23",
+	      "Key In Ignition Lock":"24",
+	      "Ignition On":"25",
+	      "Dynamic Ignition On":"26",
+	      "Webasto":"27",
+	      "Closed By Factory Remote":"29",
+	      "Factory Alarm Actuated":"30",
+	      "Factory Alarm Emulated":"31",
+	      "Parking Activated":"32",
+	      "Handbrake Actuated":"36",
+	      "Footbrake Actuated":"37",
+	      "Engine Working":"38",
+	      "Reverse On":"39",
+	      "Front Left Door Opened":"40",
+	      "Front Right Door Opened":"41",
+	      "Rear Left Door Opened":"42",
+	      "Rear Right Door Opened":"43",
+	      "Engine Cover Opened":"44",
+	      "Trunk Door Opened":"45",
+	      "Factory Remote Event":"48-51",
+	      "CAN Module Sleep Mode":"55"
+	   }
 	},
 	"133":{
 	   "PropertyName":"LVC Tacho Total Vehicle Distance",
@@ -620,28 +708,28 @@ const FM11XY string = `{
 	   "Bytes":"8",
 	   "Description":"Driver1 ID High",
 	   "Parametr Group":"A2",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"148":{
 	   "PropertyName":"LVC Driver1 ID Low",
 	   "Bytes":"8",
 	   "Description":"Driver1 ID Low",
 	   "Parametr Group":"A2",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"149":{
 	   "PropertyName":"LVC Driver2 ID High",
 	   "Bytes":"8",
 	   "Description":"Driver2 ID High",
 	   "Parametr Group":"A2",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"150":{
 	   "PropertyName":"LVC Driver2 ID Low",
 	   "Bytes":"8",
 	   "Description":"Driver2 ID Low",
 	   "Parametr Group":"A2",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"151":{
 	   "PropertyName":"LVC Battery Temperature",
@@ -937,7 +1025,11 @@ const FM11XY string = `{
 	   "Description":"Event: 0 – target left zone, 1 – target entered zone",
 	   "Parametr Group":"ME",
 	   "Type":"Unsigned",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Target Left Zone",
+	      "1":"Target Entered Zone"
+	   }
 	},
 	"249":{
 	   "PropertyName":"Jamming",
@@ -945,7 +1037,11 @@ const FM11XY string = `{
 	   "Description":"1 – jamming start, 0 – jamming stop",
 	   "Parametr Group":"ME",
 	   "Type":"Unsigned",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Jamming Stop",
+	      "1":"Jamming Start"
+	   }
 	},
 	"250":{
 	   "PropertyName":"Trip",
@@ -953,7 +1049,11 @@ const FM11XY string = `{
 	   "Description":"1 – trip start, 0 – trip stop",
 	   "Parametr Group":"ME",
 	   "Type":"Unsigned",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Trip Stop",
+	      "1":"Trip Start"
+	   }
 	},
 	"251":{
 	   "PropertyName":"Immobilizer",
@@ -977,7 +1077,12 @@ const FM11XY string = `{
 	   "Description":"1 – harsh acceleration, 2 – harsh braking, 3 – harsh cornering",
 	   "Parametr Group":"ME",
 	   "Type":"Unsigned",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "1":"Harsh Acceleration",
+	      "2":"Harsh Braking",
+	      "3":"Harsh Cornering"
+	   }
 	},
 	"254":{
 	   "PropertyName":"Green driving value",
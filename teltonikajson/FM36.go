@@ -170,7 +170,15 @@ const FM36 string = `{
 	   "Description":"States:0 – GPS module is power off.1 – GPS antenna is disconnected.2 – Working, no GPS FIX.3 – Working, GPS FIX acquired.4 – GPS sleep.5 – GPS antenna is short circuited.",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"GPS Power Off",
+	      "1":"GPS Antenna Disconnected",
+	      "2":"Working, No Fix",
+	      "3":"Working, Fix Acquired",
+	      "4":"GPS Sleep",
+	      "5":"GPS Antenna Short Circuit"
+	   }
 	},
 	"72":{
 	   "No":"13",
@@ -226,7 +234,7 @@ const FM36 string = `{
 	   "Description":"Temperature sensor ID",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"76":{
 	   "No":"17",
@@ -240,7 +248,7 @@ const FM36 string = `{
 	   "Description":"Temperature sensor ID",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"77":{
 	   "No":"18",
@@ -254,7 +262,7 @@ const FM36 string = `{
 	   "Description":"Temperature sensor ID",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"78":{
 	   "No":"19",
@@ -268,7 +276,7 @@ const FM36 string = `{
 	   "Description":"iButton ID number",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toIButton"
 	},
 	"79":{
 	   "No":"20",
@@ -296,7 +304,15 @@ const FM36 string = `{
 	   "Description":"0 – home on stop,1 – home on move,2 – roaming on stop,3 – roaming on move,4 – unknown on stop,5 – unknown on move",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Home On Stop",
+	      "1":"Home On Moving",
+	      "2":"Roaming On Stop",
+	      "3":"Roaming On Moving",
+	      "4":"Unknown On Stop",
+	      "5":"Unknown On Moving"
+	   }
 	},
 	"99":{
 	   "No":"22",
@@ -394,7 +410,11 @@ const FM36 string = `{
 	   "Description":"0 – not deep sleep mode,1 – deep sleep mode",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Not Deep Sleep",
+	      "1":"Deep Sleep"
+	   }
 	},
 	"205":{
 	   "No":"29",
@@ -436,7 +456,11 @@ const FM36 string = `{
 	   "Description":"0 – ignition off,1 – ignition on",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Ignition Off",
+	      "1":"Ignition On"
+	   }
 	},
 	"240":{
 	   "No":"32",
@@ -450,7 +474,11 @@ const FM36 string = `{
 	   "Description":"0 – not moving,1 – moving",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Movement Off",
+	      "1":"Movement On"
+	   }
 	},
 	"241":{
 	   "No":"33",
@@ -590,7 +618,7 @@ const FM36 string = `{
 	   "Description":"Module identification",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"ALLCAN300/LVCAN200 I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"102":{
 	   "No":"43",
@@ -898,7 +926,31 @@ const FM36 string = `{
 	   "Description":"see LVCAN IO element values",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"ALLCAN300/LVCAN200 I/O elements",
-	   "FinalConversion":"toUint32"
+	   "FinalConversion":"toUint32",
+	   "Bits":{
+	      "STOP":"0",
+	      "Oil Pressure Or Level":"1",
+	      "Coolant Temperature Or Level":"2",
+	      "Handbrake System":"3",
+	      "Battery Charging":"4",
+	      "Airbag":"5",
+	      "Check Engine":"8",
+	      "Lights Failure":"9",
+	      "Low Tire Pressure":"10",
+	      "Brake Pads Wear":"11",
+	      "Warning":"12",
+	      "ABS":"13",
+	      "Low Fuel":"14",
+	      "ESP":"16",
+	      "Glow Plug Indicator":"17",
+	      "FAP":"18",
+	      "Electronics Pressure Control":"19",
+	      "Parking Lights":"20",
+	      "Dipped Headlights":"21",
+	      "Full Beam Headlights":"22",
+	      "Passenger Seat Belt":"30",
+	      "Driver Seat Belt":"31"
+	   }
 	},
 	"124":{
 	   "No":"65",
@@ -912,7 +964,7 @@ const FM36 string = `{
 	   "Description":"see LVCAN IO element values",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"ALLCAN300/LVCAN200 I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"125":{
 	   "No":"66",
@@ -1024,7 +1076,36 @@ const FM36 string = `{
 	   "Description":"see LVCAN IO element values",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"ALLCAN300/LVCAN200 I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex",
+	   "Bits":{
+	      "CAN1 State":"0-1",
+	      "CAN2 State":"2-3",
+	      "CAN3 State":"4-5",
+	      "CAN4 State":"6-7",
+	      "Operate Button Pressed":"21",
+	      "Immobilizer Service Mode":"22",
+	      "Immobilizer Key Sequence":"23",
+	      "Key In Ignition Lock":"24",
+	      "Ignition On":"25",
+	      "Dynamic Ignition On":"26",
+	      "Webasto":"27",
+	      "Closed By Factory Remote":"29",
+	      "Factory Alarm Actuated":"30",
+	      "Factory Alarm Emulated":"31",
+	      "Parking Activated":"32",
+	      "Handbrake Actuated":"36",
+	      "Footbrake Actuated":"37",
+	      "Engine Working":"38",
+	      "Reverse On":"39",
+	      "Front Left Door Opened":"40",
+	      "Front Right Door Opened":"41",
+	      "Rear Left Door Opened":"42",
+	      "Rear Right Door Opened":"43",
+	      "Engine Cover Opened":"44",
+	      "Trunk Door Opened":"45",
+	      "Factory Remote Event":"48-51",
+	      "CAN Module Sleep Mode":"55"
+	   }
 	},
 	"133":{
 	   "No":"74",
@@ -1234,7 +1315,7 @@ const FM36 string = `{
 	   "Description":"Driver1 ID High",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"ALLCAN300/LVCAN200 I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"148":{
 	   "No":"89",
@@ -1248,7 +1329,7 @@ const FM36 string = `{
 	   "Description":"Driver1 ID Low",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"ALLCAN300/LVCAN200 I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"149":{
 	   "No":"90",
@@ -1262,7 +1343,7 @@ const FM36 string = `{
 	   "Description":"Driver2 ID High",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"ALLCAN300/LVCAN200 I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"150":{
 	   "No":"91",
@@ -1276,7 +1357,7 @@ const FM36 string = `{
 	   "Description":"Driver2 ID Low",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"ALLCAN300/LVCAN200 I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"151":{
 	   "No":"92",
@@ -1318,7 +1399,15 @@ const FM36 string = `{
 	   "Description":"0 – all doors closed256 - front left door opened512 - front right door opened1024 - rear left door opened2048 - rear right door opened4096 - engine cover opened8192 - trunk door opened16128 - all doors opened",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"ALLCAN300/LVCAN200 I/O elements",
-	   "FinalConversion":"toUint16"
+	   "FinalConversion":"toUint16",
+	   "Bits":{
+	      "Front Left Door":"8",
+	      "Front Right Door":"9",
+	      "Rear Left Door":"10",
+	      "Rear Right Door":"11",
+	      "Hood":"12",
+	      "Trunk":"13"
+	   }
 	},
 	"160":{
 	   "No":"95",
@@ -1794,7 +1883,11 @@ const FM36 string = `{
 	   "Description":"Event:0 – target left zone,1 – target entered zone",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Eventual I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Target Left Zone",
+	      "1":"Target Entered Zone"
+	   }
 	},
 	"177":{
 	   "No":"129",
@@ -1822,7 +1915,11 @@ const FM36 string = `{
 	   "Description":"0 – not jammed,1 – jammed",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Eventual I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Not Jammed",
+	      "1":"Jammed"
+	   }
 	},
 	"250":{
 	   "No":"131",
@@ -1836,7 +1933,11 @@ const FM36 string = `{
 	   "Description":"1 – trip start,0 – trip stop",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Eventual I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Trip Stop",
+	      "1":"Trip Start"
+	   }
 	},
 	"251":{
 	   "No":"132",
@@ -1878,7 +1979,12 @@ const FM36 string = `{
 	   "Description":"1 – harsh acceleration,2 – harsh braking,3 - harsh cornering",
 	   "HWSupport":"FM3612, FM36M1",
 	   "Parametr Group":"Eventual I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "1":"Harsh Acceleration",
+	      "2":"Harsh Braking",
+	      "3":"Harsh Cornering"
+	   }
 	},
 	"254":{
 	   "No":"135",
@@ -16,7 +16,11 @@ const FM64 string = `{
 	   "Description":"0 – Ignition Off 1 – Ignition On",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Ignition Off",
+	      "1":"Ignition On"
+	   }
 	},
 	"240":{
 	   "No":"",
@@ -30,7 +34,11 @@ const FM64 string = `{
 	   "Description":"0 – Movement Off 1 – Movement On",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Movement Off",
+	      "1":"Movement On"
+	   }
 	},
 	"22":{
 	   "No":"",
@@ -72,7 +80,11 @@ const FM64 string = `{
 	   "Description":"Normal mode Deep Sleep",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Normal Mode",
+	      "1":"Deep Sleep"
+	   }
 	},
 	"71":{
 	   "No":"",
@@ -86,7 +98,15 @@ const FM64 string = `{
 	   "Description":"0 - GNSS OFF 1 - GNSS ON, no GPS antena 2 - GNSS ON, without fix 3 - GNSS ON, with fix 4 - GNSS SLEEP 5 - GNSS Overcurrent/protect state",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"GNSS OFF",
+	      "1":"GNSS ON, No Antenna",
+	      "2":"GNSS ON, Without Fix",
+	      "3":"GNSS ON, With Fix",
+	      "4":"GNSS Sleep",
+	      "5":"GNSS Overcurrent"
+	   }
 	},
 	"181":{
 	   "No":"",
@@ -338,7 +358,7 @@ const FM64 string = `{
 	   "Description":"Value of SIM ICCID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toICCID"
 	},
 	"220":{
 	   "No":"",
@@ -352,7 +372,7 @@ const FM64 string = `{
 	   "Description":"Value of SIM ICCID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toICCID"
 	},
 	"221":{
 	   "No":"",
@@ -366,7 +386,7 @@ const FM64 string = `{
 	   "Description":"Value of SIM ICCID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toICCID"
 	},
 	"144":{
 	   "No":"",
@@ -506,7 +526,7 @@ const FM64 string = `{
 	   "Description":"Dallas sensor ID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"63":{
 	   "No":"",
@@ -520,7 +540,7 @@ const FM64 string = `{
 	   "Description":"Dallas sensor ID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"64":{
 	   "No":"",
@@ -534,7 +554,7 @@ const FM64 string = `{
 	   "Description":"Dallas sensor ID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"65":{
 	   "No":"",
@@ -548,7 +568,7 @@ const FM64 string = `{
 	   "Description":"Dallas sensor ID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"78":{
 	   "No":"",
@@ -562,7 +582,7 @@ const FM64 string = `{
 	   "Description":"iButton ID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toIButton"
 	},
 	"207":{
 	   "No":"",
@@ -576,7 +596,7 @@ const FM64 string = `{
 	   "Description":"RFID ID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"201":{
 	   "No":"",
@@ -828,7 +848,7 @@ const FM64 string = `{
 	   "Description":"Dallas sensor ID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"6":{
 	   "No":"",
@@ -856,7 +876,7 @@ const FM64 string = `{
 	   "Description":"Dallas sensor ID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"8":{
 	   "No":"",
@@ -898,7 +918,7 @@ const FM64 string = `{
 	   "Description":"RFID ID on COM2",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"218":{
 	   "No":"",
@@ -1752,7 +1772,15 @@ const FM64 string = `{
 	   "Description":"Min – 0, Max – 16128 Door status is represented as bitmask converted to decimal value. Possible values: 0 – all doors closed,0x100 (256) – front left door is opened,0x200 (512) – front right door is opened,0x400 (1024) – rear left door is opened,0x800 (2048) – rear right door is opened,0x1000 (4096) – hood is opened,0x2000 (8192) – trunk is opened,0x3F00 (16128) – all doors are opened, or combinations of values",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"CAN adapters elements",
-	   "FinalConversion":"toUint16"
+	   "FinalConversion":"toUint16",
+	   "Bits":{
+	      "Front Left Door":"8",
+	      "Front Right Door":"9",
+	      "Rear Left Door":"10",
+	      "Rear Right Door":"11",
+	      "Hood":"12",
+	      "Trunk":"13"
+	   }
 	},
 	"12":{
 	   "No":"",
@@ -1780,7 +1808,7 @@ const FM64 string = `{
 	   "Description":"Module ID",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"CAN adapters elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"14":{
 	   "No":"",
@@ -1990,7 +2018,31 @@ const FM64 string = `{
 	   "Description":"Control state flags",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"CAN adapters elements",
-	   "FinalConversion":"toUint32"
+	   "FinalConversion":"toUint32",
+	   "Bits":{
+	      "STOP":"0",
+	      "Oil Pressure Or Level":"1",
+	      "Coolant Temperature Or Level":"2",
+	      "Handbrake System":"3",
+	      "Battery Charging":"4",
+	      "Airbag":"5",
+	      "Check Engine":"8",
+	      "Lights Failure":"9",
+	      "Low Tire Pressure":"10",
+	      "Brake Pads Wear":"11",
+	      "Warning":"12",
+	      "ABS":"13",
+	      "Low Fuel":"14",
+	      "ESP":"16",
+	      "Glow Plug Indicator":"17",
+	      "FAP":"18",
+	      "Electronics Pressure Control":"19",
+	      "Parking Lights":"20",
+	      "Dipped Headlights":"21",
+	      "Full Beam Headlights":"22",
+	      "Passenger Seat Belt":"30",
+	      "Driver Seat Belt":"31"
+	   }
 	},
 	"39":{
 	   "No":"",
@@ -2004,7 +2056,7 @@ const FM64 string = `{
 	   "Description":"Agricultural machinery flags",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"CAN adapters elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex"
 	},
 	"40":{
 	   "No":"",
@@ -2116,7 +2168,36 @@ const FM64 string = `{
 	   "Description":"Security State Flag",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"CAN adapters elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toHex",
+	   "Bits":{
+	      "CAN1 State":"0-1",
+	      "CAN2 State":"2-3",
+	      "CAN3 State":"4-5",
+	      "CAN4 State":"6-7",
+	      "Operate Button Pressed":"21",
+	      "Immobilizer Service Mode":"22",
+	      "Immobilizer Key Sequence":"23",
+	      "Key In Ignition Lock":"24",
+	      "Ignition On":"25",
+	      "Dynamic Ignition On":"26",
+	      "Webasto":"27",
+	      "Closed By Factory Remote":"29",
+	      "Factory Alarm Actuated":"30",
+	      "Factory Alarm Emulated":"31",
+	      "Parking Activated":"32",
+	      "Handbrake Actuated":"36",
+	      "Footbrake Actuated":"37",
+	      "Engine Working":"38",
+	      "Reverse On":"39",
+	      "Front Left Door Opened":"40",
+	      "Front Right Door Opened":"41",
+	      "Rear Left Door Opened":"42",
+	      "Rear Right Door Opened":"43",
+	      "Engine Cover Opened":"44",
+	      "Trunk Door Opened":"45",
+	      "Factory Remote Event":"48-51",
+	      "CAN Module Sleep Mode":"55"
+	   }
 	},
 	"141":{
 	   "No":"",
@@ -2928,7 +3009,11 @@ const FM64 string = `{
 	   "Description":"0 – target left zone 1 – target entered zone",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Eventual I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Target Left Zone",
+	      "1":"Target Entered Zone"
+	   }
 	},
 	"250":{
 	   "No":"",
@@ -2942,7 +3027,11 @@ const FM64 string = `{
 	   "Description":"1 – trip start; 0 – trip stop.",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Permanent I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Trip Stop",
+	      "1":"Trip Start"
+	   }
 	},
 	"255":{
 	   "No":"",
@@ -2984,7 +3073,12 @@ const FM64 string = `{
 	   "Description":"1 – harsh acceleration, 2 – harsh braking, 3 – harsh cornering",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Eventual I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "1":"Harsh Acceleration",
+	      "2":"Harsh Braking",
+	      "3":"Harsh Cornering"
+	   }
 	},
 	"246":{
 	   "No":"",
@@ -2998,7 +3092,11 @@ const FM64 string = `{
 	   "Description":"0 – steady,1 – towing",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Eventual I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Steady",
+	      "1":"Towing"
+	   }
 	},
 	"248":{
 	   "No":"",
@@ -3026,7 +3124,14 @@ const FM64 string = `{
 	   "Description":"1 – Crash detected 2 – limited crash trace (device not calibrated) 3 - limited crash trace (device is calibrated) 4 - full crash trace (device not calibrated) 5 - full crash trace (device is calibrated)",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Eventual I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "1":"Crash Detected",
+	      "2":"Limited Crash Trace (device not calibrated)",
+	      "3":"Limited Crash Trace (device is calibrated)",
+	      "4":"Full Crash Trace (device not calibrated)",
+	      "5":"Full Crash Trace (device is calibrated)"
+	   }
 	},
 	"251":{
 	   "No":"",
@@ -3040,7 +3145,12 @@ const FM64 string = `{
 	   "Description":"0 – iButton not connected 1 – iButton connected (Immobilizer) 2 – iButton connected (Authorized Driving)",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Eventual I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"iButton Not Connected",
+	      "1":"iButton Connected (Immobilizer)",
+	      "2":"iButton Connected (Authorized Driving)"
+	   }
 	},
 	"254":{
 	   "No":"",
@@ -3068,7 +3178,11 @@ const FM64 string = `{
 	   "Description":"1 – jamming start 0 – jamming stop",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Eventual I/O elements",
-	   "FinalConversion":"toUint8"
+	   "FinalConversion":"toUint8",
+	   "Values":{
+	      "0":"Jamming Stop",
+	      "1":"Jamming Start"
+	   }
 	},
 	"252":{
 	   "No":"",
@@ -4174,7 +4288,7 @@ const FM64 string = `{
 		"Description":"Driver1 ID High",
 		"HWSupport":"FMB640",
 		"Parametr Group":"LVCAN",
-		"FinalConversion":"to[]byte"
+		"FinalConversion":"toASCII"
 	},
 	"230":{
 		"No":"",
@@ -4188,7 +4302,7 @@ const FM64 string = `{
 		"Description":"Driver1 ID Low",
 		"HWSupport":"FMB640",
 		"Parametr Group":"LVCAN",
-		"FinalConversion":"to[]byte"
+		"FinalConversion":"toASCII"
 	},
 	"108":{
 		"No":"",
@@ -4202,7 +4316,7 @@ const FM64 string = `{
 		"Description":"Driver2 ID High",
 		"HWSupport":"FMB640",
 		"Parametr Group":"LVCAN",
-		"FinalConversion":"to[]byte"
+		"FinalConversion":"toASCII"
 	},
 	"109":{
 		"No":"",
@@ -4216,7 +4330,7 @@ const FM64 string = `{
 		"Description":"4 ASCII bytes (Version format – ab.cd)",
 		"HWSupport":"FMB640",
 		"Parametr Group":"LVCAN",
-		"FinalConversion":"to[]byte"
+		"FinalConversion":"toASCII"
 	},
 	"140":{
 		"No":"",
@@ -4230,7 +4344,7 @@ const FM64 string = `{
 		"Description":"Driver2 ID Low",
 		"HWSupport":"FMB640",
 		"Parametr Group":"LVCAN",
-		"FinalConversion":"to[]byte"
+		"FinalConversion":"toASCII"
 	},
 	"184":{
 	   "No":"",
@@ -4398,7 +4512,7 @@ const FM64 string = `{
 	   "Description":"",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Tachograph data elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"232":{
 	   "No":"",
@@ -4412,7 +4526,7 @@ const FM64 string = `{
 	   "Description":"",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Tachograph data elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"233":{
 	   "No":"",
@@ -4426,7 +4540,7 @@ const FM64 string = `{
 	   "Description":"",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Tachograph data elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toVIN"
 	},
 	"234":{
 	   "No":"",
@@ -4440,7 +4554,7 @@ const FM64 string = `{
 	   "Description":"",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Tachograph data elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toVIN"
 	},
 	"235":{
 	   "No":"",
@@ -4496,7 +4610,7 @@ const FM64 string = `{
 	   "Description":"",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Tachograph data elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"196":{
 	   "No":"",
@@ -4510,7 +4624,7 @@ const FM64 string = `{
 	   "Description":"",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Tachograph data elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"197":{
 	   "No":"",
@@ -4524,7 +4638,7 @@ const FM64 string = `{
 	   "Description":"",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Tachograph data elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"198":{
 	   "No":"",
@@ -4538,7 +4652,7 @@ const FM64 string = `{
 	   "Description":"",
 	   "HWSupport":"FMB640",
 	   "Parametr Group":"Tachograph data elements",
-	   "FinalConversion":"to[]byte"
+	   "FinalConversion":"toASCII"
 	},
 	"56":{
 	   "No":"",
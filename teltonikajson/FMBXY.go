@@ -114,7 +114,15 @@ const FMBXY string = `{
        "Description":"Door status value: Min – 0, Max – 16128 Door status is represented as bitmask converted to decimal value. Possible values: 0 – all doors closed, 0x100 (256) – front left door is opened, 0x200 (512) – front right door is opened, 0x400 (1024) – rear left door is opened, 0x800 (2048) – rear right door is opened, 0x1000 (4096) – hood is opened, 0x2000 (8192) – trunk is opened, 0x3F00 (16128) – all doors are opened, or combinations of values",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"LVCAN elements",
-       "FinalConversion":"toUint16"
+       "FinalConversion":"toUint16",
+       "Bits":{
+          "Front Left Door":"8",
+          "Front Right Door":"9",
+          "Rear Left Door":"10",
+          "Rear Right Door":"11",
+          "Hood":"12",
+          "Trunk":"13"
+       }
     },
     "100":{
        "No":"114",
@@ -142,7 +150,7 @@ const FMBXY string = `{
        "Description":"Module ID",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"LVCAN elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toHex"
     },
     "102":{
        "No":"116",
@@ -352,7 +360,31 @@ const FMBXY string = `{
        "Description":"Control state flags",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"LVCAN elements",
-       "FinalConversion":"toUint32"
+       "FinalConversion":"toUint32",
+       "Bits":{
+          "STOP":"0",
+          "Oil Pressure Or Level":"1",
+          "Coolant Temperature Or Level":"2",
+          "Handbrake System":"3",
+          "Battery Charging":"4",
+          "Airbag":"5",
+          "Check Engine":"8",
+          "Lights Failure":"9",
+          "Low Tire Pressure":"10",
+          "Brake Pads Wear":"11",
+          "Warning":"12",
+          "ABS":"13",
+          "Low Fuel":"14",
+          "ESP":"16",
+          "Glow Plug Indicator":"17",
+          "FAP":"18",
+          "Electronics Pressure Control":"19",
+          "Parking Lights":"20",
+          "Dipped Headlights":"21",
+          "Full Beam Headlights":"22",
+          "Passenger Seat Belt":"30",
+          "Driver Seat Belt":"31"
+       }
     },
     "124":{
        "No":"131",
@@ -366,7 +398,7 @@ const FMBXY string = `{
        "Description":"Agricultural machinery flags",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"LVCAN elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toHex"
     },
     "125":{
        "No":"132",
@@ -478,7 +510,36 @@ const FMBXY string = `{
        "Description":"Security State Flag",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"LVCAN elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toHex",
+       "Bits":{
+          "CAN1 State":"0-1",
+          "CAN2 State":"2-3",
+          "CAN3 State":"4-5",
+          "CAN4 State":"6-7",
+          "Operate Button Pressed":"21",
+          "Immobilizer Service Mode":"22",
+          "Immobilizer Key Sequence":"23",
+          "Key In Ignition Lock":"24",
+          "Ignition On":"25",
+          "Dynamic Ignition On":"26",
+          "Webasto":"27",
+          "Closed By Factory Remote":"29",
+          "Factory Alarm Actuated":"30",
+          "Factory Alarm Emulated":"31",
+          "Parking Activated":"32",
+          "Handbrake Actuated":"36",
+          "Footbrake Actuated":"37",
+          "Engine Working":"38",
+          "Reverse On":"39",
+          "Front Left Door Opened":"40",
+          "Front Right Door Opened":"41",
+          "Rear Left Door Opened":"42",
+          "Rear Right Door Opened":"43",
+          "Engine Cover Opened":"44",
+          "Trunk Door Opened":"45",
+          "Factory Remote Event":"48-51",
+          "CAN Module Sleep Mode":"55"
+       }
     },
     "133":{
        "No":"140",
@@ -688,7 +749,7 @@ const FMBXY string = `{
        "Description":"Driver1 ID High",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"LVCAN elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toASCII"
     },
     "148":{
        "No":"155",
@@ -702,7 +763,7 @@ const FMBXY string = `{
        "Description":"Driver1 ID Low",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"LVCAN elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toASCII"
     },
     "149":{
        "No":"156",
@@ -716,7 +777,7 @@ const FMBXY string = `{
        "Description":"Driver2 ID High",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"LVCAN elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toASCII"
     },
     "150":{
        "No":"157",
@@ -730,7 +791,7 @@ const FMBXY string = `{
        "Description":"Driver2 ID Low",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"LVCAN elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toASCII"
     },
     "151":{
        "No":"158",
@@ -1878,7 +1939,11 @@ const FMBXY string = `{
        "Description":"0 – target left zone 1 – target entered zone",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010, TMT250",
        "Parametr Group":"Eventual I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "0":"Target Left Zone",
+          "1":"Target Entered Zone"
+       }
     },
     "250":{
        "No":"240",
@@ -1892,7 +1957,13 @@ const FMBXY string = `{
        "Description":"1 – trip start, 0 – trip stop. From 01.00.24 fw version available with BT app new values: 2 – Business Status; 3 – Private Status; 4-9 – Custom Statuses",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010",
        "Parametr Group":"Eventual I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "0":"Trip Stop",
+          "1":"Trip Start",
+          "2":"Business Status",
+          "3":"Private Status"
+       }
     },
     "255":{
        "No":"241",
@@ -1920,7 +1991,11 @@ const FMBXY string = `{
        "Description":"0 - moving 1 - idling",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010",
        "Parametr Group":"Eventual I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "0":"Moving",
+          "1":"Idling"
+       }
     },
     "253":{
        "No":"243",
@@ -1934,7 +2009,12 @@ const FMBXY string = `{
        "Description":"1 – harsh acceleration 2 – harsh braking 3 – harsh cornering",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010",
        "Parametr Group":"Eventual I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "1":"Harsh Acceleration",
+          "2":"Harsh Braking",
+          "3":"Harsh Cornering"
+       }
     },
     "246":{
        "No":"244",
@@ -1948,7 +2028,11 @@ const FMBXY string = `{
        "Description":"0 – steady 1 – towing",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010",
        "Parametr Group":"Eventual I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "0":"Steady",
+          "1":"Towing"
+       }
     },
     "252":{
        "No":"245",
@@ -1962,7 +2046,11 @@ const FMBXY string = `{
        "Description":"0 – battery present 1 – battery unpluged",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964",
        "Parametr Group":"Eventual I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "0":"Battery Present",
+          "1":"Battery Unplugged"
+       }
     },
     "247":{
        "No":"246",
@@ -1976,7 +2064,14 @@ const FMBXY string = `{
        "Description":"1 – crash 2 – limited crash trace (device not calibrated) 3 - limited crash trace (device is calibrated) 4 - full crash trace (device not calibrated) 5 - full crash trace (device is calibrated)",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010",
        "Parametr Group":"Eventual I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "1":"Crash Detected",
+          "2":"Limited Crash Trace (device not calibrated)",
+          "3":"Limited Crash Trace (device is calibrated)",
+          "4":"Full Crash Trace (device not calibrated)",
+          "5":"Full Crash Trace (device is calibrated)"
+       }
     },
     "248":{
        "No":"247",
@@ -2018,7 +2113,11 @@ const FMBXY string = `{
        "Description":"1 - jamming start 0 - jamming stop",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010",
        "Parametr Group":"Eventual I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "0":"Jamming Stop",
+          "1":"Jamming Start"
+       }
     },
     "14":{
        "No":"250",
@@ -2032,7 +2131,7 @@ const FMBXY string = `{
        "Description":"Value of SIM ICCID, LSB",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010",
        "Parametr Group":"Eventual I/O elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toICCID"
     },
     "243":{
        "No":"251",
@@ -2115,7 +2214,8 @@ const FMBXY string = `{
        "Units":"-",
        "Description":"Fault Codes (values separated via ,)",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010",
-       "Parametr Group":"OBD elements"
+       "Parametr Group":"OBD elements",
+       "FinalConversion":"toASCII"
     },
     "303":{
        "No":"257",
@@ -2155,7 +2255,11 @@ const FMBXY string = `{
        "Description":"0 - Ignition Off 1 - Ignition On",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "0":"Ignition Off",
+          "1":"Ignition On"
+       }
     },
     "240":{
        "No":"2",
@@ -2169,7 +2273,11 @@ const FMBXY string = `{
        "Description":"0 - Movement Off 1 - Movement On",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010, TMT250",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "0":"Movement Off",
+          "1":"Movement On"
+       }
     },
     "80":{
        "No":"3",
@@ -2183,7 +2291,15 @@ const FMBXY string = `{
        "Description":"0 – Home On Stop 1 – Home On Moving 2 – Roaming On Stop 3 – Roaming On Moving 4 – Unknown On Stop 5 – Unknown On Moving",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010, TMT250",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "0":"Home On Stop",
+          "1":"Home On Moving",
+          "2":"Roaming On Stop",
+          "3":"Roaming On Moving",
+          "4":"Unknown On Stop",
+          "5":"Unknown On Moving"
+       }
     },
     "21":{
        "No":"4",
@@ -2211,7 +2327,13 @@ const FMBXY string = `{
        "Description":"0 - No Sleep 1 – GPS Sleep 2 – Deep Sleep 3 – Online Sleep",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010, TMT250",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "0":"No Sleep",
+          "1":"GPS Sleep",
+          "2":"Deep Sleep",
+          "3":"Online Sleep"
+       }
     },
     "69":{
        "No":"6",
@@ -2225,7 +2347,13 @@ const FMBXY string = `{
        "Description":"0 - OFF 1 – ON with fix 2 - ON without fix 3 - In sleep state",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010, TMT250",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"toUint8"
+       "FinalConversion":"toUint8",
+       "Values":{
+          "0":"OFF",
+          "1":"ON With Fix",
+          "2":"ON Without Fix",
+          "3":"In Sleep State"
+       }
     },
     "181":{
        "No":"7",
@@ -2505,7 +2633,7 @@ const FMBXY string = `{
        "Description":"Value of SIM ICCID, MSB",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010, TMT250",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toICCID"
     },
     "10":{
        "No":"27",
@@ -2645,7 +2773,7 @@ const FMBXY string = `{
        "Description":"Dallas sensor ID",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toHex"
     },
     "77":{
        "No":"37",
@@ -2659,7 +2787,7 @@ const FMBXY string = `{
        "Description":"Dallas sensor ID",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toHex"
     },
     "79":{
        "No":"38",
@@ -2673,7 +2801,7 @@ const FMBXY string = `{
        "Description":"Dallas sensor ID",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toHex"
     },
     "71":{
        "No":"39",
@@ -2687,7 +2815,7 @@ const FMBXY string = `{
        "Description":"Dallas sensor ID",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toHex"
     },
     "78":{
        "No":"40",
@@ -2701,7 +2829,7 @@ const FMBXY string = `{
        "Description":"iButton ID",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toIButton"
     },
     "207":{
        "No":"41",
@@ -2715,7 +2843,7 @@ const FMBXY string = `{
        "Description":"RFID ID",
        "HWSupport":"FMB125",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toHex"
     },
     "201":{
        "No":"42",
@@ -2911,7 +3039,7 @@ const FMBXY string = `{
        "Description":"MAC address of NMEA receiver device connected via Bluetooth",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toHex"
     },
     "25":{
        "No":"56",
@@ -3121,7 +3249,7 @@ const FMBXY string = `{
        "Description":"If ID is shown in this I/O that means that attached iButton is in iButton List",
        "HWSupport":"FMB100, FMB110, FMB120, FMB122, FMB125",
        "Parametr Group":"Permanent I/O elements",
-       "FinalConversion":"to[]byte"
+       "FinalConversion":"toIButton"
     },
     "5":{
        "No":"71",
@@ -3177,7 +3305,7 @@ const FMBXY string = `{
        "Description":"VIN number",
        "HWSupport":"FMB001, FMB010, FMB100, FMB110, FMB120, FMB122, FMB125, FMB900, FMB920, FMB962, FMB964, FM3001, FM3010",
        "Parametr Group":"OBD elements",
-       "FinalConversion":"toString"
+       "FinalConversion":"toVIN"
     },
     "30":{
        "No":"75",
@@ -0,0 +1,277 @@
+// this file is used to store JSON
+
+package teltonikajson
+
+// FMBXYParams holds JSON representation of configuration parameters for devices family FMBXY.
+// Min and Max are the range of Unsigned values and the length of String values.
+const FMBXYParams string = `{
+	"102":{
+	   "PropertyName":"Sleep Mode",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"4",
+	   "Units":"-",
+	   "Description":"0 – disabled, 1 – GPS sleep, 2 – deep sleep, 3 – online deep sleep, 4 – ultra deep sleep",
+	   "Parametr Group":"System",
+	   "Values":{
+	      "0":"Disabled",
+	      "1":"GPS Sleep",
+	      "2":"Deep Sleep",
+	      "3":"Online Deep Sleep",
+	      "4":"Ultra Deep Sleep"
+	   }
+	},
+	"2001":{
+	   "PropertyName":"APN",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"32",
+	   "Units":"-",
+	   "Description":"Access point name of the SIM card operator",
+	   "Parametr Group":"GPRS"
+	},
+	"2002":{
+	   "PropertyName":"APN Username",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"30",
+	   "Units":"-",
+	   "Description":"User name of the access point, empty if it is not required",
+	   "Parametr Group":"GPRS"
+	},
+	"2003":{
+	   "PropertyName":"APN Password",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"30",
+	   "Units":"-",
+	   "Description":"Password of the access point, empty if it is not required",
+	   "Parametr Group":"GPRS"
+	},
+	"2004":{
+	   "PropertyName":"Domain",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"55",
+	   "Units":"-",
+	   "Description":"Domain or IP address of the server",
+	   "Parametr Group":"Server"
+	},
+	"2005":{
+	   "PropertyName":"Port",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"65535",
+	   "Units":"-",
+	   "Description":"Port of the server",
+	   "Parametr Group":"Server"
+	},
+	"2006":{
+	   "PropertyName":"Protocol",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"1",
+	   "Units":"-",
+	   "Description":"0 – TCP, 1 – UDP",
+	   "Parametr Group":"Server",
+	   "Values":{
+	      "0":"TCP",
+	      "1":"UDP"
+	   }
+	},
+	"10000":{
+	   "PropertyName":"Home On Stop Min Period",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"2592000",
+	   "Units":"s",
+	   "Description":"Period of records when the vehicle stands in the home network, 0 disables it",
+	   "Parametr Group":"Data Acquisition"
+	},
+	"10050":{
+	   "PropertyName":"Home Moving Min Period",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"2592000",
+	   "Units":"s",
+	   "Description":"Period of records when the vehicle moves in the home network, 0 disables it",
+	   "Parametr Group":"Data Acquisition"
+	},
+	"10051":{
+	   "PropertyName":"Home Moving Min Distance",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"65535",
+	   "Units":"m",
+	   "Description":"Distance after which a record is made when the vehicle moves in the home network, 0 disables it",
+	   "Parametr Group":"Data Acquisition"
+	},
+	"10052":{
+	   "PropertyName":"Home Moving Min Angle",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"180",
+	   "Units":"°",
+	   "Description":"Change of heading after which a record is made when the vehicle moves in the home network, 0 disables it",
+	   "Parametr Group":"Data Acquisition"
+	},
+	"11000":{
+	   "PropertyName":"Data Acquisition Period",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"2592000",
+	   "Units":"s",
+	   "Description":"Period of records, 0 disables it",
+	   "Parametr Group":"Data Acquisition"
+	}
+ }`
+
+// FM11XYParams holds JSON representation of configuration parameters for devices family FM11XY
+const FM11XYParams string = `{
+	"1240":{
+	   "PropertyName":"GPRS Content Activation",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"1",
+	   "Units":"-",
+	   "Description":"0 – disabled, 1 – enabled",
+	   "Parametr Group":"GPRS",
+	   "Values":{
+	      "0":"Disabled",
+	      "1":"Enabled"
+	   }
+	},
+	"1242":{
+	   "PropertyName":"APN",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"32",
+	   "Units":"-",
+	   "Description":"Access point name of the SIM card operator",
+	   "Parametr Group":"GPRS"
+	},
+	"1243":{
+	   "PropertyName":"APN Username",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"30",
+	   "Units":"-",
+	   "Description":"User name of the access point, empty if it is not required",
+	   "Parametr Group":"GPRS"
+	},
+	"1244":{
+	   "PropertyName":"APN Password",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"30",
+	   "Units":"-",
+	   "Description":"Password of the access point, empty if it is not required",
+	   "Parametr Group":"GPRS"
+	},
+	"1245":{
+	   "PropertyName":"Domain",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"55",
+	   "Units":"-",
+	   "Description":"Domain or IP address of the server",
+	   "Parametr Group":"GPRS"
+	},
+	"1246":{
+	   "PropertyName":"Port",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"65535",
+	   "Units":"-",
+	   "Description":"Port of the server",
+	   "Parametr Group":"GPRS"
+	},
+	"1247":{
+	   "PropertyName":"Protocol",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"1",
+	   "Units":"-",
+	   "Description":"0 – TCP, 1 – UDP",
+	   "Parametr Group":"GPRS",
+	   "Values":{
+	      "0":"TCP",
+	      "1":"UDP"
+	   }
+	}
+ }`
+
+// FM64Params holds JSON representation of configuration parameters for devices family FM64
+const FM64Params string = `{
+	"240":{
+	   "PropertyName":"GPRS Content Activation",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"1",
+	   "Units":"-",
+	   "Description":"0 – disabled, 1 – enabled",
+	   "Parametr Group":"GPRS",
+	   "Values":{
+	      "0":"Disabled",
+	      "1":"Enabled"
+	   }
+	},
+	"242":{
+	   "PropertyName":"APN",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"32",
+	   "Units":"-",
+	   "Description":"Access point name of the SIM card operator",
+	   "Parametr Group":"GPRS"
+	},
+	"243":{
+	   "PropertyName":"APN Username",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"30",
+	   "Units":"-",
+	   "Description":"User name of the access point, empty if it is not required",
+	   "Parametr Group":"GPRS"
+	},
+	"244":{
+	   "PropertyName":"APN Password",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"30",
+	   "Units":"-",
+	   "Description":"Password of the access point, empty if it is not required",
+	   "Parametr Group":"GPRS"
+	},
+	"245":{
+	   "PropertyName":"Domain",
+	   "Type":"String",
+	   "Min":"0",
+	   "Max":"55",
+	   "Units":"-",
+	   "Description":"Domain or IP address of the server",
+	   "Parametr Group":"GPRS"
+	},
+	"246":{
+	   "PropertyName":"Port",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"65535",
+	   "Units":"-",
+	   "Description":"Port of the server",
+	   "Parametr Group":"GPRS"
+	},
+	"247":{
+	   "PropertyName":"Protocol",
+	   "Type":"Unsigned",
+	   "Min":"0",
+	   "Max":"1",
+	   "Units":"-",
+	   "Description":"0 – TCP, 1 – UDP",
+	   "Parametr Group":"GPRS",
+	   "Values":{
+	      "0":"TCP",
+	      "1":"UDP"
+	   }
+	}
+ }`
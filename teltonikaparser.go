@@ -2,14 +2,16 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// Package teltonikaparser is an implementation of https://wiki.teltonika.lt/view/Codec Codec08 and Codec08Extended for UDP packets in GO Lang
+// Package teltonikaparser is an implementation of https://wiki.teltonika.lt/view/Codec Codec08 and Codec08Extended for UDP and TCP packets in GO Lang
 // implemented https://wiki.teltonika.lt/view/Codec#Codec_8
 // implemented https://wiki.teltonika.lt/view/Codec#Codec_8_Extended
 package teltonikaparser
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/basvdlei/gotsmart/crc16"
 	"github.com/filipkroca/b2n"
 )
 
@@ -19,7 +21,12 @@ type Decoded struct {
 	CodecID  byte      // 0x08 (codec 8) or 0x8E (codec 8 extended)
 	NoOfData uint8     // Number of Data
 	Data     []AvlData // Slice with avl data
-	Response []byte 	 // Slice with a response
+	Response []byte    // Slice with a response
+
+	Length      uint16 // UDP packet length (Bytes 0-1), it does not count the length field itself
+	PacketID    uint16 // UDP packet ID (Bytes 2-3), a retransmitted packet repeats it
+	NotUsable   byte   // UDP not usable Byte (Byte 4), devices send 0x01
+	AVLPacketID byte   // UDP AVL packet ID (Byte 5), a retransmitted packet repeats it, it is returned in the response
 }
 
 // AvlData represent one block of data
@@ -44,47 +51,171 @@ type Element struct {
 	Value  []byte // Value of the element represented by slice of bytes
 }
 
+// DecodeError is returned by Decode, DecodeTCP and DecodeElements for a malformed packet, Offset is the position of the Byte where decoding failed
+type DecodeError struct {
+	Offset int   // Offset of the failing field from the beginning of the packet
+	Err    error // Err describes the failure
+}
+
+func (e *DecodeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the failure
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// errorAt returns DecodeError at offset, the offset of a DecodeError wrapped by err is kept because it is more precise
+func errorAt(offset int, err error) error {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		offset = decodeErr.Offset
+	}
+	return &DecodeError{Offset: offset, Err: err}
+}
+
 // Decode takes a pointer to a slice of bytes with raw data and return Decoded struct
 func Decode(bs *[]byte) (Decoded, error) {
 	decoded := Decoded{}
 	var err error
-	var nextByte int
 
 	// check for minimum packet size
 	if len(*bs) < 45 {
-		return Decoded{}, fmt.Errorf("Minimum packet size is 45 Bytes, got %v", len(*bs))
+		return Decoded{}, errorAt(len(*bs), fmt.Errorf("Minimum packet size is 45 Bytes, got %v", len(*bs)))
 	}
 
-	// check for teltonika packet ID
-	if (*bs)[2] != 0xca || (*bs)[3] != 0xfe {
-		return Decoded{}, fmt.Errorf("Probably not Teltonika packet, trashed")
+	// the packet ID varies by device, so foreign data are rejected by the length, the IMEI length and the codec,
+	// the declared length must cover the whole buffer, otherwise there is a trailing garbage or more datagrams
+	decoded.Length = uint16((*bs)[0])<<8 | uint16((*bs)[1])
+	if int(decoded.Length) != len(*bs)-2 {
+		return Decoded{}, errorAt(0, fmt.Errorf("Invalid packet length, declared %v Bytes, got %v Bytes after the length field", decoded.Length, len(*bs)-2))
 	}
 
-	// determine bit number where start data, it can change because of IMEI length
-	imeiLenX, err := b2n.ParseBs2Uint8(bs, 7)
-	if err != nil {
-		return Decoded{}, fmt.Errorf("Decode error, %v", err)
-	}
-	imeiLen := int(imeiLenX)
+	// determine bit number where start data, it can change because of IMEI length, the length has 2 Bytes
+	imeiLen := int((*bs)[6])<<8 | int((*bs)[7])
 
 	if imeiLen != 15 && imeiLen != 16 {
 		//log.Fatalf("Error when determining IMEI len want 15 or 16, got %v", imeiLen)
-		return Decoded{}, fmt.Errorf("Error when determining IMEI len want 15 or 16, got %v", imeiLen)
+		return Decoded{}, errorAt(6, fmt.Errorf("Error when determining IMEI len want 15 or 16, got %v", imeiLen))
 	}
 
 	// decode and validate IMEI
 	decoded.IMEI, err = b2n.ParseIMEI(bs, 8, imeiLen)
 	if err != nil {
-		return Decoded{}, fmt.Errorf("Decode error, %v", err)
+		return Decoded{}, errorAt(8, fmt.Errorf("Decode error, %v", err))
 	}
 
+	// packet IDs identify retransmissions of the same packet
+	decoded.PacketID = uint16((*bs)[2])<<8 | uint16((*bs)[3])
+	decoded.NotUsable = (*bs)[4]
+	decoded.AVLPacketID = (*bs)[5]
+
 	// count start bit for data
 	startByte := 8 + imeiLen
 
+	// decode Codec ID, records and control num. of data
+	_, err = decodeAvlData(bs, startByte, &decoded)
+	if err != nil {
+		return Decoded{}, err
+	}
+
+	// create response packet
+	decoded.Response, err = EncodeUDPResponse(&decoded, decoded.NoOfData)
+	if err != nil {
+		return Decoded{}, err
+	}
+
+	return decoded, nil
+}
+
+// DecodeTCP takes a pointer to a slice of bytes with one TCP AVL packet (preamble, data field length, data, CRC) and return Decoded struct,
+// IMEI is not part of the packet because the device sends it only once after connecting, Response holds the 4 Bytes number of accepted data
+func DecodeTCP(bs *[]byte) (Decoded, error) {
+	decoded := Decoded{}
+
+	// preamble, data field length, codec ID, 2x number of data and CRC
+	if len(*bs) < 15 {
+		return Decoded{}, errorAt(len(*bs), fmt.Errorf("Minimum TCP packet size is 15 Bytes, got %v", len(*bs)))
+	}
+
+	preamble, err := b2n.ParseBs2Uint32(bs, 0)
+	if err != nil {
+		return Decoded{}, errorAt(0, fmt.Errorf("Decode error, %v", err))
+	}
+	if preamble != 0 {
+		return Decoded{}, errorAt(0, fmt.Errorf("Invalid preamble, want 0x00000000, got %#08x", preamble))
+	}
+
+	dataLen, err := b2n.ParseBs2Uint32(bs, 4)
+	if err != nil {
+		return Decoded{}, errorAt(4, fmt.Errorf("Decode error, %v", err))
+	}
+	if int(dataLen) != len(*bs)-12 {
+		return Decoded{}, errorAt(4, fmt.Errorf("Invalid data field length, want %v, got %v", len(*bs)-12, dataLen))
+	}
+
+	// CRC-16/IBM is calculated from Codec ID to the second number of data
+	expectedCRC, err := b2n.ParseBs2Uint32(bs, len(*bs)-4)
+	if err != nil {
+		return Decoded{}, errorAt(len(*bs)-4, fmt.Errorf("Decode error, %v", err))
+	}
+	crc := crc16.Checksum((*bs)[8 : len(*bs)-4])
+	if uint32(crc) != expectedCRC {
+		return Decoded{}, errorAt(len(*bs)-4, fmt.Errorf("CRC check failed, calculated %#04x, received %#04x", crc, expectedCRC))
+	}
+
+	endByte, err := decodeAvlData(bs, 8, &decoded)
+	if err != nil {
+		return Decoded{}, err
+	}
+	if endByte != len(*bs)-5 {
+		return Decoded{}, errorAt(endByte, fmt.Errorf("Unexpected end of data at Byte %v, want %v", endByte, len(*bs)-5))
+	}
+
+	// create response packet
+	decoded.Response, err = EncodeTCPResponse(&decoded, decoded.NoOfData)
+	if err != nil {
+		return Decoded{}, err
+	}
+
+	return decoded, nil
+}
+
+// EncodeUDPResponse takes a pointer to Decoded UDP packet and return the acknowledgement of the first accepted records,
+// accepted 0 tells the device to keep all records and send them again
+func EncodeUDPResponse(decoded *Decoded, accepted uint8) ([]byte, error) {
+	if accepted > decoded.NoOfData {
+		return nil, fmt.Errorf("Unable to accept %v records, packet contains %v", accepted, decoded.NoOfData)
+	}
+
+	return []byte{0x00, 0x05, byte(decoded.PacketID >> 8), byte(decoded.PacketID), 0x01, decoded.AVLPacketID, accepted}, nil
+}
+
+// EncodeTCPResponse takes a pointer to Decoded TCP packet and return the 4 Bytes acknowledgement of the first accepted records,
+// accepted 0 tells the device to keep all records and send them again
+func EncodeTCPResponse(decoded *Decoded, accepted uint8) ([]byte, error) {
+	if accepted > decoded.NoOfData {
+		return nil, fmt.Errorf("Unable to accept %v records, packet contains %v", accepted, decoded.NoOfData)
+	}
+
+	return []byte{0x00, 0x00, 0x00, accepted}, nil
+}
+
+// decodeAvlData parses Codec ID, number of data, AVL records and control number of data starting at start Byte,
+// it is shared by UDP and TCP packets and returns position of the control number of data
+func decodeAvlData(bs *[]byte, startByte int, decoded *Decoded) (int, error) {
+	var err error
+	var nextByte int
+
+	if startByte >= len(*bs) {
+		return 0, errorAt(startByte, fmt.Errorf("Missing Codec ID, want minimum length of bs %v, got %v", startByte+1, len(*bs)))
+	}
+
 	// decode Codec ID
 	decoded.CodecID = (*bs)[startByte]
 	if decoded.CodecID != 0x08 && decoded.CodecID != 0x8e {
-		return Decoded{}, fmt.Errorf("Invalid Codec ID, want 0x08 or 0x8E, get %v", decoded.CodecID)
+		return 0, errorAt(startByte, fmt.Errorf("Invalid Codec ID, want 0x08 or 0x8E, get %v", decoded.CodecID))
 	}
 
 	// initialize nextByte counter
@@ -93,7 +224,7 @@ func Decode(bs *[]byte) (Decoded, error) {
 	// determine no of data in packet
 	decoded.NoOfData, err = b2n.ParseBs2Uint8(bs, nextByte)
 	if err != nil {
-		return Decoded{}, fmt.Errorf("Decode error, %v", err)
+		return 0, errorAt(nextByte, fmt.Errorf("Decode error, %v", err))
 	}
 
 	// increment nextByte counter
@@ -109,7 +240,7 @@ func Decode(bs *[]byte) (Decoded, error) {
 		// time record in ms has 8 Bytes
 		decodedData.UtimeMs, err = b2n.ParseBs2Uint64(bs, nextByte)
 		if err != nil {
-			return Decoded{}, fmt.Errorf("Decode error, %v", err)
+			return 0, errorAt(nextByte, fmt.Errorf("Decode error, %v", err))
 		}
 
 		decodedData.Utime = uint64(decodedData.UtimeMs / 1000)
@@ -118,10 +249,10 @@ func Decode(bs *[]byte) (Decoded, error) {
 		// parse priority
 		decodedData.Priority, err = b2n.ParseBs2Uint8(bs, nextByte)
 		if err != nil {
-			return Decoded{}, fmt.Errorf("Decode error, %v", err)
+			return 0, errorAt(nextByte, fmt.Errorf("Decode error, %v", err))
 		}
 		if !(decodedData.Priority <= 2) {
-			return Decoded{}, fmt.Errorf("Invalid Priority value, want priority <= 2, got %v", decodedData.Priority)
+			return 0, errorAt(nextByte, fmt.Errorf("Invalid Priority value, want priority <= 2, got %v", decodedData.Priority))
 		}
 
 		nextByte++
@@ -129,54 +260,54 @@ func Decode(bs *[]byte) (Decoded, error) {
 		// parse and validate GPS
 		decodedData.Lng, err = b2n.ParseBs2Int32TwoComplement(bs, nextByte)
 		if err != nil {
-			return Decoded{}, fmt.Errorf("Decode error, %v", err)
+			return 0, errorAt(nextByte, fmt.Errorf("Decode error, %v", err))
 		}
 		if !(decodedData.Lng > -1800000000 && decodedData.Lng < 1800000000) {
-			return Decoded{}, fmt.Errorf("Invalid Lat value, want lat > -1800000000 AND lat < 1800000000, got %v", decodedData.Lng)
+			return 0, errorAt(nextByte, fmt.Errorf("Invalid Lat value, want lat > -1800000000 AND lat < 1800000000, got %v", decodedData.Lng))
 		}
 		nextByte += 4
 
 		decodedData.Lat, err = b2n.ParseBs2Int32TwoComplement(bs, nextByte)
 		if err != nil {
-			return Decoded{}, fmt.Errorf("Decode error, %v", err)
+			return 0, errorAt(nextByte, fmt.Errorf("Decode error, %v", err))
 		}
 
 		if !(decodedData.Lat > -850000000 && decodedData.Lat < 850000000) {
-			return Decoded{}, fmt.Errorf("Invalid Lat value, want lat > -850000000 AND lat < 850000000, got %v", decodedData.Lat)
+			return 0, errorAt(nextByte, fmt.Errorf("Invalid Lat value, want lat > -850000000 AND lat < 850000000, got %v", decodedData.Lat))
 		}
 		nextByte += 4
 
 		// parse Altitude
 		decodedData.Altitude, err = b2n.ParseBs2Int16TwoComplement(bs, nextByte)
 		if err != nil {
-			return Decoded{}, fmt.Errorf("Decode error, %v", err)
+			return 0, errorAt(nextByte, fmt.Errorf("Decode error, %v", err))
 		}
 		if !(decodedData.Altitude > -5000 && decodedData.Altitude < 12000) {
-			return Decoded{}, fmt.Errorf("Invalid Altitude value, want Altitude > -5000 AND Altitude < 12000, got %v", decodedData.Altitude)
+			return 0, errorAt(nextByte, fmt.Errorf("Invalid Altitude value, want Altitude > -5000 AND Altitude < 12000, got %v", decodedData.Altitude))
 		}
 		nextByte += 2
 
 		// parse Angle
 		decodedData.Angle, err = b2n.ParseBs2Uint16(bs, nextByte)
 		if err != nil {
-			return Decoded{}, fmt.Errorf("Decode error, %v", err)
+			return 0, errorAt(nextByte, fmt.Errorf("Decode error, %v", err))
 		}
 		if decodedData.Angle > 360 {
-			return Decoded{}, fmt.Errorf("Invalid Angle value, want Angle <= 360, got %v", decodedData.Angle)
+			return 0, errorAt(nextByte, fmt.Errorf("Invalid Angle value, want Angle <= 360, got %v", decodedData.Angle))
 		}
 		nextByte += 2
 
 		// parse num. of vissible sattelites VisSat
 		decodedData.VisSat, err = b2n.ParseBs2Uint8(bs, nextByte)
 		if err != nil {
-			return Decoded{}, fmt.Errorf("Decode error, %v", err)
+			return 0, errorAt(nextByte, fmt.Errorf("Decode error, %v", err))
 		}
 		nextByte++
 
 		// parse Speed
 		decodedData.Speed, err = b2n.ParseBs2Uint16(bs, nextByte)
 		if err != nil {
-			return Decoded{}, fmt.Errorf("Decode error, %v", err)
+			return 0, errorAt(nextByte, fmt.Errorf("Decode error, %v", err))
 		}
 		nextByte += 2
 
@@ -185,14 +316,14 @@ func Decode(bs *[]byte) (Decoded, error) {
 			// if Codec 8 extended is used, Event id has size 2 bytes
 			decodedData.EventID, err = b2n.ParseBs2Uint16(bs, nextByte)
 			if err != nil {
-				return Decoded{}, fmt.Errorf("Decode error, %v", err)
+				return 0, errorAt(nextByte, fmt.Errorf("Decode error, %v", err))
 			}
 
 			nextByte += 2
 		} else {
 			x, err := b2n.ParseBs2Uint8(bs, nextByte)
 			if err != nil {
-				return Decoded{}, fmt.Errorf("Decode error, %v", err)
+				return 0, errorAt(nextByte, fmt.Errorf("Decode error, %v", err))
 			}
 			decodedData.EventID = uint16(x)
 			nextByte++
@@ -200,7 +331,7 @@ func Decode(bs *[]byte) (Decoded, error) {
 
 		decodedIO, endByte, err := DecodeElements(bs, nextByte, decoded.CodecID)
 		if err != nil {
-			return Decoded{}, fmt.Errorf("Decode error, %v", err)
+			return 0, errorAt(nextByte, fmt.Errorf("Decode error, %w", err))
 		}
 
 		nextByte = endByte
@@ -211,17 +342,17 @@ func Decode(bs *[]byte) (Decoded, error) {
 	}
 
 	if int(decoded.NoOfData) != len(decoded.Data) {
-		return Decoded{}, fmt.Errorf("Error when counting number of parsed data, want %v, got %v", int(decoded.NoOfData), len(decoded.Data))
+		return 0, errorAt(nextByte, fmt.Errorf("Error when counting number of parsed data, want %v, got %v", int(decoded.NoOfData), len(decoded.Data)))
 	}
 
 	// check if packet was corretly parsed
+	if nextByte >= len(*bs) {
+		return 0, errorAt(nextByte, fmt.Errorf("Missing byte representing control num. of data on end of parsing, want %v Bytes, got %v", nextByte+1, len(*bs)))
+	}
 	endNoOfData := (*bs)[nextByte]
 	if decoded.NoOfData != endNoOfData {
-		return Decoded{}, fmt.Errorf("Unexpected byte representing control num. of data on end of parsing, want %#x, got %#x", decoded.NoOfData, endNoOfData)
+		return 0, errorAt(nextByte, fmt.Errorf("Unexpected byte representing control num. of data on end of parsing, want %#x, got %#x", decoded.NoOfData, endNoOfData))
 	}
 
-	// create response packet
-	decoded.Response = []byte{0x00, 0x05, (*bs)[2], (*bs)[3], 0x01, (*bs)[5], decoded.NoOfData}
-
-	return decoded, nil
+	return nextByte, nil
 }
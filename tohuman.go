@@ -5,9 +5,14 @@
 package teltonikaparser
 
 import (
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/filipkroca/b2n"
 	"github.com/filipkroca/teltonikaparser/teltonikajson"
@@ -19,32 +24,57 @@ type HAvlData struct {
 	Element      *Element
 }
 
-// HumanDecoder is responsible for decoding
+// HumanDecoder is responsible for decoding, create it by NewHumanDecoder or use the zero value,
+// both share the dictionaries which are parsed only once per process. HumanDecoder is safe for concurrent use by multiple goroutines.
 type HumanDecoder struct {
 	elements map[string]map[uint16]AvlEncodeKey
 }
 
-// AvlEncodeKey represent parsed element values from JSON
+// iccidPartDigits is the number of digits of a numeric ICCID part, e.g. ICCID1 and ICCID2 of FMBXY carry 10 digits each,
+// the padding of a shorter ICCID is trimmed by ICCID
+const iccidPartDigits = 10
+
+var (
+	// dictionaries are parsed once by loadElements and never modified after that
+	dictionaries     map[string]map[uint16]AvlEncodeKey
+	dictionariesErr  error
+	dictionariesOnce sync.Once
+)
+
+// NewHumanDecoder return a HumanDecoder with the dictionaries from ./teltonikajson/ already loaded
+func NewHumanDecoder() (*HumanDecoder, error) {
+	elements, err := loadElements()
+	if err != nil {
+		return nil, err
+	}
+	return &HumanDecoder{elements: elements}, nil
+}
+
+// AvlEncodeKey represent parsed element values from JSON. Values and Bits are shared by all keys of the element
+// returned by any decoder, they are read-only and must not be modified.
 type AvlEncodeKey struct {
-	No              string `json:"No"`
-	PropertyName    string `json:"PropertyName"`
-	Bytes           string `json:"Bytes"`
-	Type            string `json:"Type"`
-	Min             string `json:"Min"`
-	Max             string `json:"Max"`
-	Multiplier      string `json:"Multiplier"`
-	Units           string `json:"Units"`
-	Description     string `json:"Description"`
-	HWSupport       string `json:"HWSupport"`
-	ParametrGroup   string `json:"Parametr Group"`
-	FinalConversion string `json:"FinalConversion"`
+	No              string            `json:"No"`
+	PropertyName    string            `json:"PropertyName"`
+	Bytes           string            `json:"Bytes"`
+	Type            string            `json:"Type"`
+	Min             string            `json:"Min"`
+	Max             string            `json:"Max"`
+	Multiplier      string            `json:"Multiplier"`
+	Units           string            `json:"Units"`
+	Description     string            `json:"Description"`
+	HWSupport       string            `json:"HWSupport"`
+	ParametrGroup   string            `json:"Parametr Group"`
+	FinalConversion string            `json:"FinalConversion"`
+	Values          map[string]string `json:"Values"` // labels of enumerated values, keyed by the decimal value, read-only
+	Bits            map[string]string `json:"Bits"`   // named bit ranges "<bit>" or "<first>-<last>", bit 0 is the LSB of the value, read-only
 }
 
 // Human takes a pointer to Element, device type ["FMBXY", "FM64", "FM36", "FM11XY"] and return a pointer to decoding key
 func (h *HumanDecoder) Human(el *Element, device string) (*HAvlData, error) {
 	//init decoding key
-	if len(h.elements) == 0 {
-		h.loadElements()
+	elements, err := h.dictionary()
+	if err != nil {
+		return nil, err
 	}
 
 	// check if Element is valid
@@ -53,7 +83,7 @@ func (h *HumanDecoder) Human(el *Element, device string) (*HAvlData, error) {
 	}
 
 	// find decode key and pair it
-	avl, ok := h.elements[device][(*el).IOID]
+	avl, ok := elements[device][(*el).IOID]
 	if !ok {
 		return nil, fmt.Errorf("Unknown element %v", (*el).IOID)
 	}
@@ -69,8 +99,8 @@ func (h *HumanDecoder) Human(el *Element, device string) (*HAvlData, error) {
 // AvlDataToHuman takes a pointer to a slice of AvlData and return a slice with data
 func (h *HumanDecoder) AvlDataToHuman(data *[]AvlData) ([][][]string, error) {
 	//init decoding key
-	if len(h.elements) == 0 {
-		h.loadElements()
+	if _, err := h.dictionary(); err != nil {
+		return nil, err
 	}
 
 	codec := "FMBXY"
@@ -85,7 +115,7 @@ autoDecode:
 			// decode to human readable format
 			decoded, err := h.Human(&ioel, codec)
 			if err != nil {
-				log.Panicf("Error when converting human, %v\n", err)
+				return nil, fmt.Errorf("Error when converting human, %v", err)
 			}
 
 			// get final decoded value to value which is specified in ./teltonikajson/ in paramether FinalConversion
@@ -109,48 +139,40 @@ autoDecode:
 	return output, nil
 }
 
-// loadElements parses ./decoding/.. into slice
-func (h *HumanDecoder) loadElements() {
-	// make map
-	h.elements = make(map[string]map[uint16]AvlEncodeKey)
-
-	// read our opened json as a byte array.
-	byteValue := []byte(teltonikajson.FMBXY)
-	fmbxy := make(map[uint16]AvlEncodeKey)
-	//h.elements["FMBXY"] = make(map[uint16]AvlEncodeKey)
-	err := json.Unmarshal(byteValue, &fmbxy)
-	if err != nil {
-		log.Panic(err)
+// dictionary return decoding keys of the decoder, the zero value HumanDecoder uses the shared dictionaries
+func (h *HumanDecoder) dictionary() (map[string]map[uint16]AvlEncodeKey, error) {
+	if h.elements != nil {
+		return h.elements, nil
 	}
-	h.elements["FMBXY"] = fmbxy
-
-	// read our opened json as a byte array.
-	byteValue = []byte(teltonikajson.FM64)
-	fm64 := make(map[uint16]AvlEncodeKey)
-	err = json.Unmarshal(byteValue, &fm64)
-	if err != nil {
-		log.Panic(err)
-	}
-	h.elements["FM64"] = fm64
+	return loadElements()
+}
 
-	// read our opened json as a byte array.
-	byteValue = []byte(teltonikajson.FM36)
-	fm36 := make(map[uint16]AvlEncodeKey)
-	err = json.Unmarshal(byteValue, &fm36)
-	if err != nil {
-		log.Panic(err)
-	}
-	h.elements["FM36"] = fm36
+// loadElements parses ./teltonikajson/.. into maps, it is done only once and the result is shared
+func loadElements() (map[string]map[uint16]AvlEncodeKey, error) {
+	dictionariesOnce.Do(func() {
+		families := []struct {
+			device string
+			json   string
+		}{
+			{"FMBXY", teltonikajson.FMBXY},
+			{"FM64", teltonikajson.FM64},
+			{"FM36", teltonikajson.FM36},
+			{"FM11XY", teltonikajson.FM11XY},
+		}
 
-	// read our opened json as a byte array.
-	byteValue = []byte(teltonikajson.FM11XY)
-	fm11XY := make(map[uint16]AvlEncodeKey)
-	err = json.Unmarshal(byteValue, &fm11XY)
-	if err != nil {
-		log.Panic(err)
-	}
-	h.elements["FM11XY"] = fm11XY
+		elements := make(map[string]map[uint16]AvlEncodeKey, len(families))
+		for _, family := range families {
+			keys := make(map[uint16]AvlEncodeKey)
+			if err := json.Unmarshal([]byte(family.json), &keys); err != nil {
+				dictionariesErr = fmt.Errorf("Unable to parse %v dictionary, %v", family.device, err)
+				return
+			}
+			elements[family.device] = keys
+		}
+		dictionaries = elements
+	})
 
+	return dictionaries, dictionariesErr
 }
 
 // GetFinalValue return decimal value, if necesarry with float, return should be empty interface because there is many values to return
@@ -219,5 +241,216 @@ func (h *HAvlData) GetFinalValue() (interface{}, error) {
 		return b2n.ParseBs2Int64TwoComplement(&h.Element.Value, 0)
 	}
 
+	if h.AvlEncodeKey.FinalConversion == "toHex" {
+		return strings.ToUpper(hex.EncodeToString(h.Element.Value)), nil
+	}
+
+	if h.AvlEncodeKey.FinalConversion == "toASCII" {
+		return toASCII(h.Element.Value, h.AvlEncodeKey.PropertyName)
+	}
+
+	if h.AvlEncodeKey.FinalConversion == "toIButton" {
+		if len(h.Element.Value) != 8 {
+			return nil, fmt.Errorf("Unable to convert %vBytes long parametr, %vBytes real long parametr to iButton ID %v", h.AvlEncodeKey.Bytes, len(h.Element.Value), h.AvlEncodeKey.PropertyName)
+		}
+		return strings.ToUpper(hex.EncodeToString(h.Element.Value)), nil
+	}
+
+	if h.AvlEncodeKey.FinalConversion == "toICCID" {
+		if len(h.Element.Value) != 8 {
+			return nil, fmt.Errorf("Unable to convert %vBytes long parametr, %vBytes real long parametr to ICCID part %v", h.AvlEncodeKey.Bytes, len(h.Element.Value), h.AvlEncodeKey.PropertyName)
+		}
+		// some families send ICCID digits as ASCII, others as a number
+		if iccid, err := toASCII(h.Element.Value, h.AvlEncodeKey.PropertyName); err == nil && isDigits(iccid) {
+			return iccid, nil
+		}
+		part, err := b2n.ParseBs2Uint64(&h.Element.Value, 0)
+		if err != nil {
+			return nil, err
+		}
+		// a numeric part carries a fixed number of digits, leading zeros are a part of the ICCID
+		return fmt.Sprintf("%0*d", iccidPartDigits, part), nil
+	}
+
+	if h.AvlEncodeKey.FinalConversion == "toVIN" {
+		vin, err := toASCII(h.Element.Value, h.AvlEncodeKey.PropertyName)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range vin {
+			if !(c >= '0' && c <= '9' || c >= 'A' && c <= 'Z') || c == 'I' || c == 'O' || c == 'Q' {
+				return nil, fmt.Errorf("Invalid VIN character %q in %v", c, h.AvlEncodeKey.PropertyName)
+			}
+		}
+		return vin, nil
+	}
+
 	return string(h.Element.Value), nil
 }
+
+// ICCID return SIM ICCID joined from all toICCID elements of the record, e.g. ICCID1 (11) and ICCID2 (14) for FMBXY.
+// The ICCID has 19 or 20 digits and it ends by the Luhn check digit.
+func (h *HumanDecoder) ICCID(data *AvlData, device string) (string, error) {
+	iccid, err := h.joinElements(data, device, "toICCID")
+	if err != nil {
+		return "", err
+	}
+
+	// an ICCID starts by 89, leading zeros are the padding of the numeric parts of a 19 digit ICCID
+	iccid = strings.TrimLeft(iccid, "0")
+	if len(iccid) < 19 || len(iccid) > 20 || !isDigits(iccid) || !luhnValid(iccid) {
+		return "", fmt.Errorf("Invalid ICCID %v, want 19 or 20 digits with the Luhn check digit", iccid)
+	}
+	return iccid, nil
+}
+
+// VIN return vehicle identification number joined from all toVIN elements of the record
+func (h *HumanDecoder) VIN(data *AvlData, device string) (string, error) {
+	return h.joinElements(data, device, "toVIN")
+}
+
+// joinElements concatenates final values of elements with given FinalConversion ordered by IO ID, the most significant part has the lowest ID
+func (h *HumanDecoder) joinElements(data *AvlData, device string, conversion string) (string, error) {
+	parts := make([]*HAvlData, 0, 3)
+	for i := range data.Elements {
+		decoded, err := h.Human(&data.Elements[i], device)
+		if err != nil || decoded.AvlEncodeKey.FinalConversion != conversion {
+			continue
+		}
+		parts = append(parts, decoded)
+	}
+
+	if len(parts) == 0 {
+		return "", fmt.Errorf("No %v elements in the record", conversion)
+	}
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Element.IOID < parts[j].Element.IOID })
+
+	joined := ""
+	for _, part := range parts {
+		val, err := part.GetFinalValue()
+		if err != nil {
+			return "", err
+		}
+		joined += fmt.Sprintf("%v", val)
+	}
+
+	return joined, nil
+}
+
+// toASCII converts a value padded by zero bytes to a string, it fails on non printable characters
+func toASCII(value []byte, name string) (string, error) {
+	trimmed := bytes.Trim(value, "\x00")
+	for _, c := range trimmed {
+		if c < 0x20 || c > 0x7e {
+			return "", fmt.Errorf("Unable to convert parametr %v to ASCII, non printable byte %#x", name, c)
+		}
+	}
+	return string(trimmed), nil
+}
+
+// luhnValid reports whether the last digit of digits is their Luhn check digit
+func luhnValid(digits string) bool {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// isDigits reports whether s is a non empty string of decimal digits
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+// GetLabel return a label of an enumerated value as specified in ./teltonikajson/ in paramether Values, e.g. "Home On Stop" for Data Mode 0
+func (h *HAvlData) GetLabel() (string, error) {
+	if len(h.AvlEncodeKey.Values) == 0 {
+		return "", fmt.Errorf("Element %v has no enumerated values", h.AvlEncodeKey.PropertyName)
+	}
+
+	val, err := h.GetFinalValue()
+	if err != nil {
+		return "", err
+	}
+
+	label, ok := h.AvlEncodeKey.Values[fmt.Sprintf("%v", val)]
+	if !ok {
+		return "", fmt.Errorf("Unknown value %v of enumerated element %v", val, h.AvlEncodeKey.PropertyName)
+	}
+
+	return label, nil
+}
+
+// GetBits return values of named bit ranges as specified in ./teltonikajson/ in paramether Bits, e.g. {"Front Left Door": 1, "Trunk": 0} for Door Status 256
+func (h *HAvlData) GetBits() (map[string]uint64, error) {
+	if len(h.AvlEncodeKey.Bits) == 0 {
+		return nil, fmt.Errorf("Element %v has no bit ranges", h.AvlEncodeKey.PropertyName)
+	}
+
+	if len(h.Element.Value) == 0 || len(h.Element.Value) > 8 {
+		return nil, fmt.Errorf("Unable to split %vBytes long parametr %v to bits, want 1 to 8 Bytes", len(h.Element.Value), h.AvlEncodeKey.PropertyName)
+	}
+
+	// value is big endian, so the last byte holds bits 0-7
+	var raw uint64
+	for _, b := range h.Element.Value {
+		raw = raw<<8 | uint64(b)
+	}
+
+	bits := make(map[string]uint64, len(h.AvlEncodeKey.Bits))
+	for name, bitRange := range h.AvlEncodeKey.Bits {
+		first, last, err := parseBitRange(bitRange)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid bit range %v of %v, %v", name, h.AvlEncodeKey.PropertyName, err)
+		}
+		if last >= uint(len(h.Element.Value))*8 {
+			return nil, fmt.Errorf("Bit range %v of %v exceeds %vBytes long value", name, h.AvlEncodeKey.PropertyName, len(h.Element.Value))
+		}
+
+		width := last - first + 1
+		mask := uint64(1)<<width - 1
+		if width == 64 {
+			mask = ^uint64(0)
+		}
+		bits[name] = raw >> first & mask
+	}
+
+	return bits, nil
+}
+
+// parseBitRange parses bit range in form "<bit>" or "<first>-<last>"
+func parseBitRange(bitRange string) (uint, uint, error) {
+	parts := strings.SplitN(bitRange, "-", 2)
+
+	first, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 6)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	last := first
+	if len(parts) == 2 {
+		last, err = strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 6)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if last < first {
+		return 0, 0, fmt.Errorf("want first bit <= last bit, got %v", bitRange)
+	}
+
+	return uint(first), uint(last), nil
+}
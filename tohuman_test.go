@@ -0,0 +1,450 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package teltonikaparser
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestGetLabel(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Device        string
+		Element       Element
+		ExpectedLabel string
+		ErrorCase     bool
+	}{
+		{
+			Name:          "FMBXYDataMode",
+			Device:        "FMBXY",
+			Element:       Element{Length: 1, IOID: 80, Value: []byte{0x05}},
+			ExpectedLabel: "Unknown On Moving",
+		},
+		{
+			Name:          "FMBXYSleepMode",
+			Device:        "FMBXY",
+			Element:       Element{Length: 1, IOID: 200, Value: []byte{0x02}},
+			ExpectedLabel: "Deep Sleep",
+		},
+		{
+			Name:          "FM64GNSSStatus",
+			Device:        "FM64",
+			Element:       Element{Length: 1, IOID: 71, Value: []byte{0x03}},
+			ExpectedLabel: "GNSS ON, With Fix",
+		},
+		{
+			Name:          "FM11XYGreenDrivingType",
+			Device:        "FM11XY",
+			Element:       Element{Length: 1, IOID: 253, Value: []byte{0x02}},
+			ExpectedLabel: "Harsh Braking",
+		},
+		{
+			Name:      "FMBXYUnknownValue",
+			Device:    "FMBXY",
+			Element:   Element{Length: 1, IOID: 80, Value: []byte{0x09}},
+			ErrorCase: true,
+		},
+		{
+			Name:      "FMBXYNotEnumerated",
+			Device:    "FMBXY",
+			Element:   Element{Length: 2, IOID: 66, Value: []byte{0x30, 0x56}},
+			ErrorCase: true,
+		},
+	}
+
+	humanDecoder := HumanDecoder{}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			decoded, err := humanDecoder.Human(&testCase.Element, testCase.Device)
+			if err != nil {
+				test.Fatalf("Failed to decode element. %v", err)
+			}
+
+			label, err := decoded.GetLabel()
+			if testCase.ErrorCase {
+				if err == nil {
+					test.Logf("This is an error case but there is no error, got label %v", label)
+					test.Fail()
+				}
+				return
+			}
+
+			if err != nil {
+				test.Logf("Failed to get label. %v", err)
+				test.Fail()
+			}
+
+			if label != testCase.ExpectedLabel {
+				test.Logf("Expected value: %v, Actual value: %v", testCase.ExpectedLabel, label)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestGetBits(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Device       string
+		Element      Element
+		ExpectedBits map[string]uint64
+		ErrorCase    bool
+	}{
+		{
+			Name:    "FMBXYDoorStatus",
+			Device:  "FMBXY",
+			Element: Element{Length: 2, IOID: 90, Value: []byte{0x21, 0x00}},
+			ExpectedBits: map[string]uint64{
+				"Front Left Door":  1,
+				"Front Right Door": 0,
+				"Rear Left Door":   0,
+				"Rear Right Door":  0,
+				"Hood":             0,
+				"Trunk":            1,
+			},
+		},
+		{
+			Name:    "FM64DoorStatus",
+			Device:  "FM64",
+			Element: Element{Length: 2, IOID: 143, Value: []byte{0x3f, 0x00}},
+			ExpectedBits: map[string]uint64{
+				"Front Left Door":  1,
+				"Front Right Door": 1,
+				"Rear Left Door":   1,
+				"Rear Right Door":  1,
+				"Hood":             1,
+				"Trunk":            1,
+			},
+		},
+		{
+			Name:      "FMBXYNoBits",
+			Device:    "FMBXY",
+			Element:   Element{Length: 2, IOID: 66, Value: []byte{0x30, 0x56}},
+			ErrorCase: true,
+		},
+	}
+
+	humanDecoder := HumanDecoder{}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			decoded, err := humanDecoder.Human(&testCase.Element, testCase.Device)
+			if err != nil {
+				test.Fatalf("Failed to decode element. %v", err)
+			}
+
+			bits, err := decoded.GetBits()
+			if testCase.ErrorCase {
+				if err == nil {
+					test.Logf("This is an error case but there is no error, got bits %v", bits)
+					test.Fail()
+				}
+				return
+			}
+
+			if err != nil {
+				test.Logf("Failed to get bits. %v", err)
+				test.Fail()
+			}
+
+			if !reflect.DeepEqual(bits, testCase.ExpectedBits) {
+				test.Logf("Expected value: %v, Actual value: %v", testCase.ExpectedBits, bits)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestGetBitsSecurityStateFlags(t *testing.T) {
+	// CAN1 and CAN2 connected, ignition on, engine working, trunk door opened
+	el := Element{Length: 8, IOID: 132, Value: []byte{0x00, 0x00, 0x20, 0x40, 0x02, 0x00, 0x00, 0x0f}}
+
+	decoded, err := (&HumanDecoder{}).Human(&el, "FMBXY")
+	if err != nil {
+		t.Fatalf("Failed to decode element. %v", err)
+	}
+
+	bits, err := decoded.GetBits()
+	if err != nil {
+		t.Fatalf("Failed to get bits. %v", err)
+	}
+
+	for name, expected := range map[string]uint64{"CAN1 State": 3, "CAN2 State": 3, "CAN3 State": 0, "Ignition On": 1, "Engine Working": 1, "Trunk Door Opened": 1, "Reverse On": 0} {
+		if bits[name] != expected {
+			t.Errorf("%v: expected value: %v, actual value: %v", name, expected, bits[name])
+		}
+	}
+}
+
+func TestICCIDLength(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Device    string
+		Elements  []Element
+		Expected  string
+		ErrorCase bool
+	}{
+		{
+			Name:   "FMBXY19Digits",
+			Device: "FMBXY",
+			// 893700101 and 2345678907, the first part is padded by a zero
+			Elements: []Element{
+				{Length: 8, IOID: 11, Value: []byte{0x00, 0x00, 0x00, 0x00, 0x35, 0x44, 0xc8, 0x05}},
+				{Length: 8, IOID: 14, Value: []byte{0x00, 0x00, 0x00, 0x00, 0x8b, 0xd0, 0x38, 0x3b}},
+			},
+			Expected: "8937001012345678907",
+		},
+		{
+			Name:   "FM6419Digits",
+			Device: "FM64",
+			Elements: []Element{
+				{Length: 8, IOID: 219, Value: []byte("89370010")},
+				{Length: 8, IOID: 220, Value: []byte("12345678")},
+				{Length: 8, IOID: 221, Value: []byte{0x00, 0x00, 0x00, 0x00, 0x00, '9', '0', '7'}},
+			},
+			Expected: "8937001012345678907",
+		},
+		{
+			Name:   "InvalidCheckDigit",
+			Device: "FM64",
+			Elements: []Element{
+				{Length: 8, IOID: 219, Value: []byte("89370010")},
+				{Length: 8, IOID: 220, Value: []byte("12345678")},
+				{Length: 8, IOID: 221, Value: []byte{0x00, 0x00, 0x00, 0x00, '9', '0', '1', '2'}},
+			},
+			ErrorCase: true,
+		},
+		{
+			Name:      "MissingPart",
+			Device:    "FMBXY",
+			Elements:  []Element{{Length: 8, IOID: 11, Value: []byte{0x00, 0x00, 0x00, 0x02, 0x14, 0xaf, 0xd0, 0x34}}},
+			ErrorCase: true,
+		},
+	}
+
+	humanDecoder := HumanDecoder{}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			iccid, err := humanDecoder.ICCID(&AvlData{Elements: testCase.Elements}, testCase.Device)
+			if testCase.ErrorCase {
+				if err == nil {
+					test.Logf("This is an error case but there is no error, got value %v", iccid)
+					test.Fail()
+				}
+				return
+			}
+			if err != nil || iccid != testCase.Expected {
+				test.Logf("Expected value: %v, Actual value: %v, %v", testCase.Expected, iccid, err)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestHumanSharedKey(t *testing.T) {
+	humanDecoder := HumanDecoder{}
+	dataMode := Element{Length: 1, IOID: 80, Value: []byte{0x05}}
+
+	first, err := humanDecoder.Human(&dataMode, "FMBXY")
+	if err != nil {
+		t.Fatalf("Failed to decode element. %v", err)
+	}
+	second, err := humanDecoder.Human(&dataMode, "FMBXY")
+	if err != nil {
+		t.Fatalf("Failed to decode element. %v", err)
+	}
+
+	// every key is a copy of the dictionary entry, the read-only labels are not copied on every decode
+	if first.AvlEncodeKey == second.AvlEncodeKey {
+		t.Error("Keys of two decodes are the same pointer")
+	}
+	if reflect.ValueOf(first.AvlEncodeKey.Values).Pointer() != reflect.ValueOf(second.AvlEncodeKey.Values).Pointer() {
+		t.Error("Values of two decodes are not shared")
+	}
+	first.AvlEncodeKey.PropertyName = "Modified"
+	if second.AvlEncodeKey.PropertyName != "Data Mode" {
+		t.Errorf("Property name is %q, want Data Mode", second.AvlEncodeKey.PropertyName)
+	}
+}
+
+func TestGetFinalValueStrings(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Device        string
+		Element       Element
+		ExpectedValue interface{}
+		ErrorCase     bool
+	}{
+		{
+			Name:          "FMBXYiButton",
+			Device:        "FMBXY",
+			Element:       Element{Length: 8, IOID: 78, Value: []byte{0x5a, 0x00, 0x00, 0x17, 0x8c, 0x3b, 0x2e, 0x01}},
+			ExpectedValue: "5A0000178C3B2E01",
+		},
+		{
+			Name:          "FMBXYDallasID",
+			Device:        "FMBXY",
+			Element:       Element{Length: 8, IOID: 76, Value: []byte{0x28, 0xff, 0x4c, 0x7a, 0x01, 0x16, 0x04, 0x5e}},
+			ExpectedValue: "28FF4C7A0116045E",
+		},
+		{
+			Name:          "FMBXYVIN",
+			Device:        "FMBXY",
+			Element:       Element{Length: 17, IOID: 256, Value: []byte("WVWZZZ1JZXW000001")},
+			ExpectedValue: "WVWZZZ1JZXW000001",
+		},
+		{
+			Name:      "FMBXYInvalidVIN",
+			Device:    "FMBXY",
+			Element:   Element{Length: 17, IOID: 256, Value: []byte("WVWZZZ1JZXW00000O")},
+			ErrorCase: true,
+		},
+		{
+			Name:          "FMBXYDriverID",
+			Device:        "FMBXY",
+			Element:       Element{Length: 8, IOID: 147, Value: []byte{0x00, 0x00, 'D', 'R', 'V', '0', '0', '1'}},
+			ExpectedValue: "DRV001",
+		},
+		{
+			Name:      "FMBXYDriverIDGarbage",
+			Device:    "FMBXY",
+			Element:   Element{Length: 8, IOID: 147, Value: []byte{0x01, 0x02, 'D', 'R', 'V', '0', '0', '1'}},
+			ErrorCase: true,
+		},
+		{
+			Name:          "FMBXYICCIDNumeric",
+			Device:        "FMBXY",
+			Element:       Element{Length: 8, IOID: 11, Value: []byte{0x00, 0x00, 0x00, 0x02, 0x14, 0xaf, 0xd0, 0x34}},
+			ExpectedValue: "8937001012",
+		},
+		{
+			Name:          "FMBXYICCIDLeadingZeros",
+			Device:        "FMBXY",
+			Element:       Element{Length: 8, IOID: 14, Value: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x12, 0xd6, 0x87}},
+			ExpectedValue: "0001234567",
+		},
+		{
+			Name:          "FM64ICCIDASCII",
+			Device:        "FM64",
+			Element:       Element{Length: 8, IOID: 219, Value: []byte("89370010")},
+			ExpectedValue: "89370010",
+		},
+	}
+
+	humanDecoder := HumanDecoder{}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			decoded, err := humanDecoder.Human(&testCase.Element, testCase.Device)
+			if err != nil {
+				test.Fatalf("Failed to decode element. %v", err)
+			}
+
+			val, err := decoded.GetFinalValue()
+			if testCase.ErrorCase {
+				if err == nil {
+					test.Logf("This is an error case but there is no error, got value %v", val)
+					test.Fail()
+				}
+				return
+			}
+
+			if err != nil {
+				test.Logf("Failed to get final value. %v", err)
+				test.Fail()
+			}
+
+			if val != testCase.ExpectedValue {
+				test.Logf("Expected value: %v, Actual value: %v", testCase.ExpectedValue, val)
+				test.Fail()
+			}
+		})
+	}
+}
+
+func TestICCIDAndVIN(t *testing.T) {
+	humanDecoder := HumanDecoder{}
+
+	// elements are intentionally out of order, LSB part goes first
+	fmbxy := AvlData{Elements: []Element{
+		{Length: 1, IOID: 239, Value: []byte{0x01}},
+		{Length: 8, IOID: 14, Value: []byte{0x00, 0x00, 0x00, 0x00, 0xce, 0x0a, 0x6a, 0x16}},
+		{Length: 8, IOID: 11, Value: []byte{0x00, 0x00, 0x00, 0x02, 0x14, 0xaf, 0xd0, 0x34}},
+	}}
+
+	iccid, err := humanDecoder.ICCID(&fmbxy, "FMBXY")
+	if err != nil {
+		t.Fatalf("Failed to get ICCID. %v", err)
+	}
+	if iccid != "89370010123456789014" {
+		t.Errorf("Expected value: %v, Actual value: %v", "89370010123456789014", iccid)
+	}
+
+	fm64 := AvlData{Elements: []Element{
+		{Length: 8, IOID: 221, Value: []byte{0x00, 0x00, 0x00, 0x00, '9', '0', '1', '4'}},
+		{Length: 8, IOID: 219, Value: []byte("89370010")},
+		{Length: 8, IOID: 220, Value: []byte("12345678")},
+		{Length: 8, IOID: 234, Value: []byte("ZXW00000")},
+		{Length: 8, IOID: 233, Value: []byte("WVWZZZ1J")},
+	}}
+
+	iccid, err = humanDecoder.ICCID(&fm64, "FM64")
+	if err != nil {
+		t.Fatalf("Failed to get ICCID. %v", err)
+	}
+	if iccid != "89370010123456789014" {
+		t.Errorf("Expected value: %v, Actual value: %v", "89370010123456789014", iccid)
+	}
+
+	vin, err := humanDecoder.VIN(&fm64, "FM64")
+	if err != nil {
+		t.Fatalf("Failed to get VIN. %v", err)
+	}
+	if vin != "WVWZZZ1JZXW00000" {
+		t.Errorf("Expected value: %v, Actual value: %v", "WVWZZZ1JZXW00000", vin)
+	}
+
+	if _, err := humanDecoder.VIN(&fmbxy, "FMBXY"); err == nil {
+		t.Errorf("Expected an error for a record without VIN")
+	}
+}
+
+func TestHumanDecoderConcurrent(t *testing.T) {
+	shared, err := NewHumanDecoder()
+	if err != nil {
+		t.Fatalf("Failed to create a human decoder. %v", err)
+	}
+
+	// the zero value decoder loads shared dictionaries lazily, it must be safe to share as well
+	decoders := []*HumanDecoder{shared, {}}
+
+	el := Element{Length: 1, IOID: 80, Value: []byte{0x03}}
+
+	var waitgroup sync.WaitGroup
+	for _, humanDecoder := range decoders {
+		for i := 0; i < 16; i++ {
+			waitgroup.Add(1)
+			go func(humanDecoder *HumanDecoder) {
+				defer waitgroup.Done()
+				for j := 0; j < 100; j++ {
+					decoded, err := humanDecoder.Human(&el, "FMBXY")
+					if err != nil {
+						t.Errorf("Failed to decode element. %v", err)
+						return
+					}
+					if label, err := decoded.GetLabel(); err != nil || label != "Roaming On Moving" {
+						t.Errorf("Expected value: %v, Actual value: %v, error: %v", "Roaming On Moving", label, err)
+						return
+					}
+				}
+			}(humanDecoder)
+		}
+	}
+	waitgroup.Wait()
+}
@@ -0,0 +1,140 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package teltonikaparser
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// FindingKind represent a reason why an IO value was flagged by Validate
+type FindingKind int
+
+const (
+	// BelowMin value is lower than Min from the dictionary
+	BelowMin FindingKind = iota
+	// AboveMax value is higher than Max from the dictionary
+	AboveMax
+	// UnknownElement IO ID is not in the dictionary of the device family
+	UnknownElement
+	// ConversionFailed value does not match Bytes, Type or FinalConversion from the dictionary
+	ConversionFailed
+)
+
+func (k FindingKind) String() string {
+	switch k {
+	case BelowMin:
+		return "below minimum"
+	case AboveMax:
+		return "above maximum"
+	case UnknownElement:
+		return "unknown element"
+	case ConversionFailed:
+		return "conversion failed"
+	}
+	return fmt.Sprintf("FindingKind(%d)", int(k))
+}
+
+// Finding represent one IO element which does not fit the dictionary of the device family
+type Finding struct {
+	Record       int         // Index of the record in Decoded.Data
+	IOID         uint16      // IO element ID
+	PropertyName string      // Property name from the dictionary, empty for UnknownElement
+	Kind         FindingKind // Reason of the finding
+	Value        interface{} // Final value as returned by GetFinalValue, raw bytes if it can not be converted
+	Min          string      // Min from the dictionary
+	Max          string      // Max from the dictionary
+}
+
+func (f Finding) String() string {
+	switch f.Kind {
+	case BelowMin, AboveMax:
+		return fmt.Sprintf("record %v: IO %v %v value %v %v, want %v..%v", f.Record, f.IOID, f.PropertyName, f.Value, f.Kind, f.Min, f.Max)
+	}
+	return fmt.Sprintf("record %v: IO %v %v %v, value %x", f.Record, f.IOID, f.PropertyName, f.Kind, f.Value)
+}
+
+// Validate checks IO elements of all records against the dictionary of the device family ["FMBXY", "FM64", "FM36", "FM11XY"],
+// it returns findings for values out of Min and Max, unknown elements and elements which can not be converted, no findings means the packet fits the dictionary
+func (h *HumanDecoder) Validate(decoded *Decoded, device string) ([]Finding, error) {
+	//init decoding key
+	elements, err := h.dictionary()
+	if err != nil {
+		return nil, err
+	}
+
+	dictionary, ok := elements[device]
+	if !ok {
+		return nil, fmt.Errorf("Unknown device family %v", device)
+	}
+
+	var findings []Finding
+
+	for i := range decoded.Data {
+		for j := range decoded.Data[i].Elements {
+			el := &decoded.Data[i].Elements[j]
+
+			key, ok := dictionary[el.IOID]
+			if !ok {
+				findings = append(findings, Finding{Record: i, IOID: el.IOID, Kind: UnknownElement, Value: el.Value})
+				continue
+			}
+
+			havl := HAvlData{AvlEncodeKey: &key, Element: el}
+			val, err := havl.GetFinalValue()
+			if err != nil {
+				findings = append(findings, Finding{Record: i, IOID: el.IOID, PropertyName: key.PropertyName, Kind: ConversionFailed, Value: el.Value, Min: key.Min, Max: key.Max})
+				continue
+			}
+
+			n, ok := toBigInt(val)
+			if !ok {
+				// only numeric values have a range
+				continue
+			}
+
+			if min, ok := parseBound(key.Min); ok && n.Cmp(min) < 0 {
+				findings = append(findings, Finding{Record: i, IOID: el.IOID, PropertyName: key.PropertyName, Kind: BelowMin, Value: val, Min: key.Min, Max: key.Max})
+			} else if max, ok := parseBound(key.Max); ok && n.Cmp(max) > 0 {
+				findings = append(findings, Finding{Record: i, IOID: el.IOID, PropertyName: key.PropertyName, Kind: AboveMax, Value: val, Min: key.Min, Max: key.Max})
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// parseBound parses Min or Max from the dictionary, decimal and 0x prefixed values are supported, "-" or empty means no bound
+func parseBound(s string) (*big.Int, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "-" {
+		return nil, false
+	}
+	return new(big.Int).SetString(s, 0)
+}
+
+// toBigInt converts an integer value returned by GetFinalValue
+func toBigInt(val interface{}) (*big.Int, bool) {
+	switch v := val.(type) {
+	case uint8:
+		return new(big.Int).SetUint64(uint64(v)), true
+	case uint16:
+		return new(big.Int).SetUint64(uint64(v)), true
+	case uint32:
+		return new(big.Int).SetUint64(uint64(v)), true
+	case uint64:
+		return new(big.Int).SetUint64(v), true
+	case int8:
+		return big.NewInt(int64(v)), true
+	case int16:
+		return big.NewInt(int64(v)), true
+	case int32:
+		return big.NewInt(int64(v)), true
+	case int64:
+		return big.NewInt(v), true
+	}
+	return nil, false
+}
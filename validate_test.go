@@ -0,0 +1,120 @@
+// Copyright 2019 Filip Kroča. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package teltonikaparser
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	// Codec8 Extended packet from FMB920
+	stringData := `0086cafe0101000f3335323039333038353639383230368e0100000167efa919800200000000000000000000000000000000fc0013000800ef0000f00000150500c80000450200010000710000fc00000900b5000000b600000042305600cd432a00ce6064001100090012ff22001303d1000f0000000200f1000059d90010000000000000000001`
+
+	testCases := []struct {
+		Name             string
+		Device           string
+		Patch            func(decoded *Decoded)
+		ExpectedFindings []Finding
+	}{
+		{
+			Name:   "ValidPacket",
+			Device: "FMBXY",
+		},
+		{
+			Name:   "GSMSignalAboveMax",
+			Device: "FMBXY",
+			Patch: func(decoded *Decoded) {
+				decoded.Data[0].Elements[2].Value = []byte{0x09}
+			},
+			ExpectedFindings: []Finding{
+				{Record: 0, IOID: 21, PropertyName: "GSM Signal", Kind: AboveMax, Value: uint8(9), Min: "0", Max: "5"},
+			},
+		},
+		{
+			Name:   "AxisBelowMin",
+			Device: "FMBXY",
+			Patch: func(decoded *Decoded) {
+				decoded.Data[0].Elements[13].Value = []byte{0xd8, 0xf0}
+			},
+			ExpectedFindings: []Finding{
+				{Record: 0, IOID: 17, PropertyName: "Axis X", Kind: BelowMin, Value: int16(-10000), Min: "-8000", Max: "8000"},
+			},
+		},
+		{
+			Name:   "UnknownElement",
+			Device: "FMBXY",
+			Patch: func(decoded *Decoded) {
+				decoded.Data[0].Elements[0].IOID = 60000
+			},
+			ExpectedFindings: []Finding{
+				{Record: 0, IOID: 60000, Kind: UnknownElement, Value: []byte{0x00}},
+			},
+		},
+	}
+
+	humanDecoder := HumanDecoder{}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(test *testing.T) {
+			bs, _ := hex.DecodeString(stringData)
+			decoded, err := Decode(&bs)
+			if err != nil {
+				test.Fatalf("Failed to decode packet. %v", err)
+			}
+			if testCase.Patch != nil {
+				testCase.Patch(&decoded)
+			}
+
+			findings, err := humanDecoder.Validate(&decoded, testCase.Device)
+			if err != nil {
+				test.Fatalf("Failed to validate packet. %v", err)
+			}
+
+			if len(findings) != len(testCase.ExpectedFindings) {
+				test.Fatalf("Expected findings: %v, Actual findings: %v", testCase.ExpectedFindings, findings)
+			}
+			for i := range findings {
+				if findings[i].String() != testCase.ExpectedFindings[i].String() {
+					test.Logf("Expected finding: %v, Actual finding: %v", testCase.ExpectedFindings[i], findings[i])
+					test.Fail()
+				}
+			}
+		})
+	}
+}
+
+func TestValidateWrongDictionary(t *testing.T) {
+	// Codec8 packet from FM1100 validated against the FMBXY dictionary
+	stringData := `01e4cafe0128000f333532303934303839333937343634080400000163c803eb02010a2524c01d4a377d00d3012f130032421b0a4503f00150051503ef01510052005900be00c1000ab50008b60006426fd8cd3d1ece605a5400005500007300005a0000c0000007c70000000df1000059d910002d33c65300000000570000000064000000f7bf000000000000000163c803e6e8010a2530781d4a316f00d40131130031421b0a4503f00150051503ef01510052005900be00c1000ab50008b60005426fcbcd3d1ece605a5400005500007300005a0000c0000007c70000000ef1000059d910002d33b95300000000570000000064000000f7bf000000000000000163c803df18010a2536961d4a2e4f00d50134130033421b0a4503f00150051503ef01510052005900be00c1000ab50008b6000542702bcd3d1ece605a5400005500007300005a0000c0000007c70000001ef1000059d910002d33aa5300000000570000000064000000f7bf000000000000000163c8039ce2010a25d8d41d49f42c00dc0123120058421b0a4503f00150051503ef01510052005900be00c1000ab50009b60005427031cd79d8ce605a5400005500007300005a0000c0000007c700000019f1000059d910002d32505300000000570000000064000000f7bf000000000004`
+
+	bs, _ := hex.DecodeString(stringData)
+	decoded, err := Decode(&bs)
+	if err != nil {
+		t.Fatalf("Failed to decode packet. %v", err)
+	}
+
+	humanDecoder := HumanDecoder{}
+
+	findings, err := humanDecoder.Validate(&decoded, "FM11XY")
+	if err != nil {
+		t.Fatalf("Failed to validate packet. %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Expected no findings for the right dictionary, got %v", findings)
+	}
+
+	findings, err = humanDecoder.Validate(&decoded, "FMBXY")
+	if err != nil {
+		t.Fatalf("Failed to validate packet. %v", err)
+	}
+	if len(findings) == 0 {
+		t.Errorf("Expected findings for the wrong dictionary")
+	}
+
+	if _, err := humanDecoder.Validate(&decoded, "FMXXX"); err == nil {
+		t.Errorf("Expected an error for unknown device family")
+	}
+}